@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"io"
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/pkg/errors"
@@ -14,6 +16,14 @@ type Client struct {
 	transport             Transport
 	defaultParseMode      ParseMode
 	defaultWebPagePreview bool
+
+	logger      Logger
+	loggingOpts []LoggingOption
+
+	observers []Observer
+
+	serverURL  string
+	localFiles bool
 }
 
 // ClientOption represents client option.
@@ -40,6 +50,36 @@ func WithWebPagePreview(enable bool) ClientOption {
 	}
 }
 
+// WithLogger enables logging of client requests.
+// Transport of client is wrapped by LoggingTransport regardless of options order.
+func WithLogger(logger Logger, opts ...LoggingOption) ClientOption {
+	return func(c *Client) {
+		c.logger = logger
+		c.loggingOpts = opts
+	}
+}
+
+// WithServerURL sets URL of Bot API server, e.g. self-hosted telegram-bot-api.
+// Applied to HTTPTransport of client regardless of options order,
+// HTTPTransport passed by WithTransport is copied, so it is not changed.
+// Other transports should be configured to use server by itself.
+func WithServerURL(url string) ClientOption {
+	return func(c *Client) {
+		c.serverURL = url
+	}
+}
+
+// WithLocalServer sets URL of self-hosted telegram-bot-api server
+// running in local mode (--local flag).
+// Local server returns absolute paths of files in File.Path,
+// so files are read from local filesystem instead of downloading.
+func WithLocalServer(url string) ClientOption {
+	return func(c *Client) {
+		c.serverURL = url
+		c.localFiles = true
+	}
+}
+
 // NewClient creates a Telegram Bot API client.
 func NewClient(token string, options ...ClientOption) *Client {
 	client := &Client{
@@ -54,9 +94,32 @@ func NewClient(token string, options ...ClientOption) *Client {
 		option(client)
 	}
 
+	if client.serverURL != "" {
+		client.transport = withTransportServerURL(client.transport, client.serverURL)
+	}
+
+	if client.logger != nil {
+		client.transport = NewLoggingTransport(client.transport, client.logger, client.loggingOpts...)
+	}
+
 	return client
 }
 
+// withTransportServerURL returns copy of HTTPTransport configured to use server,
+// transport can be shared with other clients, so it is not changed.
+// Other transports are returned as is.
+func withTransportServerURL(transport Transport, server string) Transport {
+	t, ok := transport.(*HTTPTransport)
+	if !ok {
+		return transport
+	}
+
+	own := *t
+	WithHTTPServerURL(server)(&own)
+
+	return &own
+}
+
 // Invoke request.
 func (client *Client) Invoke(
 	ctx context.Context,
@@ -65,14 +128,55 @@ func (client *Client) Invoke(
 ) error {
 	req = req.WithToken(client.token)
 
+	if len(client.observers) == 0 {
+		return client.invoke(ctx, req, dst)
+	}
+
+	info := &CallInfo{
+		Method: req.Method(),
+		Start:  time.Now(),
+	}
+
+	ctx = client.beforeCall(ctx, info)
+
+	req, uploaded := observeUploads(req)
+
+	res, err := client.transport.Execute(ctx, req)
+
+	result := &CallResult{
+		UploadBytes: uploaded(),
+		Err:         err,
+	}
+
+	if err == nil {
+		result.StatusCode = res.StatusCode
+		result.ErrorCode = res.ErrorCode
+
+		err = client.handleResponse(res, dst)
+		result.Err = err
+	}
+
+	client.afterCall(ctx, info, result)
+
+	return err
+}
+
+func (client *Client) invoke(ctx context.Context, req *Request, dst interface{}) error {
 	res, err := client.transport.Execute(ctx, req)
 	if err != nil {
 		return err
 	}
 
-	// TODO: handle bot api errors here
+	return client.handleResponse(res, dst)
+}
+
+func (client *Client) handleResponse(res *Response, dst interface{}) error {
 	if !res.OK {
-		return errors.New(res.Description)
+		return &Error{
+			Code:        res.ErrorCode,
+			Description: res.Description,
+			Parameters:  res.Parameters,
+		}
 	}
 
 	if dst != nil {
@@ -96,6 +200,28 @@ func (client *Client) GetMe(
 	return
 }
 
+// LogOut use this method to log out from the cloud Bot API server before launching the bot locally.
+// After successful call bot can't be logged in back to cloud server for 10 minutes.
+//
+// Source: https://core.telegram.org/bots/api#logout
+func (client *Client) LogOut(ctx context.Context) error {
+	return client.Invoke(ctx,
+		NewRequest("logOut"),
+		nil,
+	)
+}
+
+// Close use this method to close the bot instance before moving it from one local server to another.
+// Delete webhook before calling this method to ensure that the bot isn't launched again after server restart.
+//
+// Source: https://core.telegram.org/bots/api#close
+func (client *Client) Close(ctx context.Context) error {
+	return client.Invoke(ctx,
+		NewRequest("close"),
+		nil,
+	)
+}
+
 // GetFile returns file info and path to download.
 //
 // Source: https://core.telegram.org/bots/api#getfile
@@ -119,11 +245,185 @@ func (client *Client) GetFile(
 }
 
 // DownloadFile downloads file by path.
+// If client uses local server, absolute path is opened from local filesystem.
 func (client *Client) DownloadFile(
 	ctx context.Context,
 	path string,
 ) (io.ReadCloser, error) {
-	return client.transport.Download(ctx, client.token, path)
+	return client.download(ctx, path, 0)
+}
+
+func (client *Client) download(ctx context.Context, path string, offset int64) (io.ReadCloser, error) {
+	if len(client.observers) == 0 {
+		return client.downloadRange(ctx, path, offset)
+	}
+
+	info := &CallInfo{
+		Path:  path,
+		Start: time.Now(),
+	}
+
+	ctx = client.beforeCall(ctx, info)
+
+	body, err := client.downloadRange(ctx, path, offset)
+
+	client.afterCall(ctx, info, &CallResult{Err: err})
+
+	return body, err
+}
+
+// downloadRange downloads file from offset,
+// transports without range support download whole file and skip first offset bytes.
+func (client *Client) downloadRange(ctx context.Context, path string, offset int64) (io.ReadCloser, error) {
+	if client.localFiles && filepath.IsAbs(path) {
+		return openLocalFile(path, offset)
+	}
+
+	return downloadFromTransport(ctx, client.transport, client.token, path, offset)
+}
+
+// openLocalFile opens file stored by local Bot API server from offset byte.
+func openLocalFile(path string, offset int64) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "open local file")
+	}
+
+	if offset > 0 {
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			file.Close()
+			return nil, errors.Wrap(err, "seek local file")
+		}
+	}
+
+	return file, nil
+}
+
+var (
+	// ErrDownloadTooLarge returned by Client.DownloadFileTo, if file exceeds DownloadOptions.MaxSize.
+	ErrDownloadTooLarge = errors.New("file is too large")
+
+	// ErrDownloadSizeMismatch returned by Client.DownloadFileTo,
+	// if size of downloaded content is not equal to File.Size.
+	ErrDownloadSizeMismatch = errors.New("downloaded size mismatch")
+)
+
+// DownloadOptions contains options for Client.DownloadFileTo and Client.DownloadFileToPath.
+type DownloadOptions struct {
+	// Optional. Maximum size of file in bytes, no limit if zero.
+	MaxSize int64
+
+	// Optional. Continue download to existing partially downloaded file.
+	// Used only by DownloadFileToPath.
+	Resume bool
+}
+
+func (opts *DownloadOptions) maxSize() int64 {
+	if opts == nil {
+		return 0
+	}
+	return opts.MaxSize
+}
+
+func (opts *DownloadOptions) resume() bool {
+	return opts != nil && opts.Resume
+}
+
+// DownloadFileTo gets file info by id and writes content of file to dst.
+// Size of downloaded content is verified with File.Size.
+func (client *Client) DownloadFileTo(
+	ctx context.Context,
+	id FileID,
+	dst io.Writer,
+	opts *DownloadOptions,
+) (*File, error) {
+	file, err := client.GetFile(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "get file")
+	}
+
+	return file, client.downloadTo(ctx, file, dst, 0, opts)
+}
+
+// DownloadFileToPath gets file info by id and writes content of file to local path.
+// With DownloadOptions.Resume partially downloaded file is continued from its size,
+// so interrupted download can be repeated.
+func (client *Client) DownloadFileToPath(
+	ctx context.Context,
+	id FileID,
+	path string,
+	opts *DownloadOptions,
+) (*File, error) {
+	file, err := client.GetFile(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "get file")
+	}
+
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+
+	var offset int64
+
+	if opts.resume() && file.Size > 0 {
+		if info, err := os.Stat(path); err == nil && info.Size() <= int64(file.Size) {
+			offset = info.Size()
+			flag = os.O_WRONLY | os.O_APPEND
+		}
+	}
+
+	if offset > 0 && offset == int64(file.Size) {
+		return file, nil
+	}
+
+	output, err := os.OpenFile(path, flag, 0644)
+	if err != nil {
+		return file, errors.Wrap(err, "open output file")
+	}
+
+	err = client.downloadTo(ctx, file, output, offset, opts)
+
+	if closeErr := output.Close(); err == nil && closeErr != nil {
+		err = errors.Wrap(closeErr, "close output file")
+	}
+
+	return file, err
+}
+
+func (client *Client) downloadTo(ctx context.Context, file *File, dst io.Writer, offset int64, opts *DownloadOptions) error {
+	maxSize := opts.maxSize()
+
+	if maxSize > 0 && int64(file.Size) > maxSize {
+		return errors.Wrapf(ErrDownloadTooLarge, "file size %d exceeds %d", file.Size, maxSize)
+	}
+
+	body, err := client.download(ctx, file.Path, offset)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	var src io.Reader = body
+
+	// read one byte more than limit to detect oversized content
+	if maxSize > 0 {
+		src = io.LimitReader(body, maxSize-offset+1)
+	}
+
+	n, err := io.Copy(dst, src)
+	if err != nil {
+		return errors.Wrap(err, "copy file content")
+	}
+
+	size := offset + n
+
+	if maxSize > 0 && size > maxSize {
+		return errors.Wrapf(ErrDownloadTooLarge, "content exceeds %d", maxSize)
+	}
+
+	if file.Size > 0 && size != int64(file.Size) {
+		return errors.Wrapf(ErrDownloadSizeMismatch, "expected %d bytes, got %d", file.Size, size)
+	}
+
+	return nil
 }
 
 // ProfilePhotosOptions contains options for method GetUserProfilePhotos.
@@ -1,5 +1,3 @@
-// +build integration
-
 package tg
 
 import (
@@ -11,10 +9,69 @@ import (
 	"testing"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// Integration tests run in one of modes:
+//  - live: if TEST_BOT_LIVE=1 and TEST_BOT_TOKEN are set, tests are executed against real Bot API
+//    (or server from TEST_BOT_API_SERVER). Set TEST_BOT_RECORD=1 to save interactions to cassette.
+//  - replay: if cassette exists, recorded interactions are served by ReplayTransport.
+//    Cassette must be recorded against real Bot API, so it is not shipped with repository.
+//  - otherwise tests are skipped.
+const integrationCassettePath = "testdata/cassettes/integration.json"
+
+type integrationConfig struct {
+	// Bot Username
+	Username Username
+
+	// Bot ID
+	ID UserID
+
+	// Any file_id available for bot
+	FileID FileID
+
+	// Any user id who has conversation with bot
+	ExampleUserID UserID
+
+	// Any channel where bot is admin.
+	ExampleChannelID ChatID
+
+	// Time of recording, used in requests instead of current time.
+	Now int64
+}
+
+func (config integrationConfig) meta() map[string]string {
+	return map[string]string{
+		"username":           string(config.Username),
+		"id":                 strconv.Itoa(int(config.ID)),
+		"file_id":            string(config.FileID),
+		"example_user_id":    strconv.Itoa(int(config.ExampleUserID)),
+		"example_channel_id": strconv.FormatInt(int64(config.ExampleChannelID), 10),
+		"now":                strconv.FormatInt(config.Now, 10),
+	}
+}
+
+func parseIntegrationConfig(get func(k string) string) integrationConfig {
+	getInt := func(k string) int64 {
+		result, err := strconv.ParseInt(get(k), 10, 64)
+		if err != nil {
+			panic(fmt.Sprintf("'%s' is not a number: %v", k, err))
+		}
+		return result
+	}
+
+	return integrationConfig{
+		Username:         Username(get("username")),
+		ID:               UserID(getInt("id")),
+		FileID:           FileID(get("file_id")),
+		ExampleUserID:    UserID(getInt("example_user_id")),
+		ExampleChannelID: ChatID(getInt("example_channel_id")),
+		Now:              getInt("now"),
+	}
+}
+
 func getEnv(k string) string {
 	v := os.Getenv(k)
 	if v == "" {
@@ -23,55 +80,116 @@ func getEnv(k string) string {
 	return v
 }
 
-func getEnvInt(k string) int {
-	v := getEnv(k)
+var (
+	config              integrationConfig
+	integrationCassette *Cassette
+	integrationClient   *Client
+	integrationSkip     string
+)
+
+// setupIntegration configures client and returns function called after tests.
+func setupIntegration() func() error {
+	if os.Getenv("TEST_BOT_LIVE") != "" {
+		token := getEnv("TEST_BOT_TOKEN")
+
+		envs := map[string]string{
+			"username":           "TEST_BOT_USERNAME",
+			"id":                 "TEST_BOT_ID",
+			"file_id":            "TEST_BOT_FILE_ID",
+			"example_user_id":    "TEST_BOT_EXAMPLE_USER_ID",
+			"example_channel_id": "TEST_EXAMPLE_CHANNEL_ID",
+		}
+
+		config = parseIntegrationConfig(func(k string) string {
+			if k == "now" {
+				return strconv.FormatInt(time.Now().Unix(), 10)
+			}
+			return getEnv(envs[k])
+		})
+
+		server := os.Getenv("TEST_BOT_API_SERVER")
+		if server == "" {
+			server = DefaultServerURL
+		}
+
+		transport := NewHTTPTransport(WithHTTPServerURL(server))
+
+		if os.Getenv("TEST_BOT_RECORD") == "" {
+			integrationClient = NewClient(token, WithTransport(transport))
+			return func() error { return nil }
+		}
+
+		recorder := NewRecordingTransport(transport)
+		integrationClient = NewClient(token, WithTransport(recorder))
 
-	result, err := strconv.Atoi(v)
-	if err != nil {
+		return func() error {
+			cassette := recorder.Cassette()
+			cassette.Meta = config.meta()
+			cassette.Meta["api_server"] = server
+			return cassette.Save(integrationCassettePath)
+		}
+	}
+
+	cassette, err := LoadCassette(integrationCassettePath)
+	if os.IsNotExist(errors.Cause(err)) {
+		integrationSkip = "TEST_BOT_LIVE is not set and cassette " + integrationCassettePath + " does not exist"
+		return func() error { return nil }
+	} else if err != nil {
 		panic(err)
 	}
-	return result
+
+	config = parseIntegrationConfig(func(k string) string {
+		return cassette.Meta[k]
+	})
+
+	integrationCassette = cassette
+
+	return func() error { return nil }
 }
 
-var (
-	config = struct {
-		// Bot Token
-		Token string
-		// Bot Username
-		Username Username
-
-		// Bot ID
-		ID UserID
-
-		// Any file_id available for bot
-		FileID FileID
-
-		// Any user id who has conversation with bot
-		ExampleUserID UserID
-
-		// Any channel where bot is admin.
-		ExampleChannelID ChatID
-	}{
-		Token:            getEnv("TEST_BOT_TOKEN"),
-		Username:         Username(getEnv("TEST_BOT_USERNAME")),
-		ID:               UserID(getEnvInt("TEST_BOT_ID")),
-		FileID:           FileID(getEnv("TEST_BOT_FILE_ID")),
-		ExampleUserID:    UserID(getEnvInt("TEST_BOT_EXAMPLE_USER_ID")),
-		ExampleChannelID: ChatID(getEnvInt("TEST_EXAMPLE_CHANNEL_ID")),
+func TestMain(m *testing.M) {
+	teardown := setupIntegration()
+
+	code := m.Run()
+
+	if err := teardown(); err != nil {
+		fmt.Fprintf(os.Stderr, "integration teardown: %v\n", err)
+		code = 1
 	}
 
-	integrationClient = NewClient(config.Token)
-)
+	os.Exit(code)
+}
+
+// newIntegrationClient returns client used by integration test.
+// In replay mode each test gets own ReplayTransport,
+// because recorded interaction can be served only once.
+func newIntegrationClient(t *testing.T) *Client {
+	t.Helper()
+
+	if integrationSkip != "" {
+		t.Skip(integrationSkip)
+	}
+
+	if integrationCassette != nil {
+		return NewClient(CassetteTokenPlaceholder, WithTransport(NewReplayTransport(integrationCassette)))
+	}
+
+	return integrationClient
+}
 
 func TestClient_GetMe_Integration(t *testing.T) {
-	bot, err := integrationClient.GetMe(context.Background())
+	client := newIntegrationClient(t)
+
+	bot, err := client.GetMe(context.Background())
 	require.NoError(t, err)
 	assert.Equal(t, config.ID, bot.ID)
 	assert.Equal(t, config.Username, bot.Username)
 }
 
 func TestClient_Integration_GetFile(t *testing.T) {
-	file, err := integrationClient.GetFile(
+	client := newIntegrationClient(t)
+
+	file, err := client.GetFile(
 		context.Background(),
 		config.FileID,
 	)
@@ -91,7 +209,9 @@ func TestClient_Integration_GetFile(t *testing.T) {
 }
 
 func TestClient_Integration_GetUserProfilePhotos(t *testing.T) {
-	profilePhotos, err := integrationClient.GetUserProfilePhotos(
+	client := newIntegrationClient(t)
+
+	profilePhotos, err := client.GetUserProfilePhotos(
 		context.Background(),
 		config.ExampleUserID,
 		nil,
@@ -108,8 +228,10 @@ func TestClient_Integration_GetUserProfilePhotos(t *testing.T) {
 }
 
 func TestClient_GetChat_Integration(t *testing.T) {
+	client := newIntegrationClient(t)
+
 	t.Run("Channel", func(t *testing.T) {
-		chat, err := integrationClient.GetChat(
+		chat, err := client.GetChat(
 			context.Background(),
 			config.ExampleChannelID,
 		)
@@ -122,7 +244,7 @@ func TestClient_GetChat_Integration(t *testing.T) {
 	})
 
 	t.Run("Private", func(t *testing.T) {
-		chat, err := integrationClient.GetChat(
+		chat, err := client.GetChat(
 			context.Background(),
 			config.ExampleUserID,
 		)
@@ -135,7 +257,7 @@ func TestClient_GetChat_Integration(t *testing.T) {
 	})
 
 	t.Run("Error", func(t *testing.T) {
-		chat, err := integrationClient.GetChat(
+		chat, err := client.GetChat(
 			context.Background(),
 			UserID(1),
 		)
@@ -146,27 +268,33 @@ func TestClient_GetChat_Integration(t *testing.T) {
 }
 
 func TestClient_SetChatTitle_Integration(t *testing.T) {
-	err := integrationClient.SetChatTitle(
+	client := newIntegrationClient(t)
+
+	err := client.SetChatTitle(
 		context.Background(),
 		config.ExampleChannelID,
-		fmt.Sprintf("mr-linch/go-tg integration tests [%d]", time.Now().Unix()),
+		fmt.Sprintf("mr-linch/go-tg integration tests [%d]", config.Now),
 	)
 
 	assert.NoError(t, err)
 }
 
 func TestClient_SetChatDescription_Integration(t *testing.T) {
-	err := integrationClient.SetChatDescription(
+	client := newIntegrationClient(t)
+
+	err := client.SetChatDescription(
 		context.Background(),
 		config.ExampleChannelID,
-		fmt.Sprintf("this channel is used for integration tests of github.com/mr-linch/go-tg\n\n last run: [%d]", time.Now().Unix()),
+		fmt.Sprintf("this channel is used for integration tests of github.com/mr-linch/go-tg\n\n last run: [%d]", config.Now),
 	)
 
 	assert.NoError(t, err)
 }
 
 func TestClient_GetChatMembersCount_Integration(t *testing.T) {
-	count, err := integrationClient.GetChatMembersCount(
+	client := newIntegrationClient(t)
+
+	count, err := client.GetChatMembersCount(
 		context.Background(),
 		config.ExampleChannelID,
 	)
@@ -176,7 +304,9 @@ func TestClient_GetChatMembersCount_Integration(t *testing.T) {
 }
 
 func TestClient_GetChatAdministrators_Integration(t *testing.T) {
-	admins, err := integrationClient.GetChatAdministrators(
+	client := newIntegrationClient(t)
+
+	admins, err := client.GetChatAdministrators(
 		context.Background(),
 		config.ExampleChannelID,
 	)
@@ -187,6 +317,8 @@ func TestClient_GetChatAdministrators_Integration(t *testing.T) {
 }
 
 func TestClient_Send_TextMessage_Integration(t *testing.T) {
+	client := newIntegrationClient(t)
+
 	msg := NewTextMessage(config.ExampleChannelID, "*Text*: `TestClient_Send_TextMessage_Integration`").
 		WithParseMode(Markdown).
 		WithNotification(false).
@@ -205,12 +337,12 @@ func TestClient_Send_TextMessage_Integration(t *testing.T) {
 	sended := Message{}
 	ctx := context.Background()
 
-	err := integrationClient.Send(ctx, msg, &sended)
+	err := client.Send(ctx, msg, &sended)
 	require.NoError(t, err)
 	require.NotZero(t, sended.ID)
 
 	t.Run("ForwardMessage", func(t *testing.T) {
-		err := integrationClient.Send(ctx, NewForwardMessage(
+		err := client.Send(ctx, NewForwardMessage(
 			config.ExampleUserID,
 			sended,
 		), nil)
@@ -220,6 +352,7 @@ func TestClient_Send_TextMessage_Integration(t *testing.T) {
 }
 
 func TestClient_Send_PhotoMessage_Integration(t *testing.T) {
+	client := newIntegrationClient(t)
 
 	photo, err := NewInputFileLocal("testdata/go-work.png")
 	require.NoError(t, err, "no test data!")
@@ -240,7 +373,7 @@ func TestClient_Send_PhotoMessage_Integration(t *testing.T) {
 			),
 		)
 
-	err = integrationClient.Send(
+	err = client.Send(
 		context.Background(),
 		msg,
 		nil,
@@ -250,6 +383,8 @@ func TestClient_Send_PhotoMessage_Integration(t *testing.T) {
 }
 
 func TestClient_Send_AudioMessage_Integration(t *testing.T) {
+	client := newIntegrationClient(t)
+
 	// open audio file
 	audioFile, err := NewInputFileLocal("testdata/audio.mp3")
 	require.NoError(t, err, "no test data!")
@@ -279,7 +414,7 @@ func TestClient_Send_AudioMessage_Integration(t *testing.T) {
 			),
 		)
 
-	err = integrationClient.Send(
+	err = client.Send(
 		context.Background(),
 		msg,
 		nil,
@@ -289,6 +424,8 @@ func TestClient_Send_AudioMessage_Integration(t *testing.T) {
 }
 
 func TestClient_Webhook_Integration(t *testing.T) {
+	client := newIntegrationClient(t)
+
 	const (
 		url            = "https://httpbin.org/status/200"
 		maxConnections = 1
@@ -299,7 +436,7 @@ func TestClient_Webhook_Integration(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("Install", func(t *testing.T) {
-		err := integrationClient.SetWebhook(ctx, url, &WebhookOptions{
+		err := client.SetWebhook(ctx, url, &WebhookOptions{
 			MaxConnections: maxConnections,
 			AllowedUpdates: allowedUpdates,
 		})
@@ -308,7 +445,7 @@ func TestClient_Webhook_Integration(t *testing.T) {
 	})
 
 	t.Run("Retrieve", func(t *testing.T) {
-		info, err := integrationClient.GetWebhookInfo(ctx)
+		info, err := client.GetWebhookInfo(ctx)
 
 		if assert.NoError(t, err) && assert.NotNil(t, info) {
 			assert.Equal(t, url, info.URL)
@@ -318,19 +455,19 @@ func TestClient_Webhook_Integration(t *testing.T) {
 	})
 
 	t.Run("Delete", func(t *testing.T) {
-		err := integrationClient.DeleteWebhook(ctx)
+		err := client.DeleteWebhook(ctx)
 		assert.NoError(t, err)
 	})
 
 	t.Run("RetrieveAfterDelete", func(t *testing.T) {
-		info, err := integrationClient.GetWebhookInfo(ctx)
+		info, err := client.GetWebhookInfo(ctx)
 
 		if assert.NoError(t, err) && assert.NotNil(t, info) {
 			assert.Empty(t, info.URL)
 		}
 	})
 
-	info, err := integrationClient.GetWebhookInfo(context.Background())
+	info, err := client.GetWebhookInfo(context.Background())
 
 	assert.NoError(t, err)
 	assert.NotNil(t, info)
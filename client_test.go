@@ -1,14 +1,21 @@
 package tg
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var (
@@ -93,6 +100,32 @@ func TestClient_Invoke(t *testing.T) {
 		assert.Equal(t, exceptedError, err)
 	})
 
+	t.Run("APIError", func(t *testing.T) {
+		transport := &TransportMock{
+			ExecuteFunc: func(ctx context.Context, r *Request) (*Response, error) {
+				return &Response{
+					StatusCode:  429,
+					ErrorCode:   429,
+					Description: "Too Many Requests: retry after 5",
+					Parameters:  &ResponseParameters{RetryAfter: 5},
+				}, nil
+			},
+		}
+
+		client := NewClient("1234:secret",
+			WithTransport(transport),
+		)
+
+		err := client.Invoke(ctx, NewRequest("sendMessage"), nil)
+
+		assert.EqualError(t, err, "Too Many Requests: retry after 5")
+		assert.Equal(t, &Error{
+			Code:        429,
+			Description: "Too Many Requests: retry after 5",
+			Parameters:  &ResponseParameters{RetryAfter: 5},
+		}, err)
+	})
+
 	t.Run("UnmarshalError", func(t *testing.T) {
 		transport := &TransportMock{
 			ExecuteFunc: func(ctx context.Context, r *Request) (*Response, error) {
@@ -369,3 +402,169 @@ func TestClient_SetWebhook(t *testing.T) {
 	})
 
 }
+
+func TestClient_DownloadFileTo(t *testing.T) {
+	ctx := context.Background()
+
+	newClient := func(size int, content string) *Client {
+		return NewClient("1234:secret", WithTransport(&TransportMock{
+			ExecuteFunc: func(ctx context.Context, r *Request) (*Response, error) {
+				assert.Equal(t, "getFile", r.Method())
+
+				return &Response{
+					OK:     true,
+					Result: json.RawMessage(`{"file_id":"file","file_size":` + strconv.Itoa(size) + `,"file_path":"documents/file.txt"}`),
+				}, nil
+			},
+			DownloadFunc: func(ctx context.Context, token string, path string) (io.ReadCloser, error) {
+				assert.Equal(t, "documents/file.txt", path)
+				return ioutil.NopCloser(strings.NewReader(content)), nil
+			},
+		}))
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+
+		file, err := newClient(4, "test").DownloadFileTo(ctx, FileID("file"), buf, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "documents/file.txt", file.Path)
+		assert.Equal(t, "test", buf.String())
+	})
+
+	t.Run("SizeMismatch", func(t *testing.T) {
+		_, err := newClient(10, "test").DownloadFileTo(ctx, FileID("file"), ioutil.Discard, nil)
+		assert.Equal(t, ErrDownloadSizeMismatch, errors.Cause(err))
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		_, err := newClient(10, "test, test").DownloadFileTo(ctx, FileID("file"), ioutil.Discard, &DownloadOptions{
+			MaxSize: 5,
+		})
+		assert.Equal(t, ErrDownloadTooLarge, errors.Cause(err))
+	})
+
+	t.Run("TooLargeContent", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+
+		// size is unknown, so content is limited during download
+		_, err := newClient(0, "test, test").DownloadFileTo(ctx, FileID("file"), buf, &DownloadOptions{
+			MaxSize: 5,
+		})
+		assert.Equal(t, ErrDownloadTooLarge, errors.Cause(err))
+		assert.Equal(t, "test, ", buf.String())
+	})
+
+	t.Run("ResumePath", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "go-tg")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "file.txt")
+
+		require.NoError(t, ioutil.WriteFile(path, []byte("test, "), 0644))
+
+		// transport does not support range, so first bytes are skipped
+		_, err = newClient(10, "test, test").DownloadFileToPath(ctx, FileID("file"), path, &DownloadOptions{
+			Resume: true,
+		})
+		require.NoError(t, err)
+
+		content, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "test, test", string(content))
+	})
+
+	t.Run("OverwritePath", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "go-tg")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "file.txt")
+
+		require.NoError(t, ioutil.WriteFile(path, []byte("old content"), 0644))
+
+		_, err = newClient(4, "test").DownloadFileToPath(ctx, FileID("file"), path, nil)
+		require.NoError(t, err)
+
+		content, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "test", string(content))
+	})
+}
+
+func TestClient_LocalServer(t *testing.T) {
+	t.Run("ServerURL", func(t *testing.T) {
+		transport := NewHTTPTransport()
+
+		client := NewClient("1234:secret",
+			WithServerURL("http://localhost:8081"),
+			WithTransport(transport),
+		)
+
+		own, ok := client.transport.(*HTTPTransport)
+		require.True(t, ok)
+		assert.Equal(t, "http://localhost:8081/bot1234:secret/getMe", own.buildCallURL("1234:secret", "getMe"))
+
+		// transport passed by caller is not changed
+		assert.Equal(t, "https://api.telegram.org/bot1234:secret/getMe", transport.buildCallURL("1234:secret", "getMe"))
+	})
+
+	t.Run("LocalFiles", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "go-tg")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "file.txt")
+
+		require.NoError(t, ioutil.WriteFile(path, []byte("test, test"), 0644))
+
+		transport := &TransportMock{
+			ExecuteFunc: func(ctx context.Context, r *Request) (*Response, error) {
+				result, err := json.Marshal(File{ID: "file", Size: 10, Path: path})
+				require.NoError(t, err)
+
+				return &Response{OK: true, Result: result}, nil
+			},
+		}
+
+		client := NewClient("1234:secret",
+			WithTransport(transport),
+			WithLocalServer("http://localhost:8081"),
+		)
+
+		buf := &bytes.Buffer{}
+
+		_, err = client.DownloadFileTo(context.Background(), FileID("file"), buf, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "test, test", buf.String())
+
+		body, err := client.downloadRange(context.Background(), path, 6)
+		require.NoError(t, err)
+		defer body.Close()
+
+		content, err := ioutil.ReadAll(body)
+		require.NoError(t, err)
+		assert.Equal(t, "test", string(content))
+
+		assert.Len(t, transport.DownloadCalls(), 0, "local files are not downloaded")
+	})
+}
+
+func TestClient_LogOut(t *testing.T) {
+	request, err := FakeExecuteRequest(func(ctx context.Context, client *Client) error {
+		return client.LogOut(ctx)
+	}, ResponseResultTrue, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, "logOut", request.Method())
+}
+
+func TestClient_Close(t *testing.T) {
+	request, err := FakeExecuteRequest(func(ctx context.Context, client *Client) error {
+		return client.Close(ctx)
+	}, ResponseResultTrue, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, "close", request.Method())
+}
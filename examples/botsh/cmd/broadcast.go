@@ -0,0 +1,305 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mr-linch/go-tg"
+)
+
+// Statuses of broadcast recipients saved to progress file.
+const (
+	broadcastOK          = "ok"
+	broadcastBlocked     = "blocked"
+	broadcastNotFound    = "not_found"
+	broadcastFloodWaited = "flood_waited"
+	broadcastFailed      = "failed"
+)
+
+// isBroadcastDone returns true if recipient with status should not be retried on resume.
+func isBroadcastDone(status string) bool {
+	switch status {
+	case broadcastOK, broadcastBlocked, broadcastNotFound:
+		return true
+	default:
+		return false
+	}
+}
+
+// broadcastStatus returns status of recipient by error of send.
+// Result is broadcastOK for nil error.
+func broadcastStatus(err error) string {
+	if err == nil {
+		return broadcastOK
+	}
+
+	apiErr, ok := err.(*tg.Error)
+	if !ok {
+		return broadcastFailed
+	}
+
+	description := strings.ToLower(apiErr.Description)
+
+	switch {
+	case apiErr.Code == 429:
+		return broadcastFloodWaited
+	// bot was blocked or kicked by the user, user is deactivated, etc.
+	case apiErr.Code == 403:
+		return broadcastBlocked
+	case apiErr.Code == 400 && (strings.Contains(description, "chat not found") ||
+		strings.Contains(description, "user not found") ||
+		strings.Contains(description, "peer_id_invalid")):
+		return broadcastNotFound
+	default:
+		return broadcastFailed
+	}
+}
+
+// retryAfter returns time to wait before retry of request failed by flood control.
+func retryAfter(err error) (time.Duration, bool) {
+	apiErr, ok := err.(*tg.Error)
+	if !ok || apiErr.Code != 429 {
+		return 0, false
+	}
+
+	if apiErr.Parameters == nil || apiErr.Parameters.RetryAfter <= 0 {
+		return time.Second, true
+	}
+
+	return time.Duration(apiErr.Parameters.RetryAfter) * time.Second, true
+}
+
+// broadcastRecipient is peer from recipients file.
+type broadcastRecipient struct {
+	// Peer as specified in file, e.g. 42 or @channel.
+	ID   string
+	Peer tg.Peer
+}
+
+// readBroadcastRecipients reads peers from first column of CSV.
+// Empty lines and lines starting with # are ignored,
+// first line is treated as header if it does not contain valid peer.
+// Duplicate peers are ignored.
+func readBroadcastRecipients(r io.Reader) ([]broadcastRecipient, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	var (
+		recipients []broadcastRecipient
+		seen       = make(map[string]bool)
+	)
+
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		id := strings.TrimSpace(record[0])
+		if id == "" {
+			continue
+		}
+
+		peer, err := tg.ParsePeer(id)
+		if err != nil {
+			// header
+			if line == 1 {
+				continue
+			}
+
+			return nil, fmt.Errorf("line %d: invalid peer id: '%s'", line, id)
+		}
+
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		recipients = append(recipients, broadcastRecipient{
+			ID:   id,
+			Peer: peer,
+		})
+	}
+
+	return recipients, nil
+}
+
+// broadcastProgress is append-only CSV file with status of each processed recipient.
+// Columns: peer, status, message id, time and error.
+type broadcastProgress struct {
+	file   *os.File
+	writer *csv.Writer
+}
+
+// openBroadcastProgress opens progress file for append
+// and returns last saved status of each recipient.
+func openBroadcastProgress(path string) (*broadcastProgress, map[string]string, error) {
+	statuses := make(map[string]string)
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			file.Close()
+			return nil, nil, fmt.Errorf("read progress file: %v", err)
+		}
+
+		if len(record) >= 2 {
+			statuses[record[0]] = record[1]
+		}
+	}
+
+	return &broadcastProgress{
+		file:   file,
+		writer: csv.NewWriter(file),
+	}, statuses, nil
+}
+
+// Save appends status of recipient to file.
+func (progress *broadcastProgress) Save(id string, status string, msg *tg.Message, err error) error {
+	record := []string{id, status, "", time.Now().Format(time.RFC3339), ""}
+
+	if msg != nil {
+		record[2] = strconv.Itoa(int(msg.ID))
+	}
+
+	if err != nil {
+		record[4] = err.Error()
+	}
+
+	if err := progress.writer.Write(record); err != nil {
+		return err
+	}
+
+	progress.writer.Flush()
+
+	return progress.writer.Error()
+}
+
+func (progress *broadcastProgress) Close() error {
+	return progress.file.Close()
+}
+
+// broadcastSendFunc sends message to peer of message.
+type broadcastSendFunc func(ctx context.Context, msg *tg.TextMessage) (*tg.Message, error)
+
+// broadcaster sends message to each recipient and saves status of them to progress.
+type broadcaster struct {
+	// Message sent to recipients, peer is replaced for each recipient.
+	Message *tg.TextMessage
+
+	Send     broadcastSendFunc
+	Progress *broadcastProgress
+
+	// Log of processed recipients.
+	Log io.Writer
+}
+
+// Run sends message to recipients which are not done according to statuses of previous runs.
+// When ctx is canceled, recipient with unknown result of send and rest of recipients are left for next run.
+func (b *broadcaster) Run(ctx context.Context, recipients []broadcastRecipient, statuses map[string]string) (*broadcastReport, error) {
+	report := &broadcastReport{Total: len(recipients)}
+
+	for i, recipient := range recipients {
+		if isBroadcastDone(statuses[recipient.ID]) {
+			report.Skipped++
+			continue
+		}
+
+		if ctx.Err() != nil {
+			report.Remaining = pendingBroadcastRecipients(recipients[i:], statuses)
+			break
+		}
+
+		msg := *b.Message
+		msg.Peer = recipient.Peer
+
+		sent, err := b.Send(ctx, &msg)
+		if err != nil && ctx.Err() != nil {
+			// result of interrupted send is unknown, so recipient is retried by next run
+			report.Remaining = pendingBroadcastRecipients(recipients[i:], statuses)
+			break
+		}
+
+		status := broadcastStatus(err)
+		report.Add(status)
+
+		if err := b.Progress.Save(recipient.ID, status, sent, err); err != nil {
+			return nil, fmt.Errorf("save progress: %v", err)
+		}
+
+		if err != nil {
+			fmt.Fprintf(b.Log, "[%d/%d] %s: %s: %v\n", i+1, len(recipients), recipient.ID, status, err)
+		} else {
+			fmt.Fprintf(b.Log, "[%d/%d] %s: %s\n", i+1, len(recipients), recipient.ID, status)
+		}
+	}
+
+	return report, nil
+}
+
+// pendingBroadcastRecipients returns number of recipients which are not done.
+func pendingBroadcastRecipients(recipients []broadcastRecipient, statuses map[string]string) int {
+	n := 0
+
+	for _, recipient := range recipients {
+		if !isBroadcastDone(statuses[recipient.ID]) {
+			n++
+		}
+	}
+
+	return n
+}
+
+// broadcastReport is summary of broadcast run.
+type broadcastReport struct {
+	Total       int `json:"total"`
+	Skipped     int `json:"skipped"`
+	OK          int `json:"ok"`
+	Blocked     int `json:"blocked"`
+	NotFound    int `json:"not_found"`
+	FloodWaited int `json:"flood_waited"`
+	Failed      int `json:"failed"`
+
+	// Number of recipients not processed because of interrupt.
+	Remaining int `json:"remaining"`
+}
+
+func (report *broadcastReport) Add(status string) {
+	switch status {
+	case broadcastOK:
+		report.OK++
+	case broadcastBlocked:
+		report.Blocked++
+	case broadcastNotFound:
+		report.NotFound++
+	case broadcastFloodWaited:
+		report.FloodWaited++
+	default:
+		report.Failed++
+	}
+}
+
+// Incomplete returns number of recipients which should be retried by next run.
+func (report *broadcastReport) Incomplete() int {
+	return report.FloodWaited + report.Failed + report.Remaining
+}
@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mr-linch/go-tg"
+)
+
+// newTestBroadcast returns recipients and path of progress file with saved statuses.
+// Directory of progress file should be removed by caller.
+func newTestBroadcast(t *testing.T, ids string, saved map[string]string) ([]broadcastRecipient, string) {
+	t.Helper()
+
+	recipients, err := readBroadcastRecipients(strings.NewReader(ids))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "botsh-broadcast")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "recipients.csv.progress")
+
+	progress, _, err := openBroadcastProgress(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer progress.Close()
+
+	for _, recipient := range recipients {
+		if status, ok := saved[recipient.ID]; ok {
+			if err := progress.Save(recipient.ID, status, nil, nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	return recipients, path
+}
+
+func runTestBroadcast(
+	t *testing.T,
+	ctx context.Context,
+	recipients []broadcastRecipient,
+	path string,
+	send broadcastSendFunc,
+) (*broadcastReport, map[string]string) {
+	t.Helper()
+
+	progress, statuses, err := openBroadcastProgress(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer progress.Close()
+
+	b := &broadcaster{
+		Message:  tg.NewTextMessage(nil, "hello"),
+		Send:     send,
+		Progress: progress,
+		Log:      ioutil.Discard,
+	}
+
+	report, err := b.Run(ctx, recipients, statuses)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saved, statuses, err := openBroadcastProgress(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved.Close()
+
+	return report, statuses
+}
+
+func TestBroadcaster_Run(t *testing.T) {
+	t.Run("Resume", func(t *testing.T) {
+		recipients, path := newTestBroadcast(t, "id\n1\n2\n3\n4\n", map[string]string{
+			"1": broadcastOK,
+			"2": broadcastFloodWaited,
+			"3": broadcastBlocked,
+		})
+		defer os.RemoveAll(filepath.Dir(path))
+
+		var peers []string
+
+		report, statuses := runTestBroadcast(t, context.Background(), recipients, path,
+			func(ctx context.Context, msg *tg.TextMessage) (*tg.Message, error) {
+				peers = append(peers, fmt.Sprint(msg.Peer))
+				return &tg.Message{ID: 1}, nil
+			},
+		)
+
+		if want := []string{"2", "4"}; !reflect.DeepEqual(peers, want) {
+			t.Errorf("sent to %v, want %v", peers, want)
+		}
+
+		if want := (broadcastReport{Total: 4, Skipped: 2, OK: 2}); *report != want {
+			t.Errorf("report is %+v, want %+v", *report, want)
+		}
+
+		for _, id := range []string{"1", "2", "4"} {
+			if statuses[id] != broadcastOK {
+				t.Errorf("status of %s is '%s', want ok", id, statuses[id])
+			}
+		}
+	})
+
+	t.Run("InterruptedAfterSuccess", func(t *testing.T) {
+		recipients, path := newTestBroadcast(t, "1\n2\n3\n", map[string]string{
+			"3": broadcastOK,
+		})
+		defer os.RemoveAll(filepath.Dir(path))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		report, statuses := runTestBroadcast(t, ctx, recipients, path,
+			func(ctx context.Context, msg *tg.TextMessage) (*tg.Message, error) {
+				cancel()
+				return &tg.Message{ID: 1}, nil
+			},
+		)
+
+		if statuses["1"] != broadcastOK {
+			t.Errorf("status of successful send is '%s', want ok", statuses["1"])
+		}
+
+		if want := (broadcastReport{Total: 3, OK: 1, Remaining: 1}); *report != want {
+			t.Errorf("report is %+v, want %+v", *report, want)
+		}
+	})
+
+	t.Run("InterruptedSend", func(t *testing.T) {
+		recipients, path := newTestBroadcast(t, "1\n2\n", nil)
+		defer os.RemoveAll(filepath.Dir(path))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		report, statuses := runTestBroadcast(t, ctx, recipients, path,
+			func(ctx context.Context, msg *tg.TextMessage) (*tg.Message, error) {
+				cancel()
+				return nil, ctx.Err()
+			},
+		)
+
+		if len(statuses) != 0 {
+			t.Errorf("interrupted send is saved: %v", statuses)
+		}
+
+		if want := (broadcastReport{Total: 2, Remaining: 2}); *report != want {
+			t.Errorf("report is %+v, want %+v", *report, want)
+		}
+	})
+
+	t.Run("Statuses", func(t *testing.T) {
+		recipients, path := newTestBroadcast(t, "1\n2\n3\n4\n", nil)
+		defer os.RemoveAll(filepath.Dir(path))
+
+		errs := map[string]error{
+			"1": &tg.Error{Code: 403, Description: "Forbidden: bot was blocked by the user"},
+			"2": &tg.Error{Code: 400, Description: "Bad Request: chat not found"},
+			"3": &tg.Error{Code: 429, Description: "Too Many Requests: retry after 5"},
+			"4": errors.New("connection reset"),
+		}
+
+		report, statuses := runTestBroadcast(t, context.Background(), recipients, path,
+			func(ctx context.Context, msg *tg.TextMessage) (*tg.Message, error) {
+				return nil, errs[fmt.Sprint(msg.Peer)]
+			},
+		)
+
+		want := map[string]string{
+			"1": broadcastBlocked,
+			"2": broadcastNotFound,
+			"3": broadcastFloodWaited,
+			"4": broadcastFailed,
+		}
+
+		if !reflect.DeepEqual(statuses, want) {
+			t.Errorf("statuses are %v, want %v", statuses, want)
+		}
+
+		if n := report.Incomplete(); n != 2 {
+			t.Errorf("incomplete is %d, want 2", n)
+		}
+	})
+}
+
+func TestSendBroadcastMessage(t *testing.T) {
+	limiter := tg.NewTokenBucketLimiter(1000, time.Second, 1000)
+	msg := tg.NewTextMessage(tg.ChatID(1), "hello")
+
+	floodErr := &tg.Error{
+		Code:       429,
+		Parameters: &tg.ResponseParameters{RetryAfter: 1},
+	}
+
+	t.Run("Retry", func(t *testing.T) {
+		calls := 0
+
+		sent, err := sendBroadcastMessage(context.Background(), limiter, msg, 3,
+			func(ctx context.Context, msg *tg.TextMessage) (*tg.Message, error) {
+				calls++
+				if calls == 1 {
+					return nil, floodErr
+				}
+				return &tg.Message{ID: 1}, nil
+			},
+		)
+
+		if err != nil || sent == nil {
+			t.Fatalf("send failed: %v", err)
+		}
+
+		if calls != 2 {
+			t.Errorf("calls is %d, want 2", calls)
+		}
+	})
+
+	t.Run("MaxRetries", func(t *testing.T) {
+		calls := 0
+
+		_, err := sendBroadcastMessage(context.Background(), limiter, msg, 0,
+			func(ctx context.Context, msg *tg.TextMessage) (*tg.Message, error) {
+				calls++
+				return nil, floodErr
+			},
+		)
+
+		if err != floodErr {
+			t.Errorf("error is %v, want flood error", err)
+		}
+
+		if calls != 1 {
+			t.Errorf("calls is %d, want 1", calls)
+		}
+	})
+
+	t.Run("NotFlood", func(t *testing.T) {
+		calls := 0
+		blocked := &tg.Error{Code: 403}
+
+		_, err := sendBroadcastMessage(context.Background(), limiter, msg, 3,
+			func(ctx context.Context, msg *tg.TextMessage) (*tg.Message, error) {
+				calls++
+				return nil, blocked
+			},
+		)
+
+		if err != blocked || calls != 1 {
+			t.Errorf("error is %v after %d calls, want not retried error", err, calls)
+		}
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		_, err := sendBroadcastMessage(ctx, limiter, msg, 3,
+			func(ctx context.Context, msg *tg.TextMessage) (*tg.Message, error) {
+				cancel()
+				return nil, floodErr
+			},
+		)
+
+		if err != context.Canceled {
+			t.Errorf("error is %v, want context.Canceled", err)
+		}
+	})
+}
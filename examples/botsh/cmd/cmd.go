@@ -1,15 +1,19 @@
 package cmd
 
 import (
+	"io"
+	"os"
 	"time"
 
 	"github.com/urfave/cli"
+
+	"github.com/mr-linch/go-tg/examples/botsh/internal"
 )
 
 func flags(fs ...cli.Flag) []cli.Flag {
 	return append(fs, cli.StringFlag{
 		Name:  "format, f",
-		Usage: "Output format (json, pretty or custom template)",
+		Usage: "Output format (json, ndjson, pretty or custom template)",
 		Value: "pretty",
 	})
 }
@@ -27,8 +31,29 @@ func NewApp() *cli.App {
 	app.EnableBashCompletion = true
 	app.Usage = "Simple Telegram Bot API command-line client"
 	app.HideVersion = true
+	app.Metadata = make(map[string]interface{})
+
+	// token is redacted from all output, see applyProfile
+	app.Writer = internal.NewRedactWriter(os.Stdout)
+	app.ErrWriter = internal.NewRedactWriter(os.Stderr)
+
+	// exit errors are printed by cli package to its own writer
+	cli.ErrWriter = app.ErrWriter
+
+	app.Before = applyProfile
+	app.After = flushOutput
 
 	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:   "profile, P",
+			Usage:  "use settings of profile `NAME` from config file, flags and environment variables take precedence",
+			EnvVar: "BOTSH_PROFILE",
+		},
+		cli.StringFlag{
+			Name:   "config",
+			Usage:  "config `FILE` with profiles (default: ~/.config/botsh/config)",
+			EnvVar: "BOTSH_CONFIG",
+		},
 		cli.StringFlag{
 			Name:   "token",
 			Usage:  "Telegram Bot API token",
@@ -45,6 +70,16 @@ func NewApp() *cli.App {
 			EnvVar: "TELEGRAM_BOT_API_DOMAIN",
 			Value:  "api.telegram.org",
 		},
+		cli.StringFlag{
+			Name:   "api-server",
+			Usage:  "Telegram Bot API server URL, e.g. self-hosted server (overrides --api-domain)",
+			EnvVar: "TELEGRAM_BOT_API_SERVER",
+		},
+		cli.BoolFlag{
+			Name:   "local",
+			Usage:  "API server is running in local mode, files are read from local filesystem",
+			EnvVar: "TELEGRAM_BOT_API_LOCAL",
+		},
 	}
 
 	app.Commands = []cli.Command{
@@ -55,9 +90,35 @@ func NewApp() *cli.App {
 		getFileCommand,
 		getChatAdmins,
 		getChatMembersCount,
+		exportChatCommand,
 		deleteWebhookCommand,
 		getUpdatesCommand,
+		sendMessageCommand,
+		sendPhotoCommand,
+		sendAudioCommand,
+		sendDocumentCommand,
+		forwardMessageCommand,
+		broadcastCommand,
+		shellCommand,
+		callCommand,
+		serveWebhookCommand,
+		replayCommand,
+		webhookWatchCommand,
+		profileCommand,
 	}
 
 	return app
 }
+
+// flushOutput writes output kept by redact writers while waiting for rest of secret.
+func flushOutput(cliCtx *cli.Context) error {
+	for _, w := range []io.Writer{cliCtx.App.Writer, cliCtx.App.ErrWriter} {
+		if w, ok := w.(*internal.RedactWriter); ok {
+			if err := w.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/mr-linch/go-tg"
+	"github.com/mr-linch/go-tg/examples/botsh/internal"
+)
+
+var broadcastCommand = cli.Command{
+	Name:     "broadcast",
+	Category: "messages",
+	Usage:    "send text message to each peer from CSV file with rate limiting, interrupted broadcast is resumed by next run",
+
+	Before: func(cliCtx *cli.Context) error {
+		validateRecipients := func() error {
+			if !cliCtx.IsSet("recipients") {
+				return fmt.Errorf("--recipients: required")
+			}
+			return isFileAvailable(cliCtx.String("recipients"))
+		}
+
+		validateText := func() error {
+			if cliCtx.IsSet("text") == cliCtx.IsSet("text-file") {
+				return fmt.Errorf("one of --text or --text-file is required")
+			}
+			if path := cliCtx.String("text-file"); path != "" && path != "-" {
+				return isFileAvailable(path)
+			}
+			return nil
+		}
+
+		validateRate := func() error {
+			if cliCtx.Int("rate") < 1 {
+				return fmt.Errorf("--rate: should be positive")
+			}
+			return nil
+		}
+
+		validateSendOptions := func() error {
+			_, err := parseBroadcastMessage(cliCtx, "")
+			return err
+		}
+
+		return validate(
+			validateRecipients,
+			validateText,
+			validateRate,
+			validateSendOptions,
+		)
+	},
+
+	Action: internal.NewAction(func(ctx context.Context, cliCtx internal.CLIContext, client *tg.Client, output internal.Output) error {
+		text := cliCtx.String("text")
+
+		if path := cliCtx.String("text-file"); path != "" {
+			content, err := readTextFile(path)
+			if err != nil {
+				return err
+			}
+			text = content
+		}
+
+		msg, err := parseBroadcastMessage(cliCtx, text)
+		if err != nil {
+			return err
+		}
+
+		file, err := os.Open(cliCtx.String("recipients"))
+		if err != nil {
+			return err
+		}
+
+		recipients, err := readBroadcastRecipients(file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("read recipients: %v", err)
+		}
+
+		progressPath := cliCtx.String("progress")
+		if progressPath == "" {
+			progressPath = cliCtx.String("recipients") + ".progress"
+		}
+
+		progress, statuses, err := openBroadcastProgress(progressPath)
+		if err != nil {
+			return err
+		}
+		defer progress.Close()
+
+		// broadcast can take longer than request timeout, so each request has own timeout
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(signals)
+
+		go func() {
+			select {
+			case <-signals:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		limiter := tg.NewTokenBucketLimiter(cliCtx.Int("rate"), time.Second, cliCtx.Int("rate"))
+		timeout := cliCtx.GlobalDuration("request-timeout")
+		maxRetries := cliCtx.Int("max-retries")
+
+		send := func(ctx context.Context, msg *tg.TextMessage) (*tg.Message, error) {
+			return sendWithTimeout(ctx, client, msg, timeout)
+		}
+
+		b := &broadcaster{
+			Message: msg,
+			Send: func(ctx context.Context, msg *tg.TextMessage) (*tg.Message, error) {
+				return sendBroadcastMessage(ctx, limiter, msg, maxRetries, send)
+			},
+			Progress: progress,
+			Log:      cliCtx.App.ErrWriter,
+		}
+
+		report, err := b.Run(ctx, recipients, statuses)
+		if err != nil {
+			return err
+		}
+
+		if err := output.Print(report); err != nil {
+			return err
+		}
+
+		if n := report.Incomplete(); n > 0 {
+			return cli.NewExitError(
+				fmt.Sprintf("%d recipients are not completed, run same command again to resume", n),
+				1,
+			)
+		}
+
+		return nil
+	}),
+
+	Flags: flags(
+		cli.StringFlag{
+			Name:  "recipients",
+			Usage: "CSV `FILE` with peer ID or @username in first column",
+		},
+		cli.StringFlag{
+			Name:  "text",
+			Usage: "`TEXT` of message",
+		},
+		cli.StringFlag{
+			Name:  "text-file",
+			Usage: "read text of message from `FILE` (- for standard input)",
+		},
+		cli.StringFlag{
+			Name:  "progress",
+			Usage: "status of each recipient is appended to `FILE` (default: recipients file with .progress suffix)",
+		},
+		cli.IntFlag{
+			Name:  "rate",
+			Usage: "max messages per second",
+			Value: 25,
+		},
+		cli.IntFlag{
+			Name:  "max-retries",
+			Usage: "max retries of message after flood wait, recipient is left for next run when exceeded",
+			Value: 3,
+		},
+		cli.StringFlag{
+			Name:  "parse-mode, p",
+			Usage: "parse mode of text (plain, markdown or html)",
+			Value: "plain",
+		},
+		cli.BoolFlag{
+			Name:  "silent, s",
+			Usage: "send messages without notification",
+		},
+		cli.BoolFlag{
+			Name:  "no-preview",
+			Usage: "disable link preview of messages",
+		},
+		cli.StringFlag{
+			Name:  "keyboard, k",
+			Usage: "reply markup of messages as `JSON`",
+		},
+	),
+}
+
+// parseBroadcastMessage returns message with text and options from flags, peer is set later.
+func parseBroadcastMessage(cliCtx *cli.Context, text string) (*tg.TextMessage, error) {
+	pm, err := parseParseMode(cliCtx.String("parse-mode"))
+	if err != nil {
+		return nil, err
+	}
+
+	msg := tg.NewTextMessage(nil, text).
+		WithParseMode(pm).
+		WithWebPagePreview(!cliCtx.Bool("no-preview")).
+		WithNotification(!cliCtx.Bool("silent"))
+
+	if cliCtx.IsSet("keyboard") {
+		rm, err := parseReplyMarkup(cliCtx.String("keyboard"))
+		if err != nil {
+			return nil, err
+		}
+		msg = msg.WithReplyMarkup(rm)
+	}
+
+	return msg, nil
+}
+
+// readTextFile reads whole file or standard input if path is "-".
+func readTextFile(path string) (string, error) {
+	if path == "-" {
+		content, err := ioutil.ReadAll(os.Stdin)
+		return string(content), err
+	}
+
+	content, err := ioutil.ReadFile(path)
+	return string(content), err
+}
+
+// sendBroadcastMessage sends message, waits and retries it if flood control is exceeded.
+func sendBroadcastMessage(
+	ctx context.Context,
+	limiter tg.RateLimiter,
+	msg *tg.TextMessage,
+	maxRetries int,
+	send broadcastSendFunc,
+) (*tg.Message, error) {
+	for attempt := 0; ; attempt++ {
+		if err := limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		sent, err := send(ctx, msg)
+		if err == nil {
+			return sent, nil
+		}
+
+		delay, ok := retryAfter(err)
+		if !ok || attempt >= maxRetries {
+			return nil, err
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func sendWithTimeout(ctx context.Context, client *tg.Client, msg *tg.TextMessage, timeout time.Duration) (*tg.Message, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var sent tg.Message
+
+	if err := client.Send(ctx, msg, &sent); err != nil {
+		return nil, err
+	}
+
+	return &sent, nil
+}
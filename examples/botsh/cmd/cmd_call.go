@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli"
+
+	"github.com/mr-linch/go-tg"
+	"github.com/mr-linch/go-tg/examples/botsh/internal"
+)
+
+var callCommand = cli.Command{
+	Name:      "call",
+	Category:  "generic",
+	Usage:     "call any Bot API method, e.g. call sendMessage chat_id=42 text=hi, call sendPhoto chat_id=42 photo=@photo.jpg",
+	ArgsUsage: "METHOD [key=value | key=@FILE | key=@- ...]",
+
+	Before: func(cliCtx *cli.Context) error {
+		validateMethod := func() error {
+			if cliCtx.Args().First() == "" {
+				return fmt.Errorf("method: missing")
+			}
+			return nil
+		}
+
+		validateArgs := func() error {
+			for _, arg := range cliCtx.Args().Tail() {
+				if !strings.Contains(arg, "=") {
+					return fmt.Errorf("argument '%s': expected key=value", arg)
+				}
+			}
+			return nil
+		}
+
+		return validate(
+			validateMethod,
+			validateArgs,
+		)
+	},
+
+	Action: internal.NewAction(func(ctx context.Context, cliCtx internal.CLIContext, client *tg.Client, output internal.Output) error {
+		req := tg.NewRequest(cliCtx.Args().First())
+
+		for _, arg := range cliCtx.Args().Tail() {
+			kv := strings.SplitN(arg, "=", 2)
+			key, value := kv[0], kv[1]
+
+			if !strings.HasPrefix(value, "@") {
+				req.AddString(key, value)
+				continue
+			}
+
+			file, err := openCallFile(strings.TrimPrefix(value, "@"))
+			if err != nil {
+				return fmt.Errorf("argument '%s': %v", key, err)
+			}
+			defer file.Close()
+
+			req.AddFile(key, file)
+		}
+
+		var result json.RawMessage
+
+		if err := client.Invoke(ctx, req, &result); err != nil {
+			// print full API error, so error code and parameters are available in selected format
+			if apiErr, ok := err.(*tg.Error); ok {
+				if err := output.Print(apiErr); err != nil {
+					return err
+				}
+
+				return cli.NewExitError("", 1)
+			}
+
+			return err
+		}
+
+		return printCallResult(cliCtx, output, result)
+	}),
+
+	Flags: flags(),
+}
+
+// openCallFile opens file for upload, "-" is standard input.
+func openCallFile(path string) (tg.InputFile, error) {
+	if path == "-" {
+		return tg.NewInputFile("file", os.Stdin), nil
+	}
+
+	return tg.NewInputFileLocal(path)
+}
+
+// printCallResult prints raw result JSON, so numbers are not changed (e.g. big IDs printed as float).
+// Pretty format prints indented JSON, templates get result decoded with json.Number.
+func printCallResult(cliCtx *cli.Context, output internal.Output, result json.RawMessage) error {
+	switch internal.OutputFormat(cliCtx) {
+	case "pretty":
+		buf := &bytes.Buffer{}
+
+		if err := json.Indent(buf, result, "", "  "); err != nil {
+			return err
+		}
+
+		buf.WriteByte('\n')
+
+		_, err := output.Write(buf.Bytes())
+
+		return err
+	case "json", "ndjson":
+		return output.Print(result)
+	default:
+		var v interface{}
+
+		decoder := json.NewDecoder(bytes.NewReader(result))
+		decoder.UseNumber()
+
+		if err := decoder.Decode(&v); err != nil {
+			return err
+		}
+
+		return output.Print(v)
+	}
+}
@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/urfave/cli"
+
+	"github.com/mr-linch/go-tg"
+	"github.com/mr-linch/go-tg/examples/botsh/internal"
+)
+
+// chatExportFile is name of bundle file in export directory.
+const chatExportFile = "chat.json"
+
+// chatExport is snapshot of chat configuration.
+// It doesn't contain export time, so snapshots of unchanged chat are equal.
+type chatExport struct {
+	Chat           *tg.Chat          `json:"chat"`
+	Administrators []tg.ChatMember   `json:"administrators,omitempty"`
+	MembersCount   int               `json:"members_count"`
+	PinnedMessage  *tg.Message       `json:"pinned_message,omitempty"`
+	Photo          *chatExportPhotos `json:"photo,omitempty"`
+}
+
+// chatExportPhotos contains downloaded sizes of chat photo.
+type chatExportPhotos struct {
+	Small *chatExportPhoto `json:"small,omitempty"`
+	Big   *chatExportPhoto `json:"big,omitempty"`
+}
+
+// chatExportPhoto identifies photo by size and hash of content,
+// because file_id is changed by Telegram even if photo is not.
+type chatExportPhoto struct {
+	fileID tg.FileID
+
+	// Path relative to export directory, empty if bundle is printed.
+	Path string `json:"path,omitempty"`
+
+	// Size and hex encoded SHA-256 of file, so changes of photo are visible in bundle.
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+var exportChatCommand = cli.Command{
+	Name:      "export-chat",
+	Category:  "chats",
+	Usage:     "export chat info, administrators, members count, pinned message and photo to directory or print them as single object",
+	ArgsUsage: "PEER_ID [DIR]",
+
+	Before: func(cliCtx *cli.Context) error {
+		validatePeer := func() error {
+			if _, err := tg.ParsePeer(cliCtx.Args().First()); err != nil {
+				return fmt.Errorf("invalid peer id: '%s'", cliCtx.Args().First())
+			}
+			return nil
+		}
+
+		return validate(
+			validatePeer,
+		)
+	},
+
+	Action: internal.NewAction(func(ctx context.Context, cliCtx internal.CLIContext, client *tg.Client, output internal.Output) error {
+		peer, err := tg.ParsePeer(cliCtx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		export, err := exportChat(ctx, client, peer)
+		if err != nil {
+			return err
+		}
+
+		dir := cliCtx.Args().Get(1)
+
+		if dir != "" {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+		}
+
+		if cliCtx.Bool("no-photo") {
+			export.Photo = nil
+		}
+
+		if export.Photo != nil {
+			for name, photo := range map[string]*chatExportPhoto{
+				"photo_small.jpg": export.Photo.Small,
+				"photo_big.jpg":   export.Photo.Big,
+			} {
+				if err := downloadChatPhoto(ctx, client, photo, dir, name); err != nil {
+					return fmt.Errorf("download %s: %v", name, err)
+				}
+			}
+		}
+
+		if dir == "" {
+			return output.Print(export)
+		}
+
+		content, err := json.MarshalIndent(export, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		path := filepath.Join(dir, chatExportFile)
+
+		if err := ioutil.WriteFile(path, append(content, '\n'), 0644); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cliCtx.App.ErrWriter, "chat %d exported to %s\n", export.Chat.ID, dir)
+
+		return nil
+	}),
+
+	Flags: flags(
+		cli.BoolFlag{
+			Name:  "no-photo",
+			Usage: "don't download chat photo to DIR",
+		},
+	),
+}
+
+// exportChat gets chat and related objects.
+// Administrators are not requested for private chats.
+func exportChat(ctx context.Context, client *tg.Client, peer tg.Peer) (*chatExport, error) {
+	chat, err := client.GetChat(ctx, peer)
+	if err != nil {
+		return nil, fmt.Errorf("get chat: %v", err)
+	}
+
+	export := &chatExport{
+		Chat: chat,
+	}
+
+	if chat.Type != tg.PrivateChat {
+		admins, err := client.GetChatAdministrators(ctx, peer)
+		if err != nil {
+			return nil, fmt.Errorf("get chat administrators: %v", err)
+		}
+
+		// API doesn't guarantee order, sort to make snapshots comparable
+		sort.Slice(admins, func(i, j int) bool {
+			return admins[i].User.ID < admins[j].User.ID
+		})
+
+		export.Administrators = admins
+	}
+
+	export.MembersCount, err = client.GetChatMembersCount(ctx, peer)
+	if err != nil {
+		return nil, fmt.Errorf("get chat members count: %v", err)
+	}
+
+	if len(chat.PinnedMessage) > 0 {
+		export.PinnedMessage = &tg.Message{}
+
+		if err := json.Unmarshal(chat.PinnedMessage, export.PinnedMessage); err != nil {
+			return nil, fmt.Errorf("unmarshal pinned message: %v", err)
+		}
+
+		// pinned message is exported as separate field
+		chat.PinnedMessage = nil
+	}
+
+	if chat.Photo != nil {
+		export.Photo = &chatExportPhotos{
+			Small: &chatExportPhoto{fileID: chat.Photo.SmallFileID},
+			Big:   &chatExportPhoto{fileID: chat.Photo.BigFileID},
+		}
+
+		// photo is exported as separate field
+		chat.Photo = nil
+	}
+
+	return export, nil
+}
+
+// downloadChatPhoto downloads photo to dir and sets its path, size and hash.
+// If dir is empty, photo is downloaded only to calculate size and hash.
+func downloadChatPhoto(ctx context.Context, client *tg.Client, photo *chatExportPhoto, dir string, name string) error {
+	if photo == nil || photo.fileID == "" {
+		return nil
+	}
+
+	hash := sha256.New()
+	counter := &countingWriter{}
+
+	dst := io.MultiWriter(hash, counter)
+
+	if dir != "" {
+		file, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		dst = io.MultiWriter(file, hash, counter)
+		photo.Path = name
+	}
+
+	if _, err := client.DownloadFileTo(ctx, photo.fileID, dst, nil); err != nil {
+		return err
+	}
+
+	photo.Size = counter.n
+	photo.SHA256 = hex.EncodeToString(hash.Sum(nil))
+
+	return nil
+}
+
+// countingWriter counts written bytes.
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
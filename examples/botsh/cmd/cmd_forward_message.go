@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli"
+
+	"github.com/mr-linch/go-tg"
+	"github.com/mr-linch/go-tg/examples/botsh/internal"
+)
+
+var forwardMessageCommand = cli.Command{
+	Name:      "forward",
+	Aliases:   []string{"forwardMessage", "forward-message"},
+	Category:  "messages",
+	Usage:     "forward message from one chat to another",
+	ArgsUsage: "PEER_ID FROM_PEER_ID MESSAGE_ID",
+
+	Before: func(cliCtx *cli.Context) error {
+		validateFrom := func() error {
+			if _, err := tg.ParsePeer(cliCtx.Args().Get(1)); err != nil {
+				return fmt.Errorf("from peer: invalid or missing")
+			}
+			return nil
+		}
+
+		validateMessageID := func() error {
+			if _, err := parseMessageID(cliCtx.Args().Get(2)); err != nil {
+				return fmt.Errorf("message id: invalid or missing")
+			}
+			return nil
+		}
+
+		return validate(
+			func() error { return validateSendArgs(cliCtx, 3) },
+			validateFrom,
+			validateMessageID,
+		)
+	},
+
+	Action: internal.NewInfoAction(func(ctx context.Context, cliCtx internal.CLIContext, client *tg.Client) (interface{}, error) {
+		peer, err := tg.ParsePeer(cliCtx.Args().First())
+		if err != nil {
+			return nil, err
+		}
+
+		from, err := tg.ParsePeer(cliCtx.Args().Get(1))
+		if err != nil {
+			return nil, err
+		}
+
+		id, err := parseMessageID(cliCtx.Args().Get(2))
+		if err != nil {
+			return nil, err
+		}
+
+		msg := tg.NewForwardMessage(peer, tg.MessageLocation{
+			Chat:    from,
+			Message: id,
+		}).WithNotification(!cliCtx.Bool("silent"))
+
+		var message tg.Message
+
+		if err := client.Send(ctx, msg, &message); err != nil {
+			return nil, err
+		}
+
+		return message, nil
+	}),
+
+	Flags: flags(
+		cli.BoolFlag{
+			Name:  "silent, s",
+			Usage: "forward message without notification",
+		},
+	),
+}
@@ -3,7 +3,6 @@ package cmd
 import (
 	"context"
 	"io"
-	"os"
 
 	"github.com/urfave/cli"
 
@@ -15,47 +14,57 @@ var getFileCommand = cli.Command{
 	Name:      "get-file",
 	Aliases:   []string{"getFile"},
 	Category:  "generic",
-	Usage:     "get information about chat",
+	Usage:     "get information about file and download it",
 	ArgsUsage: "FILE_ID [PATH]",
 
 	Action: internal.NewAction(func(ctx context.Context, cliCtx internal.CLIContext, client *tg.Client, output internal.Output) error {
-		file, err := client.GetFile(ctx, tg.FileID(cliCtx.Args().First()))
-
-		if err != nil {
-			return err
-		}
+		id := tg.FileID(cliCtx.Args().First())
 
 		// download file
 		if cliCtx.NArg() > 1 {
-			var downloadTo io.Writer
+			opts := &tg.DownloadOptions{
+				MaxSize: cliCtx.Int64("max-size"),
+				Resume:  cliCtx.Bool("resume"),
+			}
 
 			pathOpt := cliCtx.Args().Get(1)
 
 			// write file to std output
 			if pathOpt == "-" {
-				downloadTo = output
-			} else {
-				outputFile, err := os.Create(pathOpt)
-				if err != nil {
-					return err
-				}
-				defer outputFile.Close()
+				var dst io.Writer = output
 
-				downloadTo = outputFile
-			}
+				// content of file is written as is, redaction would corrupt binary files
+				if w, ok := cliCtx.App.Writer.(*internal.RedactWriter); ok {
+					if err := w.Flush(); err != nil {
+						return err
+					}
+					dst = w.Unredacted()
+				}
 
-			reader, err := file.NewReader(ctx)
-			if err != nil {
+				_, err := client.DownloadFileTo(ctx, id, dst, opts)
 				return err
 			}
-			defer reader.Close()
 
-			_, err = io.Copy(downloadTo, reader)
+			_, err := client.DownloadFileToPath(ctx, id, pathOpt, opts)
+			return err
+		}
+
+		file, err := client.GetFile(ctx, id)
+		if err != nil {
 			return err
 		}
 
 		return output.Print(file)
 	}),
 
-	Flags: flags(),
+	Flags: flags(
+		cli.BoolFlag{
+			Name:  "resume, r",
+			Usage: "continue download of partially downloaded file at PATH",
+		},
+		cli.Int64Flag{
+			Name:  "max-size",
+			Usage: "maximum allowed size of file in bytes, 0 means no limit",
+		},
+	),
 }
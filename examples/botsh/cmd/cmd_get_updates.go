@@ -2,8 +2,12 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/urfave/cli"
@@ -13,11 +17,48 @@ import (
 	"github.com/mr-linch/go-tg/examples/botsh/internal"
 )
 
+// errUpdatesLimitReached stops receiving of updates after --max updates are printed.
+var errUpdatesLimitReached = errors.New("updates limit reached")
+
 var getUpdatesCommand = cli.Command{
 	Name:     "get-updates",
-	Aliases:  []string{"getMe"},
+	Aliases:  []string{"getUpdates"},
 	Category: "generic",
-	Usage:    "returns basic information about the bot.",
+	Usage:    "receive incoming updates using long polling and print them",
+
+	Before: func(cliCtx *cli.Context) error {
+		validateUpdateTypes := func(flag string) func() error {
+			return func() error {
+				for _, v := range cliCtx.StringSlice(flag) {
+					if !isValidUpdateType(v) {
+						return fmt.Errorf("--%s: invalid update type: '%s'", flag, v)
+					}
+				}
+				return nil
+			}
+		}
+
+		validateMax := func() error {
+			if cliCtx.Int("max") < 0 {
+				return fmt.Errorf("--max: should be positive")
+			}
+			if cliCtx.Bool("once") && cliCtx.IsSet("max") {
+				return fmt.Errorf("--once and --max can't be used together")
+			}
+			return nil
+		}
+
+		validateFilter := func() error {
+			_, err := parseUpdateFilter(cliCtx)
+			return err
+		}
+
+		return validate(
+			validateUpdateTypes("allowed-updates"),
+			validateMax,
+			validateFilter,
+		)
+	},
 
 	Action: internal.NewAction(func(
 		ctx context.Context,
@@ -27,81 +68,159 @@ var getUpdatesCommand = cli.Command{
 	) error {
 		ctx = context.Background()
 
-		updates := make(chan tg.Update, cliCtx.Int("limit"))
+		filter, err := parseUpdateFilter(cliCtx)
+		if err != nil {
+			return err
+		}
+
+		allowedUpdates, err := parseAllowedUpdates(cliCtx.StringSlice("allowed-updates"))
+		if err != nil {
+			return err
+		}
+
+		max := cliCtx.Int("max")
+		if cliCtx.Bool("once") {
+			max = 1
+		}
+
+		var recorder *updateRecorder
+
+		if path := cliCtx.String("record"); path != "" {
+			file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			recorder = newUpdateRecorder(file)
+		}
+
+		// stop on interrupt, so processed updates are confirmed
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(signals)
+
+		go func() {
+			select {
+			case <-signals:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		updates := make(chan rawUpdate, cliCtx.Int("limit"))
 
 		g, ctx := errgroup.WithContext(ctx)
 
 		// writer
 		g.Go(func() error {
-			offset := tg.UpdateID(0)
-			limit := cliCtx.Int("limit")
-			timeout := cliCtx.Duration("timeout")
+			defer close(updates)
 
-			for {
-				upds, err := client.GetUpdates(ctx, &tg.UpdatesOptions{
-					Offset:  offset,
-					Limit:   limit,
-					Timeout: timeout,
-				})
+			opts := &tg.UpdatesOptions{
+				Limit:   cliCtx.Int("limit"),
+				Timeout: cliCtx.Duration("timeout"),
+			}
+
+			if len(allowedUpdates) > 0 {
+				opts.AllowedUpdates = allowedUpdates
+			}
 
+			for {
+				upds, err := getRawUpdates(ctx, client, opts)
 				if err != nil {
-					fmt.Fprintf(output, "get updates error: %v\n", err)
-					time.Sleep(time.Second * 5)
+					if ctx.Err() != nil {
+						return ctx.Err()
+					}
+
+					fmt.Fprintf(cliCtx.App.ErrWriter, "get updates error: %v\n", err)
+
+					select {
+					case <-time.After(time.Second * 5):
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+
 					continue
 				}
 
-				if len(upds) > 0 {
-					for _, update := range upds {
-						updates <- update
+				for _, update := range upds {
+					select {
+					case updates <- update:
+					case <-ctx.Done():
+						return ctx.Err()
 					}
 
-					offset = upds[len(upds)-1].ID + 1
+					opts.Offset = update.ID + 1
 				}
 			}
-
-			return nil
 		})
 
+		// id of last processed update, updates up to it are confirmed on exit
+		var last tg.UpdateID
+
 		// reader
 		g.Go(func() error {
+			printed := 0
+
 			for update := range updates {
-				var body interface{}
-
-				switch update.Type() {
-				case tg.UpdateMessage:
-					body = update.Message
-				case tg.UpdateEditedMessage:
-					body = update.EditedMessage
-				case tg.UpdateChannelPost:
-					body = update.ChannelPost
-				case tg.UpdateEditedChannelPost:
-					body = update.EditedChannelPost
-				case tg.UpdateInlineQuery:
-					body = update.InlineQuery
-				case tg.UpdateChosenInlineResult:
-					body = update.ChosenInlineResult
-				case tg.UpdateCallbackQuery:
-					body = update.CallbackQuery
-				case tg.UpdateShippingQuery:
-					body = update.ShippingQuery
-				case tg.UpdatePreCheckoutQuery:
-					body = update.PreCheckoutQuery
-				case tg.UpdatePoll:
-					body = update.Poll
-				default:
-					body = update
+				last = update.ID
+
+				// raw stream is recorded, filters are applied to output only
+				if recorder != nil {
+					if err := recorder.Record(update.Raw); err != nil {
+						return err
+					}
+				}
+
+				if !filter.Match(&update.Update) {
+					continue
+				}
+
+				if err := output.Print(updateBody(&update.Update)); err != nil {
+					return err
 				}
 
-				output.Print(body)
+				printed++
+
+				if max > 0 && printed >= max {
+					return errUpdatesLimitReached
+				}
 			}
 
 			return nil
 		})
 
-		return g.Wait()
+		if err := g.Wait(); err != errUpdatesLimitReached && err != context.Canceled {
+			return err
+		}
+
+		if last == 0 {
+			return nil
+		}
+
+		// confirm processed updates, so they are not received again by next run
+		ctx, cancel = context.WithTimeout(context.Background(), cliCtx.GlobalDuration("request-timeout"))
+		defer cancel()
+
+		opts := &tg.UpdatesOptions{
+			Offset: last.Next(),
+			Limit:  1,
+		}
+
+		// empty allowed updates would reset types of updates set by previous calls
+		if len(allowedUpdates) > 0 {
+			opts.AllowedUpdates = allowedUpdates
+		}
+
+		_, err = getRawUpdates(ctx, client, opts)
+
+		return err
 	}),
 
-	Flags: flags(
+	Flags: flags(append([]cli.Flag{
 		cli.IntFlag{
 			Name:  "limit",
 			Usage: "limits the number of updates to be retrieved once",
@@ -112,6 +231,10 @@ var getUpdatesCommand = cli.Command{
 			Usage: "timeout for long polling (1s-100s)",
 			Value: time.Second * 10,
 		},
+		cli.StringFlag{
+			Name:  "record",
+			Usage: "append received updates to `FILE` in NDJSON format, see replay command",
+		},
 		cli.StringSliceFlag{
 			Name: "allowed-updates, u",
 			Usage: fmt.Sprintf(
@@ -119,5 +242,13 @@ var getUpdatesCommand = cli.Command{
 				strings.Join(updateTypes, ", "),
 			),
 		},
-	),
+		cli.IntFlag{
+			Name:  "max",
+			Usage: "exit after `N` printed updates (0 means no limit)",
+		},
+		cli.BoolFlag{
+			Name:  "once",
+			Usage: "exit after first printed update, same as --max 1",
+		},
+	}, updateFilterFlags()...)...),
 }
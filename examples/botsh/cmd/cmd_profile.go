@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli"
+
+	"github.com/mr-linch/go-tg/examples/botsh/internal"
+)
+
+// profileInfo is profile printed by profile list, token is masked.
+type profileInfo struct {
+	Name           string `json:"name"`
+	Default        bool   `json:"default"`
+	Token          string `json:"token"`
+	APIDomain      string `json:"api_domain,omitempty"`
+	APIServer      string `json:"api_server,omitempty"`
+	Local          bool   `json:"local,omitempty"`
+	Format         string `json:"format,omitempty"`
+	RequestTimeout string `json:"request_timeout,omitempty"`
+}
+
+var profileCommand = cli.Command{
+	Name:     "profile",
+	Category: "config",
+	Usage:    "manage profiles of config file, use --profile NAME to select profile",
+
+	Subcommands: []cli.Command{
+		profileAddCommand,
+		profileListCommand,
+		profileRemoveCommand,
+	},
+}
+
+var profileAddCommand = cli.Command{
+	Name:      "add",
+	Usage:     "add profile, empty settings are taken from flags or defaults",
+	ArgsUsage: "NAME",
+
+	Before: func(cliCtx *cli.Context) error {
+		validateName := func() error {
+			if cliCtx.NArg() != 1 || cliCtx.Args().First() == "" {
+				return fmt.Errorf("profile NAME is required")
+			}
+			return nil
+		}
+
+		validateToken := func() error {
+			if cliCtx.String("token") == "" {
+				return fmt.Errorf("--token: required")
+			}
+			return nil
+		}
+
+		validateTimeout := func() error {
+			if err := parseRequestTimeout(cliCtx.String("request-timeout")); err != nil {
+				return fmt.Errorf("--request-timeout: %v", err)
+			}
+			return nil
+		}
+
+		return validate(
+			validateName,
+			validateToken,
+			validateTimeout,
+		)
+	},
+
+	Action: func(cliCtx *cli.Context) error {
+		path, err := configPath(cliCtx)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := loadConfig(path)
+		if err != nil {
+			return err
+		}
+
+		name := cliCtx.Args().First()
+
+		if _, ok := cfg.Profiles[name]; ok && !cliCtx.Bool("force") {
+			return fmt.Errorf("profile '%s' already exists, use --force to replace it", name)
+		}
+
+		cfg.Profiles[name] = &profile{
+			Token:          cliCtx.String("token"),
+			APIDomain:      cliCtx.String("api-domain"),
+			APIServer:      cliCtx.String("api-server"),
+			Local:          cliCtx.Bool("local"),
+			Format:         cliCtx.String("format"),
+			RequestTimeout: cliCtx.String("request-timeout"),
+		}
+
+		if cliCtx.Bool("default") || len(cfg.Profiles) == 1 {
+			cfg.Default = name
+		}
+
+		if err := saveConfig(path, cfg); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cliCtx.App.Writer, "profile '%s' saved to '%s'\n", name, path)
+
+		return nil
+	},
+
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "token",
+			Usage: "Telegram Bot API `TOKEN` (required)",
+		},
+		cli.StringFlag{
+			Name:  "api-domain",
+			Usage: "Telegram Bot API `DOMAIN`",
+		},
+		cli.StringFlag{
+			Name:  "api-server",
+			Usage: "Telegram Bot API server `URL`",
+		},
+		cli.BoolFlag{
+			Name:  "local",
+			Usage: "API server is running in local mode",
+		},
+		cli.StringFlag{
+			Name:  "format",
+			Usage: "default output `FORMAT` of commands",
+		},
+		cli.StringFlag{
+			Name:  "request-timeout",
+			Usage: "timeout for requests, e.g. 30s",
+		},
+		cli.BoolFlag{
+			Name:  "default",
+			Usage: "use profile if --profile is not set (first added profile is default)",
+		},
+		cli.BoolFlag{
+			Name:  "force",
+			Usage: "replace existing profile",
+		},
+	},
+}
+
+var profileListCommand = cli.Command{
+	Name:  "list",
+	Usage: "list profiles, tokens are masked",
+
+	Action: func(cliCtx *cli.Context) error {
+		path, err := configPath(cliCtx)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := loadConfig(path)
+		if err != nil {
+			return err
+		}
+
+		infos := make([]profileInfo, 0, len(cfg.Profiles))
+
+		for _, name := range cfg.Names() {
+			p := cfg.Profiles[name]
+
+			infos = append(infos, profileInfo{
+				Name:           name,
+				Default:        name == cfg.Default,
+				Token:          maskToken(p.Token),
+				APIDomain:      p.APIDomain,
+				APIServer:      p.APIServer,
+				Local:          p.Local,
+				Format:         p.Format,
+				RequestTimeout: p.RequestTimeout,
+			})
+		}
+
+		output := internal.NewOutput(cliCtx.App.Writer, cliCtx.String("format"))
+
+		if cliCtx.IsSet("format") {
+			return output.Print(infos)
+		}
+
+		for _, info := range infos {
+			mark := " "
+			if info.Default {
+				mark = "*"
+			}
+			fmt.Fprintf(cliCtx.App.Writer, "%s %s\t%s\n", mark, info.Name, info.Token)
+		}
+
+		return nil
+	},
+
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "format, f",
+			Usage: "Output format (json, ndjson, pretty or custom template), by default names are printed",
+			Value: "pretty",
+		},
+	},
+}
+
+var profileRemoveCommand = cli.Command{
+	Name:      "remove",
+	Aliases:   []string{"rm"},
+	Usage:     "remove profile",
+	ArgsUsage: "NAME",
+
+	Action: func(cliCtx *cli.Context) error {
+		name := cliCtx.Args().First()
+		if name == "" {
+			return fmt.Errorf("profile NAME is required")
+		}
+
+		path, err := configPath(cliCtx)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := loadConfig(path)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := cfg.Profiles[name]; !ok {
+			return fmt.Errorf("profile '%s' not found", name)
+		}
+
+		delete(cfg.Profiles, name)
+
+		if cfg.Default == name {
+			cfg.Default = ""
+		}
+
+		if err := saveConfig(path, cfg); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cliCtx.App.Writer, "profile '%s' removed from '%s'\n", name, path)
+
+		return nil
+	},
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/mr-linch/go-tg"
+	"github.com/mr-linch/go-tg/examples/botsh/internal"
+)
+
+var replayCommand = cli.Command{
+	Name:      "replay",
+	Category:  "webhook",
+	Usage:     "send updates recorded by get-updates --record to webhook endpoint",
+	ArgsUsage: "FILE",
+
+	Before: func(cliCtx *cli.Context) error {
+		validateFile := func() error {
+			return isFileAvailable(cliCtx.Args().First())
+		}
+
+		validateTo := func() error {
+			if _, err := url.ParseRequestURI(cliCtx.String("to")); err != nil {
+				return fmt.Errorf("--to: invalid or missing")
+			}
+			return nil
+		}
+
+		validateSpeed := func() error {
+			if cliCtx.Float64("speed") < 0 {
+				return fmt.Errorf("--speed: should not be negative")
+			}
+			return nil
+		}
+
+		return validate(
+			validateFile,
+			validateTo,
+			validateSpeed,
+		)
+	},
+
+	Action: internal.NewAction(func(ctx context.Context, cliCtx internal.CLIContext, client *tg.Client, output internal.Output) error {
+		file, err := os.Open(cliCtx.Args().First())
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		records, err := readRecordedUpdates(file)
+		if err != nil {
+			return err
+		}
+
+		// replay can take longer than request timeout
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(signals)
+
+		go func() {
+			select {
+			case <-signals:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		to := cliCtx.String("to")
+		speed := cliCtx.Float64("speed")
+		httpClient := &http.Client{Timeout: cliCtx.GlobalDuration("request-timeout")}
+
+		failed := 0
+
+		for i, record := range records {
+			if i > 0 && speed > 0 && !record.Time.IsZero() && !records[i-1].Time.IsZero() {
+				delay := time.Duration(float64(record.Time.Sub(records[i-1].Time)) / speed)
+
+				select {
+				case <-time.After(delay):
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+
+			status, ok, err := postUpdate(ctx, httpClient, to, record.Update)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+
+				status = err.Error()
+			}
+
+			if !ok {
+				failed++
+
+				if cliCtx.Bool("stop-on-error") {
+					return fmt.Errorf("update %d of %d: %s", i+1, len(records), status)
+				}
+			}
+
+			fmt.Fprintf(output, "update %d of %d: %s\n", i+1, len(records), status)
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d of %d updates failed", failed, len(records))
+		}
+
+		return nil
+	}),
+
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "to, t",
+			Usage: "`URL` of webhook endpoint",
+		},
+		cli.Float64Flag{
+			Name:  "speed",
+			Usage: "replay speed relative to recorded timing, e.g. 2 is twice faster, 0 sends without delays",
+			Value: 1,
+		},
+		cli.BoolFlag{
+			Name:  "stop-on-error",
+			Usage: "stop replay if endpoint responds with non-2xx status",
+		},
+	},
+}
+
+// postUpdate sends update to webhook endpoint and returns response status,
+// ok is true for 2xx status.
+func postUpdate(ctx context.Context, client *http.Client, to string, update []byte) (status string, ok bool, err error) {
+	req, err := http.NewRequest(http.MethodPost, to, bytes.NewReader(update))
+	if err != nil {
+		return "", false, err
+	}
+
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return "", false, err
+	}
+	defer res.Body.Close()
+
+	// read body to reuse connection
+	ioutil.ReadAll(res.Body)
+
+	return res.Status, res.StatusCode >= 200 && res.StatusCode < 300, nil
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/urfave/cli"
+
+	"github.com/mr-linch/go-tg"
+	"github.com/mr-linch/go-tg/examples/botsh/internal"
+)
+
+var sendAudioCommand = cli.Command{
+	Name:      "send-audio",
+	Aliases:   []string{"sendAudio"},
+	Category:  "messages",
+	Usage:     "send .mp3 audio from file path, standard input (-), file ID or URL",
+	ArgsUsage: "PEER_ID AUDIO",
+
+	Before: func(cliCtx *cli.Context) error {
+		validateThumb := func() error {
+			if cliCtx.IsSet("thumb") {
+				return isFileAvailable(cliCtx.String("thumb"))
+			}
+
+			return nil
+		}
+
+		return validate(
+			func() error { return validateSendArgs(cliCtx, 2) },
+			validateThumb,
+		)
+	},
+
+	Action: internal.NewInfoAction(func(ctx context.Context, cliCtx internal.CLIContext, client *tg.Client) (interface{}, error) {
+		peer, err := tg.ParsePeer(cliCtx.Args().First())
+		if err != nil {
+			return nil, err
+		}
+
+		opts, err := parseSendOptions(cliCtx)
+		if err != nil {
+			return nil, err
+		}
+
+		audio, err := parseMedia(cliCtx.Args().Get(1), cliCtx.String("file-name"))
+		if err != nil {
+			return nil, err
+		}
+		defer closeMedia(audio)
+
+		msg := tg.NewAudioMessage(peer, audio).
+			WithCaption(cliCtx.String("caption")).
+			WithTitle(cliCtx.String("title")).
+			WithPerformer(cliCtx.String("performer")).
+			WithDuration(cliCtx.Duration("duration")).
+			WithParseMode(opts.ParseMode).
+			WithNotification(opts.Notification).
+			WithReplyTo(opts.ReplyTo).
+			WithReplyMarkup(opts.ReplyMarkup)
+
+		if cliCtx.IsSet("thumb") {
+			thumb, err := tg.NewInputFileLocal(cliCtx.String("thumb"))
+			if err != nil {
+				return nil, err
+			}
+			defer thumb.Close()
+
+			msg = msg.WithThumb(thumb)
+		}
+
+		var message tg.Message
+
+		if err := client.Send(ctx, msg, &message); err != nil {
+			return nil, err
+		}
+
+		return message, nil
+	}),
+
+	Flags: sendFlags(
+		cli.StringFlag{
+			Name:  "caption, c",
+			Usage: "audio caption",
+		},
+		cli.StringFlag{
+			Name:  "title",
+			Usage: "track name",
+		},
+		cli.StringFlag{
+			Name:  "performer",
+			Usage: "performer of the track",
+		},
+		cli.DurationFlag{
+			Name:  "duration",
+			Usage: "duration of the audio",
+		},
+		cli.StringFlag{
+			Name:  "thumb",
+			Usage: "load thumbnail from JPEG `FILE`",
+		},
+		cli.StringFlag{
+			Name:  "file-name",
+			Usage: "name of file read from standard input",
+			Value: "audio.mp3",
+		},
+	),
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/urfave/cli"
+
+	"github.com/mr-linch/go-tg"
+	"github.com/mr-linch/go-tg/examples/botsh/internal"
+)
+
+var sendDocumentCommand = cli.Command{
+	Name:      "send-document",
+	Aliases:   []string{"sendDocument"},
+	Category:  "messages",
+	Usage:     "send general file from file path, standard input (-), file ID or URL",
+	ArgsUsage: "PEER_ID DOCUMENT",
+
+	Before: func(cliCtx *cli.Context) error {
+		validateThumb := func() error {
+			if cliCtx.IsSet("thumb") {
+				return isFileAvailable(cliCtx.String("thumb"))
+			}
+
+			return nil
+		}
+
+		return validate(
+			func() error { return validateSendArgs(cliCtx, 2) },
+			validateThumb,
+		)
+	},
+
+	Action: internal.NewInfoAction(func(ctx context.Context, cliCtx internal.CLIContext, client *tg.Client) (interface{}, error) {
+		peer, err := tg.ParsePeer(cliCtx.Args().First())
+		if err != nil {
+			return nil, err
+		}
+
+		opts, err := parseSendOptions(cliCtx)
+		if err != nil {
+			return nil, err
+		}
+
+		document, err := parseMedia(cliCtx.Args().Get(1), cliCtx.String("file-name"))
+		if err != nil {
+			return nil, err
+		}
+		defer closeMedia(document)
+
+		msg := tg.NewDocumentMessage(peer, document).
+			WithCaption(cliCtx.String("caption")).
+			WithParseMode(opts.ParseMode).
+			WithNotification(opts.Notification).
+			WithReplyTo(opts.ReplyTo).
+			WithReplyMarkup(opts.ReplyMarkup)
+
+		if cliCtx.IsSet("thumb") {
+			thumb, err := tg.NewInputFileLocal(cliCtx.String("thumb"))
+			if err != nil {
+				return nil, err
+			}
+			defer thumb.Close()
+
+			msg = msg.WithThumb(thumb)
+		}
+
+		var message tg.Message
+
+		if err := client.Send(ctx, msg, &message); err != nil {
+			return nil, err
+		}
+
+		return message, nil
+	}),
+
+	Flags: sendFlags(
+		cli.StringFlag{
+			Name:  "caption, c",
+			Usage: "document caption",
+		},
+		cli.StringFlag{
+			Name:  "thumb",
+			Usage: "load thumbnail from JPEG `FILE`",
+		},
+		cli.StringFlag{
+			Name:  "file-name",
+			Usage: "name of file read from standard input",
+			Value: "document",
+		},
+	),
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/urfave/cli"
+
+	"github.com/mr-linch/go-tg"
+	"github.com/mr-linch/go-tg/examples/botsh/internal"
+)
+
+var sendMessageCommand = cli.Command{
+	Name:      "send-message",
+	Aliases:   []string{"sendMessage"},
+	Category:  "messages",
+	Usage:     "send text message",
+	ArgsUsage: "PEER_ID TEXT",
+
+	Before: func(cliCtx *cli.Context) error {
+		return validateSendArgs(cliCtx, 2)
+	},
+
+	Action: internal.NewInfoAction(func(ctx context.Context, cliCtx internal.CLIContext, client *tg.Client) (interface{}, error) {
+		peer, err := tg.ParsePeer(cliCtx.Args().First())
+		if err != nil {
+			return nil, err
+		}
+
+		opts, err := parseSendOptions(cliCtx)
+		if err != nil {
+			return nil, err
+		}
+
+		msg := tg.NewTextMessage(peer, cliCtx.Args().Get(1)).
+			WithParseMode(opts.ParseMode).
+			WithWebPagePreview(!cliCtx.Bool("no-preview")).
+			WithNotification(opts.Notification).
+			WithReplyTo(opts.ReplyTo).
+			WithReplyMarkup(opts.ReplyMarkup)
+
+		var message tg.Message
+
+		if err := client.Send(ctx, msg, &message); err != nil {
+			return nil, err
+		}
+
+		return message, nil
+	}),
+
+	Flags: sendFlags(
+		cli.BoolFlag{
+			Name:  "no-preview",
+			Usage: "disable link preview of message",
+		},
+	),
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/urfave/cli"
+
+	"github.com/mr-linch/go-tg"
+	"github.com/mr-linch/go-tg/examples/botsh/internal"
+)
+
+var sendPhotoCommand = cli.Command{
+	Name:      "send-photo",
+	Aliases:   []string{"sendPhoto"},
+	Category:  "messages",
+	Usage:     "send photo from file path, standard input (-), file ID or URL",
+	ArgsUsage: "PEER_ID PHOTO",
+
+	Before: func(cliCtx *cli.Context) error {
+		return validateSendArgs(cliCtx, 2)
+	},
+
+	Action: internal.NewInfoAction(func(ctx context.Context, cliCtx internal.CLIContext, client *tg.Client) (interface{}, error) {
+		peer, err := tg.ParsePeer(cliCtx.Args().First())
+		if err != nil {
+			return nil, err
+		}
+
+		opts, err := parseSendOptions(cliCtx)
+		if err != nil {
+			return nil, err
+		}
+
+		photo, err := parseMedia(cliCtx.Args().Get(1), cliCtx.String("file-name"))
+		if err != nil {
+			return nil, err
+		}
+		defer closeMedia(photo)
+
+		msg := tg.NewPhotoMessage(peer, photo).
+			WithCaption(cliCtx.String("caption")).
+			WithParseMode(opts.ParseMode).
+			WithNotification(opts.Notification).
+			WithReplyTo(opts.ReplyTo).
+			WithReplyMarkup(opts.ReplyMarkup)
+
+		var message tg.Message
+
+		if err := client.Send(ctx, msg, &message); err != nil {
+			return nil, err
+		}
+
+		return message, nil
+	}),
+
+	Flags: sendFlags(
+		cli.StringFlag{
+			Name:  "caption, c",
+			Usage: "photo caption",
+		},
+		cli.StringFlag{
+			Name:  "file-name",
+			Usage: "name of file read from standard input",
+			Value: "photo.jpg",
+		},
+	),
+}
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/urfave/cli"
+
+	"github.com/mr-linch/go-tg"
+	"github.com/mr-linch/go-tg/examples/botsh/internal"
+)
+
+var serveWebhookCommand = cli.Command{
+	Name:     "serve-webhook",
+	Category: "webhook",
+	Usage:    "run HTTP server receiving updates by webhook and print them",
+
+	Before: func(cliCtx *cli.Context) error {
+		validatePath := func() error {
+			if !strings.HasPrefix(cliCtx.String("path"), "/") {
+				return fmt.Errorf("--path: should start with /")
+			}
+			return nil
+		}
+
+		validateTLS := func() error {
+			if cliCtx.IsSet("tls-cert") != cliCtx.IsSet("tls-key") {
+				return fmt.Errorf("--tls-cert and --tls-key should be set together")
+			}
+
+			if cliCtx.IsSet("tls-cert") {
+				return validate(
+					func() error { return isFileAvailable(cliCtx.String("tls-cert")) },
+					func() error { return isFileAvailable(cliCtx.String("tls-key")) },
+				)
+			}
+
+			return nil
+		}
+
+		validateAllowedUpdates := func() error {
+			for _, v := range cliCtx.StringSlice("allowed-updates") {
+				if !isValidUpdateType(v) {
+					return fmt.Errorf("--allowed-updates: invalid update type: '%s'", v)
+				}
+			}
+			return nil
+		}
+
+		return validate(
+			validatePath,
+			validateTLS,
+			validateAllowedUpdates,
+		)
+	},
+
+	Action: internal.NewAction(func(ctx context.Context, cliCtx internal.CLIContext, client *tg.Client, output internal.Output) error {
+		timeout := cliCtx.GlobalDuration("request-timeout")
+
+		// output is not safe for concurrent use
+		var lock sync.Mutex
+
+		webhook := tg.NewWebhook(
+			tg.HandlerFunc(func(ctx context.Context, update *tg.Update) error {
+				lock.Lock()
+				defer lock.Unlock()
+
+				return output.Print(updateBody(update))
+			}),
+			tg.WithWebhookErrorHandler(func(err error) {
+				fmt.Fprintf(cliCtx.App.ErrWriter, "webhook error: %v\n", err)
+			}),
+		)
+
+		mux := http.NewServeMux()
+		mux.Handle(cliCtx.String("path"), webhook)
+
+		server := &http.Server{
+			Addr:    cliCtx.String("listen"),
+			Handler: mux,
+		}
+
+		if publicURL := cliCtx.String("public-url"); publicURL != "" {
+			url := strings.TrimSuffix(publicURL, "/") + cliCtx.String("path")
+
+			if err := setServeWebhook(cliCtx, client, url); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cliCtx.App.ErrWriter, "webhook is set to %s\n", url)
+
+			// webhook is deleted on stop, so bot can use getUpdates again
+			defer func() {
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+
+				if err := client.DeleteWebhook(ctx); err != nil {
+					fmt.Fprintf(cliCtx.App.ErrWriter, "delete webhook error: %v\n", err)
+				}
+			}()
+		}
+
+		errs := make(chan error, 1)
+
+		go func() {
+			if cliCtx.IsSet("tls-cert") {
+				errs <- server.ListenAndServeTLS(cliCtx.String("tls-cert"), cliCtx.String("tls-key"))
+			} else {
+				errs <- server.ListenAndServe()
+			}
+		}()
+
+		fmt.Fprintf(cliCtx.App.ErrWriter, "listening on %s\n", server.Addr)
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(signals)
+
+		select {
+		case err := <-errs:
+			return err
+		case <-signals:
+		}
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		return server.Shutdown(shutdownCtx)
+	}),
+
+	Flags: flags(
+		cli.StringFlag{
+			Name:  "listen, l",
+			Usage: "`ADDRESS` of HTTP server",
+			Value: ":8443",
+		},
+		cli.StringFlag{
+			Name:  "path",
+			Usage: "path of webhook, use secret path to prevent fake updates",
+			Value: "/",
+		},
+		cli.StringFlag{
+			Name:  "tls-cert",
+			Usage: "serve HTTPS using certificate from `FILE`",
+		},
+		cli.StringFlag{
+			Name:  "tls-key",
+			Usage: "serve HTTPS using private key from `FILE`",
+		},
+		cli.StringFlag{
+			Name:  "public-url",
+			Usage: "public `URL` of server (without path), webhook is set on start and deleted on stop",
+		},
+		cli.BoolFlag{
+			Name:  "upload-cert",
+			Usage: "upload --tls-cert on set webhook, required for self-signed certificates",
+		},
+		cli.IntFlag{
+			Name:  "max-connections, m",
+			Usage: "maximum allowed number of simultaneous HTTPS connections to the webhook for update delivery, 1-100.",
+			Value: 40,
+		},
+		cli.StringSliceFlag{
+			Name: "allowed-updates, u",
+			Usage: fmt.Sprintf(
+				"list the types of updates you want your bot to receive (values: %s)",
+				strings.Join(updateTypes, ", "),
+			),
+		},
+	),
+}
+
+// setServeWebhook sets webhook of bot to url using serve-webhook flags.
+func setServeWebhook(cliCtx *cli.Context, client *tg.Client, url string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cliCtx.GlobalDuration("request-timeout"))
+	defer cancel()
+
+	opts := &tg.WebhookOptions{
+		MaxConnections: cliCtx.Int("max-connections"),
+	}
+
+	if cliCtx.IsSet("allowed-updates") {
+		allowedUpdates, err := parseAllowedUpdates(cliCtx.StringSlice("allowed-updates"))
+		if err != nil {
+			return err
+		}
+		opts.AllowedUpdates = allowedUpdates
+	}
+
+	if cliCtx.Bool("upload-cert") {
+		cert, err := tg.NewInputFileLocal(cliCtx.String("tls-cert"))
+		if err != nil {
+			return err
+		}
+		defer cert.Close()
+
+		opts.Certificate = &cert
+	}
+
+	return client.SetWebhook(ctx, url, opts)
+}
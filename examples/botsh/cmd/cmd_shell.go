@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/chzyer/readline"
+	"github.com/urfave/cli"
+
+	"github.com/mr-linch/go-tg"
+	"github.com/mr-linch/go-tg/examples/botsh/internal"
+)
+
+var shellCommand = cli.Command{
+	Name:    shellCommandName,
+	Aliases: []string{"repl"},
+	Usage:   "run interactive shell with history, completion, current chat and incoming updates",
+	Description: `In addition to botsh commands shell has own commands, type 'help' to see them.
+
+   Example:
+     botsh> use @channel
+     botsh @channel> send hello
+     botsh @channel> get-chat .`,
+
+	Action: internal.NewAction(func(ctx context.Context, cliCtx internal.CLIContext, client *tg.Client, output internal.Output) error {
+		sh := &shell{
+			app:            cliCtx.App,
+			global:         globalArgs(cliCtx),
+			client:         client,
+			timeout:        cliCtx.GlobalDuration("request-timeout"),
+			updatesTimeout: cliCtx.Duration("updates-timeout"),
+			format:         internal.OutputFormat(cliCtx),
+			peers:          make(map[string]bool),
+		}
+
+		// edited line is written as is, because redaction can delay output waiting for rest of secret,
+		// text printed by shell is redacted by own writer
+		stdout := cliCtx.App.Writer
+		if w, ok := stdout.(*internal.RedactWriter); ok {
+			stdout = w.Unredacted()
+		}
+
+		reader, err := readline.NewEx(&readline.Config{
+			Prompt:          "botsh> ",
+			HistoryFile:     shellHistoryFile(cliCtx),
+			AutoComplete:    shellCompleter{sh: sh},
+			InterruptPrompt: "^C",
+			EOFPrompt:       "exit",
+			Stdout:          stdout,
+			Stderr:          cliCtx.App.ErrWriter,
+		})
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+
+		out := internal.NewRedactWriter(reader.Stdout())
+		out.AddSecret(cliCtx.GlobalString("token"))
+
+		sh.reader = reader
+		sh.out = out
+
+		if !cliCtx.Bool("no-updates") {
+			sh.startUpdates()
+		}
+
+		// shell lives longer than request timeout
+		return sh.Run(context.Background())
+	}),
+
+	Flags: flags(
+		cli.StringFlag{
+			Name:  "history",
+			Usage: "save history to `FILE` (default: ~/.botsh_history)",
+		},
+		cli.BoolFlag{
+			Name:  "no-updates",
+			Usage: "don't print incoming updates, can be turned on by 'updates on'",
+		},
+		cli.DurationFlag{
+			Name:  "updates-timeout",
+			Usage: "timeout for long polling of updates",
+			Value: time.Second * 30,
+		},
+	),
+}
+
+// shellHistoryFile returns path of history file or empty string if home directory is unknown.
+func shellHistoryFile(cliCtx *cli.Context) string {
+	if path := cliCtx.String("history"); path != "" {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, ".botsh_history")
+}
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/mr-linch/go-tg"
+	"github.com/mr-linch/go-tg/examples/botsh/internal"
+)
+
+var webhookWatchCommand = cli.Command{
+	Name:     "webhook-watch",
+	Category: "webhook",
+	Usage:    "poll webhook info, print changes and alert on delivery errors or too many pending updates",
+	Description: `On alert the hook command (--exec) is run with environment variables
+   BOTSH_ALERT (last_error, pending_update_count or get_webhook_info), BOTSH_ALERT_MESSAGE,
+   BOTSH_WEBHOOK_URL, BOTSH_WEBHOOK_PENDING and BOTSH_WEBHOOK_ERROR,
+   then command exits with code 1, unless --keep-going is set.
+
+   Delivery errors happened earlier than --interval before start are ignored,
+   so --once can be run by cron with same interval.
+
+   Events are printed as lines in pretty format.`,
+
+	Before: func(cliCtx *cli.Context) error {
+		validateInterval := func() error {
+			if cliCtx.Duration("interval") <= 0 {
+				return fmt.Errorf("--interval: should be positive")
+			}
+			return nil
+		}
+
+		validateMaxPending := func() error {
+			if cliCtx.Int("max-pending") < 0 {
+				return fmt.Errorf("--max-pending: should not be negative")
+			}
+			return nil
+		}
+
+		validateMaxFailures := func() error {
+			if cliCtx.Int("max-failures") < 1 {
+				return fmt.Errorf("--max-failures: should be positive")
+			}
+			return nil
+		}
+
+		return validate(
+			validateInterval,
+			validateMaxPending,
+			validateMaxFailures,
+		)
+	},
+
+	Action: internal.NewAction(func(ctx context.Context, cliCtx internal.CLIContext, client *tg.Client, output internal.Output) error {
+		// watch lives longer than request timeout
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(signals)
+
+		go func() {
+			select {
+			case <-signals:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		interval := cliCtx.Duration("interval")
+		timeout := cliCtx.GlobalDuration("request-timeout")
+		hook := cliCtx.String("exec")
+
+		watcher := newWebhookWatcher(cliCtx.Int("max-pending"), cliCtx.Int("max-failures"), time.Now().Add(-interval))
+
+		pretty := internal.OutputFormat(cliCtx) == "pretty"
+
+		for {
+			var events []webhookEvent
+
+			info, err := getWebhookInfoWithTimeout(ctx, client, timeout)
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			if err != nil {
+				if cliCtx.Bool("once") {
+					return err
+				}
+
+				fmt.Fprintf(cliCtx.App.ErrWriter, "get webhook info: %v\n", err)
+
+				events = watcher.Fail(err, time.Now())
+			} else {
+				events = watcher.Check(info, time.Now())
+			}
+
+			alerts := 0
+
+			for _, event := range events {
+				if pretty {
+					_, err = fmt.Fprintln(output, event)
+				} else {
+					err = output.Print(event)
+				}
+
+				if err != nil {
+					return err
+				}
+
+				if event.Type != webhookEventAlert {
+					continue
+				}
+
+				alerts++
+
+				if hook != "" {
+					if err := runWebhookHook(ctx, hook, event, watcher.Info(), cliCtx.App.Writer, cliCtx.App.ErrWriter); err != nil {
+						fmt.Fprintf(cliCtx.App.ErrWriter, "hook: %v\n", err)
+					}
+				}
+			}
+
+			if alerts > 0 && !cliCtx.Bool("keep-going") {
+				return cli.NewExitError("", 1)
+			}
+
+			if cliCtx.Bool("once") {
+				return nil
+			}
+
+			select {
+			case <-time.After(interval):
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}),
+
+	Flags: flags(
+		cli.DurationFlag{
+			Name:  "interval, i",
+			Usage: "interval between checks of webhook info",
+			Value: time.Second * 30,
+		},
+		cli.IntFlag{
+			Name:  "max-pending",
+			Usage: "alert if pending updates count is greater than `N` (0 means no limit)",
+		},
+		cli.IntFlag{
+			Name:  "max-failures",
+			Usage: "alert if webhook info is not received `N` times in a row",
+			Value: 3,
+		},
+		cli.StringFlag{
+			Name:  "exec",
+			Usage: "run shell `COMMAND` on each alert",
+		},
+		cli.BoolFlag{
+			Name:  "keep-going",
+			Usage: "don't exit on alert",
+		},
+		cli.BoolFlag{
+			Name:  "once",
+			Usage: "check webhook info once and exit, e.g. for cron",
+		},
+	),
+}
+
+func getWebhookInfoWithTimeout(ctx context.Context, client *tg.Client, timeout time.Duration) (*tg.WebhookInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return client.GetWebhookInfo(ctx)
+}
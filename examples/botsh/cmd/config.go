@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/mr-linch/go-tg/examples/botsh/internal"
+)
+
+// profile contains settings of bot, empty fields are not applied.
+type profile struct {
+	Token          string `json:"token,omitempty"`
+	APIDomain      string `json:"api_domain,omitempty"`
+	APIServer      string `json:"api_server,omitempty"`
+	Local          bool   `json:"local,omitempty"`
+	Format         string `json:"format,omitempty"`
+	RequestTimeout string `json:"request_timeout,omitempty"`
+}
+
+// config is content of botsh config file.
+type config struct {
+	// Name of profile used if --profile is not set.
+	Default string `json:"default,omitempty"`
+
+	Profiles map[string]*profile `json:"profiles"`
+}
+
+// Names returns sorted names of profiles.
+func (cfg *config) Names() []string {
+	names := make([]string, 0, len(cfg.Profiles))
+
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// configPath returns path of config file from --config flag
+// or $XDG_CONFIG_HOME/botsh/config (~/.config/botsh/config by default).
+func configPath(cliCtx *cli.Context) (string, error) {
+	if path := cliCtx.GlobalString("config"); path != "" {
+		return path, nil
+	}
+
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(dir, "botsh", "config"), nil
+}
+
+// checkConfigPermissions returns error if config file with tokens is accessible by other users.
+func checkConfigPermissions(path string, info os.FileInfo) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	if mode := info.Mode().Perm(); mode&0077 != 0 {
+		return fmt.Errorf(
+			"config file '%s' is accessible by other users (mode %#o), run: chmod 600 %s",
+			path, mode, path,
+		)
+	}
+
+	return nil
+}
+
+// loadConfig reads config file, missing file is empty config.
+func loadConfig(path string) (*config, error) {
+	cfg := &config{Profiles: make(map[string]*profile)}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := checkConfigPermissions(path, info); err != nil {
+		return nil, err
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(content, cfg); err != nil {
+		return nil, fmt.Errorf("parse config file '%s': %v", path, err)
+	}
+
+	if cfg.Profiles == nil {
+		cfg.Profiles = make(map[string]*profile)
+	}
+
+	return cfg, nil
+}
+
+// saveConfig writes config file readable only by owner.
+func saveConfig(path string, cfg *config) error {
+	content, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	// temp file is created in the same directory with 0600 mode, so rename is atomic
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	// on success file is already renamed, so remove is no-op
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(content, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// applyProfile sets global flags from selected profile, if they are not set by flag or environment,
+// and redacts token in app output.
+func applyProfile(cliCtx *cli.Context) error {
+	path, err := configPath(cliCtx)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	name := cliCtx.String("profile")
+	if name == "" {
+		name = cfg.Default
+	}
+
+	if name != "" {
+		p, ok := cfg.Profiles[name]
+		if !ok {
+			return fmt.Errorf("profile '%s' not found in '%s'", name, path)
+		}
+
+		// global flag and value
+		values := [][2]string{
+			{"token", p.Token},
+			{"api-domain", p.APIDomain},
+			{"api-server", p.APIServer},
+			{"request-timeout", p.RequestTimeout},
+		}
+
+		if p.Local {
+			values = append(values, [2]string{"local", "true"})
+		}
+
+		for _, v := range values {
+			flag, value := v[0], v[1]
+
+			if value == "" || cliCtx.IsSet(flag) {
+				continue
+			}
+
+			if err := cliCtx.Set(flag, value); err != nil {
+				return fmt.Errorf("profile '%s': %s: %v", name, flag, err)
+			}
+		}
+
+		cliCtx.App.Metadata[internal.MetadataDefaultFormat] = p.Format
+	}
+
+	for _, w := range []interface{}{cliCtx.App.Writer, cliCtx.App.ErrWriter} {
+		if w, ok := w.(*internal.RedactWriter); ok {
+			w.AddSecret(cliCtx.String("token"))
+		}
+	}
+
+	return nil
+}
+
+// maskToken returns token with hidden secret part, e.g. 123456:***.
+func maskToken(token string) string {
+	if i := strings.Index(token, ":"); i != -1 {
+		return token[:i+1] + "***"
+	}
+
+	if token == "" {
+		return ""
+	}
+
+	return "***"
+}
+
+// parseRequestTimeout validates duration of profile.
+func parseRequestTimeout(v string) error {
+	if v == "" {
+		return nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return err
+	}
+
+	if d <= 0 {
+		return fmt.Errorf("should be positive")
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli"
+
+	"github.com/mr-linch/go-tg"
+)
+
+// sendFlags returns flags common for send commands.
+func sendFlags(fs ...cli.Flag) []cli.Flag {
+	return flags(append(fs,
+		cli.StringFlag{
+			Name:  "parse-mode, p",
+			Usage: "parse mode of text or caption (plain, markdown or html)",
+			Value: "plain",
+		},
+		cli.BoolFlag{
+			Name:  "silent, s",
+			Usage: "send message without notification",
+		},
+		cli.IntFlag{
+			Name:  "reply-to, r",
+			Usage: "`ID` of the original message, if message is reply",
+		},
+		cli.StringFlag{
+			Name:  "keyboard, k",
+			Usage: "reply markup of message as `JSON`, e.g. '{\"inline_keyboard\": [[{\"text\": \"Open\", \"url\": \"https://example.com\"}]]}'",
+		},
+	)...)
+}
+
+// sendOptions contains options parsed from sendFlags.
+type sendOptions struct {
+	ParseMode    tg.ParseMode
+	Notification bool
+	ReplyTo      tg.MessageIdentity
+	ReplyMarkup  tg.ReplyMarkup
+}
+
+func parseSendOptions(cliCtx *cli.Context) (*sendOptions, error) {
+	opts := &sendOptions{
+		Notification: !cliCtx.Bool("silent"),
+	}
+
+	pm, err := parseParseMode(cliCtx.String("parse-mode"))
+	if err != nil {
+		return nil, err
+	}
+	opts.ParseMode = pm
+
+	if id := cliCtx.Int("reply-to"); id != 0 {
+		opts.ReplyTo = tg.MessageID(id)
+	}
+
+	if cliCtx.IsSet("keyboard") {
+		rm, err := parseReplyMarkup(cliCtx.String("keyboard"))
+		if err != nil {
+			return nil, err
+		}
+		opts.ReplyMarkup = rm
+	}
+
+	return opts, nil
+}
+
+func parseParseMode(v string) (tg.ParseMode, error) {
+	switch strings.ToLower(v) {
+	case "", "plain":
+		return tg.Plain, nil
+	case "markdown":
+		return tg.Markdown, nil
+	case "html":
+		return tg.HTML, nil
+	default:
+		return tg.Plain, fmt.Errorf("--parse-mode: invalid parse mode: '%s'", v)
+	}
+}
+
+func parseMessageID(v string) (tg.MessageID, error) {
+	id, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, err
+	}
+
+	return tg.MessageID(id), nil
+}
+
+// rawReplyMarkup is reply markup provided by user as JSON.
+type rawReplyMarkup string
+
+func (rm rawReplyMarkup) EncodeReplyMarkup() (string, error) {
+	return string(rm), nil
+}
+
+func parseReplyMarkup(v string) (tg.ReplyMarkup, error) {
+	var markup map[string]interface{}
+
+	if err := json.Unmarshal([]byte(v), &markup); err != nil {
+		return nil, fmt.Errorf("--keyboard: invalid JSON: %v", err)
+	}
+
+	return rawReplyMarkup(v), nil
+}
+
+// parseMedia returns media by command argument:
+// "-" is content of standard input, path of existing file is local file,
+// anything else is file ID or URL.
+// Returned InputFile should be closed after send.
+func parseMedia(v string, name string) (tg.Media, error) {
+	if v == "-" {
+		return tg.NewInputFile(name, os.Stdin), nil
+	}
+
+	if info, err := os.Stat(v); err == nil && !info.IsDir() {
+		return tg.NewInputFileLocal(v)
+	}
+
+	return tg.FileID(v), nil
+}
+
+func closeMedia(media tg.Media) {
+	if file, ok := media.(tg.InputFile); ok {
+		file.Close()
+	}
+}
+
+// validateSendArgs checks peer and number of arguments of send commands.
+func validateSendArgs(cliCtx *cli.Context, n int) error {
+	validateArgs := func() error {
+		if cliCtx.NArg() != n {
+			return fmt.Errorf("expected %d arguments, got %d", n, cliCtx.NArg())
+		}
+		return nil
+	}
+
+	validatePeer := func() error {
+		if _, err := tg.ParsePeer(cliCtx.Args().First()); err != nil {
+			return fmt.Errorf("peer: invalid or missing")
+		}
+		return nil
+	}
+
+	validateParseMode := func() error {
+		_, err := parseParseMode(cliCtx.String("parse-mode"))
+		return err
+	}
+
+	validateKeyboard := func() error {
+		if cliCtx.IsSet("keyboard") {
+			_, err := parseReplyMarkup(cliCtx.String("keyboard"))
+			return err
+		}
+		return nil
+	}
+
+	return validate(
+		validateArgs,
+		validatePeer,
+		validateParseMode,
+		validateKeyboard,
+	)
+}
@@ -0,0 +1,587 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chzyer/readline"
+	"github.com/urfave/cli"
+
+	"github.com/mr-linch/go-tg"
+	"github.com/mr-linch/go-tg/examples/botsh/internal"
+)
+
+const shellCommandName = "shell"
+
+// shellCurrentChat is argument of commands replaced by current chat, e.g. get-chat .
+const shellCurrentChat = "."
+
+// shellBuiltin is command implemented by shell itself.
+// Builtins without Run (help and exit) are handled by shell directly.
+type shellBuiltin struct {
+	Usage string
+
+	// Run is called with arguments of command and raw text after command name.
+	Run func(ctx context.Context, sh *shell, args []string, text string) error
+}
+
+var shellBuiltins = map[string]shellBuiltin{
+	"help": {
+		Usage: "show this help, use 'help COMMAND' for help of botsh command",
+	},
+	"use": {
+		Usage: "use PEER_ID as current chat, '.' in arguments of commands is replaced by it",
+		Run: func(ctx context.Context, sh *shell, args []string, text string) error {
+			if len(args) == 0 {
+				if sh.chat == "" {
+					return fmt.Errorf("current chat is not set")
+				}
+				fmt.Fprintln(sh.out, sh.chat)
+				return nil
+			}
+
+			return sh.use(ctx, args[0])
+		},
+	},
+	"send": {
+		Usage: "send TEXT to current chat, rest of line is sent as is",
+		Run: func(ctx context.Context, sh *shell, args []string, text string) error {
+			if sh.chat == "" {
+				return fmt.Errorf("current chat is not set, see 'use'")
+			}
+
+			if text == "" {
+				return fmt.Errorf("text is required")
+			}
+
+			return sh.send(ctx, text)
+		},
+	},
+	"format": {
+		Usage: "show or set output FORMAT (json, ndjson, pretty or custom template, quote it if it contains spaces)",
+		Run: func(ctx context.Context, sh *shell, args []string, text string) error {
+			sh.lock.Lock()
+			if len(args) > 0 {
+				sh.format = args[0]
+			}
+			format := sh.format
+			sh.lock.Unlock()
+
+			fmt.Fprintln(sh.out, format)
+			return nil
+		},
+	},
+	"updates": {
+		Usage: "show state or turn on/off printing of incoming updates",
+		Run: func(ctx context.Context, sh *shell, args []string, text string) error {
+			switch text {
+			case "on":
+				sh.startUpdates()
+			case "off":
+				sh.stopUpdates()
+			case "":
+			default:
+				return fmt.Errorf("usage: updates [on|off]")
+			}
+
+			if sh.isReceivingUpdates() {
+				fmt.Fprintln(sh.out, "updates: on")
+			} else {
+				fmt.Fprintln(sh.out, "updates: off")
+			}
+
+			return nil
+		},
+	},
+	"exit": {
+		Usage: "exit shell (also quit or Ctrl-D)",
+	},
+}
+
+// shell is interactive botsh session.
+type shell struct {
+	app    *cli.App
+	global []string
+	client *tg.Client
+	reader *readline.Instance
+
+	// out prints text above the prompt, so it is safe to use while line is edited
+	out io.Writer
+
+	timeout        time.Duration
+	updatesTimeout time.Duration
+
+	// current chat as specified by user, e.g. @channel
+	chat string
+
+	// protects fields used by updates receiver
+	lock   sync.Mutex
+	format string
+	peers  map[string]bool
+	cancel context.CancelFunc
+}
+
+// globalArgs returns global flags of app set by user, so commands run from shell use same settings.
+func globalArgs(cliCtx *cli.Context) []string {
+	var args []string
+
+	for _, flag := range cliCtx.App.Flags {
+		name := strings.TrimSpace(strings.Split(flag.GetName(), ",")[0])
+
+		if cliCtx.GlobalIsSet(name) {
+			args = append(args, fmt.Sprintf("--%s=%v", name, cliCtx.GlobalGeneric(name)))
+		}
+	}
+
+	return args
+}
+
+// Run reads and executes lines until end of input.
+func (sh *shell) Run(ctx context.Context) error {
+	// commands can exit app in case of error, but shell should continue
+	exiter := cli.OsExiter
+	cli.OsExiter = func(int) {}
+	defer func() { cli.OsExiter = exiter }()
+
+	defer sh.stopUpdates()
+
+	// Ctrl-C interrupts running command (if it handles signals), but not shell
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	defer close(signals)
+	defer signal.Stop(signals)
+
+	go func() {
+		for range signals {
+			// ignore
+		}
+	}()
+
+	for {
+		if sh.chat != "" {
+			sh.reader.SetPrompt(fmt.Sprintf("botsh %s> ", sh.chat))
+		} else {
+			sh.reader.SetPrompt("botsh> ")
+		}
+
+		line, err := sh.reader.Readline()
+		if err == readline.ErrInterrupt {
+			continue
+		} else if err != nil {
+			return nil
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		words, err := splitShellWords(line)
+		if err != nil {
+			fmt.Fprintf(sh.out, "error: %v\n", err)
+			continue
+		}
+
+		args := make([]string, len(words))
+		for i, word := range words {
+			args[i] = word.Value
+		}
+
+		if args[0] == "exit" || args[0] == "quit" {
+			return nil
+		}
+
+		if err := sh.exec(ctx, args, strings.TrimSpace(line[words[0].End:])); err != nil {
+			fmt.Fprintf(sh.out, "error: %v\n", err)
+		}
+	}
+}
+
+// exec runs builtin or botsh command.
+func (sh *shell) exec(ctx context.Context, args []string, text string) error {
+	if args[0] == "help" && len(args) == 1 {
+		sh.printHelp()
+		return nil
+	}
+
+	if builtin, ok := shellBuiltins[args[0]]; ok && builtin.Run != nil {
+		ctx, cancel := context.WithTimeout(ctx, sh.timeout)
+		defer cancel()
+
+		return builtin.Run(ctx, sh, args[1:], text)
+	}
+
+	command := sh.app.Command(args[0])
+	if command == nil {
+		return fmt.Errorf("unknown command '%s', see 'help'", args[0])
+	}
+
+	if command.Name == shellCommandName {
+		return fmt.Errorf("already in shell")
+	}
+
+	for i, arg := range args[1:] {
+		if arg == shellCurrentChat {
+			if sh.chat == "" {
+				return fmt.Errorf("current chat is not set, see 'use'")
+			}
+			args[i+1] = sh.chat
+		}
+	}
+
+	runArgs := append([]string{sh.app.Name}, sh.global...)
+	runArgs = append(runArgs, args[0])
+
+	if hasFlag(command.Flags, "format") && !hasShellFormatArg(args[1:]) {
+		runArgs = append(runArgs, "--format", sh.format)
+	}
+
+	runArgs = append(runArgs, args[1:]...)
+
+	// exit errors are already printed by app
+	if err := sh.app.Run(runArgs); err != nil {
+		if _, ok := err.(cli.ExitCoder); !ok {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func hasFlag(flags []cli.Flag, name string) bool {
+	for _, flag := range flags {
+		for _, v := range strings.Split(flag.GetName(), ",") {
+			if strings.TrimSpace(v) == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func hasShellFormatArg(args []string) bool {
+	for _, arg := range args {
+		for _, prefix := range []string{"-f", "--f", "-format", "--format"} {
+			if arg == prefix || strings.HasPrefix(arg, prefix+"=") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// print writes object in current format above the prompt.
+func (sh *shell) print(v interface{}) error {
+	buf := &bytes.Buffer{}
+
+	sh.lock.Lock()
+	format := sh.format
+	sh.lock.Unlock()
+
+	if err := internal.NewOutput(buf, format).Print(v); err != nil {
+		return err
+	}
+
+	_, err := sh.out.Write(buf.Bytes())
+
+	return err
+}
+
+func (sh *shell) printHelp() {
+	names := make([]string, 0, len(shellBuiltins))
+	for name := range shellBuiltins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf := &bytes.Buffer{}
+
+	fmt.Fprintln(buf, "Shell commands:")
+	for _, name := range names {
+		fmt.Fprintf(buf, "  %-10s %s\n", name, shellBuiltins[name].Usage)
+	}
+
+	fmt.Fprintln(buf, "\nbotsh commands:")
+	for _, command := range sh.app.VisibleCommands() {
+		if command.Name == shellCommandName || command.Name == "help" {
+			continue
+		}
+		fmt.Fprintf(buf, "  %-25s %s\n", command.Name, command.Usage)
+	}
+
+	sh.out.Write(buf.Bytes())
+}
+
+// use checks that chat is available and sets it as current.
+func (sh *shell) use(ctx context.Context, id string) error {
+	peer, err := tg.ParsePeer(id)
+	if err != nil {
+		return fmt.Errorf("invalid peer id: '%s'", id)
+	}
+
+	chat, err := sh.client.GetChat(ctx, peer)
+	if err != nil {
+		return err
+	}
+
+	sh.chat = id
+	sh.addChatPeers(chat)
+
+	return nil
+}
+
+func (sh *shell) send(ctx context.Context, text string) error {
+	peer, err := tg.ParsePeer(sh.chat)
+	if err != nil {
+		return err
+	}
+
+	var message tg.Message
+
+	if err := sh.client.Send(ctx, tg.NewTextMessage(peer, text), &message); err != nil {
+		return err
+	}
+
+	return sh.print(message)
+}
+
+// addPeer remembers peer for completion.
+func (sh *shell) addPeer(id string) {
+	sh.lock.Lock()
+	defer sh.lock.Unlock()
+
+	sh.peers[id] = true
+}
+
+func (sh *shell) addChatPeers(chat *tg.Chat) {
+	sh.addPeer(strconv.FormatInt(int64(chat.ID), 10))
+
+	if chat.Username != "" {
+		sh.addPeer("@" + string(chat.Username))
+	}
+}
+
+func (sh *shell) addUserPeers(user *tg.User) {
+	sh.addPeer(strconv.Itoa(int(user.ID)))
+
+	if user.Username != "" {
+		sh.addPeer("@" + string(user.Username))
+	}
+}
+
+// shellCompleter completes line edited in shell.
+type shellCompleter struct {
+	sh *shell
+}
+
+// Do returns suffixes of candidates for word before cursor.
+func (c shellCompleter) Do(line []rune, pos int) ([][]rune, int) {
+	text := string(line[:pos])
+	word := []rune(text[strings.LastIndex(text, " ")+1:])
+
+	var result [][]rune
+
+	for _, candidate := range c.sh.complete(text) {
+		result = append(result, []rune(candidate)[len(word):])
+	}
+
+	return result, len(word)
+}
+
+// complete returns candidates for the last word of line:
+// commands for the first word, flags of command for words starting with dash
+// and known peers for others.
+func (sh *shell) complete(line string) []string {
+	words := strings.Split(line, " ")
+	word := words[len(words)-1]
+
+	var candidates []string
+
+	switch {
+	case len(words) == 1:
+		for name := range shellBuiltins {
+			candidates = append(candidates, name)
+		}
+
+		for _, command := range sh.app.VisibleCommands() {
+			if command.Name != shellCommandName {
+				candidates = append(candidates, command.Names()...)
+			}
+		}
+	case strings.HasPrefix(word, "-"):
+		if command := sh.app.Command(words[0]); command != nil {
+			for _, flag := range command.Flags {
+				name := strings.TrimSpace(strings.Split(flag.GetName(), ",")[0])
+				candidates = append(candidates, "--"+name)
+			}
+		}
+	default:
+		sh.lock.Lock()
+		for peer := range sh.peers {
+			candidates = append(candidates, peer)
+		}
+		sh.lock.Unlock()
+	}
+
+	result := candidates[:0]
+
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, word) {
+			result = append(result, candidate)
+		}
+	}
+
+	return result
+}
+
+// startUpdates starts printing of incoming updates in background.
+func (sh *shell) startUpdates() {
+	sh.lock.Lock()
+	defer sh.lock.Unlock()
+
+	if sh.cancel != nil {
+		return
+	}
+
+	var ctx context.Context
+	ctx, sh.cancel = context.WithCancel(context.Background())
+
+	go sh.receiveUpdates(ctx)
+}
+
+func (sh *shell) stopUpdates() {
+	sh.lock.Lock()
+	defer sh.lock.Unlock()
+
+	if sh.cancel != nil {
+		sh.cancel()
+		sh.cancel = nil
+	}
+}
+
+func (sh *shell) isReceivingUpdates() bool {
+	sh.lock.Lock()
+	defer sh.lock.Unlock()
+
+	return sh.cancel != nil
+}
+
+func (sh *shell) receiveUpdates(ctx context.Context) {
+	opts := &tg.UpdatesOptions{
+		Timeout: sh.updatesTimeout,
+	}
+
+	for {
+		updates, err := sh.client.GetUpdates(ctx, opts)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			// e.g. webhook is set, retries will not help
+			if apiErr, ok := err.(*tg.Error); ok && apiErr.Code == 409 {
+				fmt.Fprintf(sh.out, "updates: %v\n", err)
+				sh.stopUpdates()
+				return
+			}
+
+			fmt.Fprintf(sh.out, "updates: %v\n", err)
+
+			select {
+			case <-time.After(time.Second * 5):
+			case <-ctx.Done():
+				return
+			}
+
+			continue
+		}
+
+		for i := range updates {
+			update := &updates[i]
+
+			if chat := update.Chat(); chat != nil {
+				sh.addChatPeers(chat)
+			}
+
+			if user := update.From(); user != nil {
+				sh.addUserPeers(user)
+			}
+
+			if err := sh.print(updateBody(update)); err != nil {
+				fmt.Fprintf(sh.out, "updates: %v\n", err)
+			}
+
+			opts.Offset = update.ID.Next()
+		}
+	}
+}
+
+// shellWord is word of shell line.
+type shellWord struct {
+	// Value of word without quotes and escapes.
+	Value string
+
+	// End is offset of first byte after word in line.
+	End int
+}
+
+// splitShellWords splits line to words by spaces, words can be quoted by ' or ".
+func splitShellWords(line string) ([]shellWord, error) {
+	var (
+		words   []shellWord
+		word    strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+
+	for i, c := range line {
+		switch {
+		case escaped:
+			word.WriteRune(c)
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				word.WriteRune(c)
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inWord = true
+		case c == ' ' || c == '\t':
+			if inWord {
+				words = append(words, shellWord{Value: word.String(), End: i})
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+
+	if inWord {
+		words = append(words, shellWord{Value: word.String(), End: len(line)})
+	}
+
+	return words, nil
+}
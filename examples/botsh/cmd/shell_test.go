@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitShellWords(t *testing.T) {
+	for _, tt := range []struct {
+		Line  string
+		Words []shellWord
+	}{
+		{`send hello  world`, []shellWord{{"send", 4}, {"hello", 10}, {"world", 17}}},
+		{`"send" hi`, []shellWord{{"send", 6}, {"hi", 9}}},
+		{`se\ nd 'a b' "c'd"`, []shellWord{{"se nd", 6}, {"a b", 12}, {"c'd", 18}}},
+		{`привет мир`, []shellWord{{"привет", 12}, {"мир", 19}}},
+	} {
+		words, err := splitShellWords(tt.Line)
+		if err != nil {
+			t.Errorf("%s: %v", tt.Line, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(words, tt.Words) {
+			t.Errorf("%s: words are %v, want %v", tt.Line, words, tt.Words)
+		}
+	}
+
+	if _, err := splitShellWords(`send "hello`); err == nil {
+		t.Error("unterminated quote is not error")
+	}
+}
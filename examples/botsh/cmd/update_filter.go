@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli"
+
+	"github.com/mr-linch/go-tg"
+)
+
+// updateFilterFlags returns flags of client-side update filtering.
+func updateFilterFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  "chat",
+			Usage: "print only updates from chat with `PEER_ID` (id or @username)",
+		},
+		cli.StringFlag{
+			Name:  "from",
+			Usage: "print only updates sent by user with `PEER_ID` (id or @username)",
+		},
+		cli.StringSliceFlag{
+			Name:  "type",
+			Usage: "print only updates of `TYPE` (same values as --allowed-updates)",
+		},
+		cli.StringFlag{
+			Name:  "text-regex",
+			Usage: "print only updates with text (message text or caption, callback data, inline query) matching `REGEX`",
+		},
+		cli.StringFlag{
+			Name:  "command",
+			Usage: "print only messages with bot `COMMAND`, e.g. start or /start",
+		},
+	}
+}
+
+// updateFilter matches updates by flags of updateFilterFlags.
+// Empty filter matches all updates.
+type updateFilter struct {
+	chat    string
+	from    string
+	types   []tg.UpdateType
+	text    *regexp.Regexp
+	command string
+}
+
+func parseUpdateFilter(cliCtx *cli.Context) (*updateFilter, error) {
+	filter := &updateFilter{
+		chat:    cliCtx.String("chat"),
+		from:    cliCtx.String("from"),
+		command: strings.TrimPrefix(cliCtx.String("command"), "/"),
+	}
+
+	for _, v := range []string{filter.chat, filter.from} {
+		if v == "" {
+			continue
+		}
+
+		if _, err := tg.ParsePeer(v); err != nil {
+			return nil, fmt.Errorf("invalid peer id: '%s'", v)
+		}
+	}
+
+	for _, v := range cliCtx.StringSlice("type") {
+		if !isValidUpdateType(v) {
+			return nil, fmt.Errorf("--type: invalid update type: '%s'", v)
+		}
+	}
+
+	types, err := parseAllowedUpdates(cliCtx.StringSlice("type"))
+	if err != nil {
+		return nil, err
+	}
+	filter.types = types
+
+	if v := cliCtx.String("text-regex"); v != "" {
+		re, err := regexp.Compile(v)
+		if err != nil {
+			return nil, fmt.Errorf("--text-regex: %v", err)
+		}
+		filter.text = re
+	}
+
+	return filter, nil
+}
+
+// Match returns true if update matches all conditions of filter.
+func (filter *updateFilter) Match(update *tg.Update) bool {
+	if len(filter.types) > 0 && !filter.matchType(update.Type()) {
+		return false
+	}
+
+	if filter.chat != "" {
+		chat := update.Chat()
+		if chat == nil || !matchPeer(filter.chat, int64(chat.ID), chat.Username) {
+			return false
+		}
+	}
+
+	if filter.from != "" {
+		from := update.From()
+		if from == nil || !matchPeer(filter.from, int64(from.ID), from.Username) {
+			return false
+		}
+	}
+
+	if filter.text != nil && !filter.text.MatchString(updateText(update)) {
+		return false
+	}
+
+	if filter.command != "" && !matchCommand(filter.command, updateText(update)) {
+		return false
+	}
+
+	return true
+}
+
+func (filter *updateFilter) matchType(typ tg.UpdateType) bool {
+	for _, v := range filter.types {
+		if v == typ {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchPeer returns true if peer (id or @username) identifies chat or user.
+func matchPeer(peer string, id int64, username tg.Username) bool {
+	if strings.HasPrefix(peer, "@") {
+		return strings.EqualFold(peer[1:], string(username))
+	}
+
+	return peer == strconv.FormatInt(id, 10)
+}
+
+// matchCommand returns true if text is bot command, e.g. "/start" or "/start@bot args".
+func matchCommand(command string, text string) bool {
+	if !strings.HasPrefix(text, "/") {
+		return false
+	}
+
+	name := strings.Fields(text[1:])
+	if len(name) == 0 {
+		return false
+	}
+
+	// strip bot username
+	if i := strings.Index(name[0], "@"); i != -1 {
+		name[0] = name[0][:i]
+	}
+
+	return strings.EqualFold(name[0], command)
+}
+
+// updateText returns text of update: text or caption of message,
+// data of callback query or query of inline query.
+func updateText(update *tg.Update) string {
+	for _, msg := range []*tg.Message{
+		update.Message,
+		update.EditedMessage,
+		update.ChannelPost,
+		update.EditedChannelPost,
+	} {
+		if msg == nil {
+			continue
+		}
+
+		if msg.Text != "" {
+			return msg.Text
+		}
+
+		return msg.Caption
+	}
+
+	switch {
+	case update.CallbackQuery != nil:
+		return update.CallbackQuery.Data
+	case update.InlineQuery != nil:
+		return update.InlineQuery.Query
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/mr-linch/go-tg"
+)
+
+// updateBody returns object of update depends on its type,
+// e.g. message for message updates, or update itself for unknown types.
+func updateBody(update *tg.Update) interface{} {
+	switch update.Type() {
+	case tg.UpdateMessage:
+		return update.Message
+	case tg.UpdateEditedMessage:
+		return update.EditedMessage
+	case tg.UpdateChannelPost:
+		return update.ChannelPost
+	case tg.UpdateEditedChannelPost:
+		return update.EditedChannelPost
+	case tg.UpdateInlineQuery:
+		return update.InlineQuery
+	case tg.UpdateChosenInlineResult:
+		return update.ChosenInlineResult
+	case tg.UpdateCallbackQuery:
+		return update.CallbackQuery
+	case tg.UpdateShippingQuery:
+		return update.ShippingQuery
+	case tg.UpdatePreCheckoutQuery:
+		return update.PreCheckoutQuery
+	case tg.UpdatePoll:
+		return update.Poll
+	default:
+		return update
+	}
+}
+
+// rawUpdate is update received from API with original JSON.
+type rawUpdate struct {
+	tg.Update
+
+	Raw json.RawMessage
+}
+
+// getRawUpdates calls getUpdates and keeps original JSON of updates,
+// so unknown fields are not lost on recording.
+func getRawUpdates(ctx context.Context, client *tg.Client, opts *tg.UpdatesOptions) ([]rawUpdate, error) {
+	req := tg.NewRequest("getUpdates").
+		AddOptInt("offset", int(opts.Offset)).
+		AddOptInt("limit", opts.Limit).
+		AddOptInt("timeout", int(opts.Timeout.Seconds()))
+
+	if opts.AllowedUpdates != nil {
+		allowedUpdates, err := json.Marshal(opts.AllowedUpdates)
+		if err != nil {
+			return nil, err
+		}
+		req.AddString("allowed_updates", string(allowedUpdates))
+	}
+
+	var raws []json.RawMessage
+
+	if err := client.Invoke(ctx, req, &raws); err != nil {
+		return nil, err
+	}
+
+	updates := make([]rawUpdate, len(raws))
+
+	for i, raw := range raws {
+		updates[i].Raw = raw
+
+		if err := json.Unmarshal(raw, &updates[i].Update); err != nil {
+			return nil, fmt.Errorf("unmarshal update: %v", err)
+		}
+	}
+
+	return updates, nil
+}
+
+// recordedUpdate is line of update stream record (NDJSON).
+type recordedUpdate struct {
+	// Time of update receiving.
+	Time time.Time `json:"time"`
+
+	// Original JSON of update.
+	Update json.RawMessage `json:"update"`
+}
+
+// updateRecorder writes received updates to NDJSON stream.
+type updateRecorder struct {
+	lock sync.Mutex
+	enc  *json.Encoder
+}
+
+func newUpdateRecorder(w io.Writer) *updateRecorder {
+	return &updateRecorder{enc: json.NewEncoder(w)}
+}
+
+func (recorder *updateRecorder) Record(update json.RawMessage) error {
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+
+	return recorder.enc.Encode(recordedUpdate{
+		Time:   time.Now(),
+		Update: update,
+	})
+}
+
+// readRecordedUpdates reads NDJSON stream of recorded updates.
+// Lines without "update" key are treated as bare updates without time.
+func readRecordedUpdates(r io.Reader) ([]recordedUpdate, error) {
+	var result []recordedUpdate
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		content := scanner.Bytes()
+		if len(content) == 0 {
+			continue
+		}
+
+		record := recordedUpdate{}
+
+		if err := json.Unmarshal(content, &record); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		if record.Update == nil {
+			record.Update = append(json.RawMessage(nil), content...)
+		}
+
+		result = append(result, record)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
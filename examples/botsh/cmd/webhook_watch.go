@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/mr-linch/go-tg"
+)
+
+// Types of webhook watch events.
+const (
+	webhookEventChange    = "change"
+	webhookEventAlert     = "alert"
+	webhookEventRecovered = "recovered"
+)
+
+// webhookEvent is change of webhook info or alert.
+type webhookEvent struct {
+	Time    time.Time   `json:"time"`
+	Type    string      `json:"type"`
+	Field   string      `json:"field"`
+	Old     interface{} `json:"old,omitempty"`
+	New     interface{} `json:"new,omitempty"`
+	Message string      `json:"message,omitempty"`
+}
+
+func (event webhookEvent) String() string {
+	switch event.Type {
+	case webhookEventChange:
+		return fmt.Sprintf("%s %s %s: %v -> %v",
+			event.Time.Format(time.RFC3339), event.Type, event.Field, event.Old, event.New,
+		)
+	default:
+		return fmt.Sprintf("%s %s %s: %s",
+			event.Time.Format(time.RFC3339), event.Type, event.Field, event.Message,
+		)
+	}
+}
+
+// webhookWatcher compares webhook info with previous one and detects alerts:
+// new delivery error and pending updates count above threshold.
+type webhookWatcher struct {
+	// max pending updates, 0 means no limit
+	maxPending int
+
+	// number of consecutive failed requests of webhook info raising alert
+	maxFailures int
+
+	prev           *tg.WebhookInfo
+	lastErrorDate  time.Time
+	pendingAlerted bool
+
+	failures      int
+	failedAlerted bool
+}
+
+// newWebhookWatcher creates watcher which ignores delivery errors happened before since.
+func newWebhookWatcher(maxPending int, maxFailures int, since time.Time) *webhookWatcher {
+	return &webhookWatcher{
+		maxPending:    maxPending,
+		maxFailures:   maxFailures,
+		lastErrorDate: since,
+	}
+}
+
+// Info returns last received webhook info, empty if it was not received yet.
+func (watcher *webhookWatcher) Info() *tg.WebhookInfo {
+	if watcher.prev == nil {
+		return &tg.WebhookInfo{}
+	}
+	return watcher.prev
+}
+
+// Fail returns alert, if request of webhook info is failed maxFailures times in a row.
+func (watcher *webhookWatcher) Fail(err error, now time.Time) []webhookEvent {
+	watcher.failures++
+
+	if watcher.failedAlerted || watcher.failures < watcher.maxFailures {
+		return nil
+	}
+
+	watcher.failedAlerted = true
+
+	return []webhookEvent{{
+		Time:    now,
+		Type:    webhookEventAlert,
+		Field:   "get_webhook_info",
+		Message: fmt.Sprintf("failed %d times in a row: %v", watcher.failures, err),
+	}}
+}
+
+// Check returns events of info compared with previous check.
+func (watcher *webhookWatcher) Check(info *tg.WebhookInfo, now time.Time) []webhookEvent {
+	var events []webhookEvent
+
+	if watcher.failedAlerted {
+		events = append(events, webhookEvent{
+			Time:    now,
+			Type:    webhookEventRecovered,
+			Field:   "get_webhook_info",
+			Message: fmt.Sprintf("received after %d failures", watcher.failures),
+		})
+	}
+
+	watcher.failures = 0
+	watcher.failedAlerted = false
+
+	if prev := watcher.prev; prev != nil {
+		fields := []struct {
+			name     string
+			old, new interface{}
+		}{
+			{"url", prev.URL, info.URL},
+			{"has_custom_certificate", prev.HasCustomCertificate, info.HasCustomCertificate},
+			{"pending_update_count", prev.PendingUpdateCount, info.PendingUpdateCount},
+			{"max_connections", prev.MaxConnections, info.MaxConnections},
+			{"allowed_updates", prev.AllowedUpdates, info.AllowedUpdates},
+			{"last_error_message", webhookErrorMessage(prev), webhookErrorMessage(info)},
+		}
+
+		for _, field := range fields {
+			if !reflect.DeepEqual(field.old, field.new) {
+				events = append(events, webhookEvent{
+					Time:  now,
+					Type:  webhookEventChange,
+					Field: field.name,
+					Old:   field.old,
+					New:   field.new,
+				})
+			}
+		}
+	}
+
+	if info.Error != nil && info.Error.Date.After(watcher.lastErrorDate) {
+		watcher.lastErrorDate = info.Error.Date
+
+		events = append(events, webhookEvent{
+			Time:    now,
+			Type:    webhookEventAlert,
+			Field:   "last_error",
+			Message: fmt.Sprintf("%s at %s", info.Error.Message, info.Error.Date.Format(time.RFC3339)),
+		})
+	}
+
+	if watcher.maxPending > 0 {
+		over := info.PendingUpdateCount > watcher.maxPending
+
+		if over != watcher.pendingAlerted {
+			event := webhookEvent{
+				Time:    now,
+				Type:    webhookEventAlert,
+				Field:   "pending_update_count",
+				Message: fmt.Sprintf("%d pending updates, more than %d", info.PendingUpdateCount, watcher.maxPending),
+			}
+
+			if !over {
+				event.Type = webhookEventRecovered
+				event.Message = fmt.Sprintf("%d pending updates", info.PendingUpdateCount)
+			}
+
+			events = append(events, event)
+			watcher.pendingAlerted = over
+		}
+	}
+
+	watcher.prev = info
+
+	return events
+}
+
+func webhookErrorMessage(info *tg.WebhookInfo) string {
+	if info.Error == nil {
+		return ""
+	}
+	return info.Error.Message
+}
+
+// runWebhookHook runs command by shell with alert and webhook info in environment variables.
+func runWebhookHook(ctx context.Context, command string, event webhookEvent, info *tg.WebhookInfo, stdout, stderr io.Writer) error {
+	var cmd *exec.Cmd
+
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.Env = append(os.Environ(),
+		"BOTSH_ALERT="+event.Field,
+		"BOTSH_ALERT_MESSAGE="+event.Message,
+		"BOTSH_WEBHOOK_URL="+info.URL,
+		"BOTSH_WEBHOOK_PENDING="+strconv.Itoa(info.PendingUpdateCount),
+		"BOTSH_WEBHOOK_ERROR="+webhookErrorMessage(info),
+	)
+
+	return cmd.Run()
+}
@@ -3,6 +3,7 @@ module github.com/mr-linch/go-tg/examples/botsh
 go 1.12
 
 require (
+	github.com/chzyer/readline v0.0.0-20161106042343-c914be64f07d
 	github.com/k0kubun/pp v3.0.2-0.20190719145753-b20d3da80efa+incompatible
 	github.com/mattn/go-colorable v0.1.2 // indirect
 	github.com/mr-linch/go-tg v0.0.0-20190724235406-fc0a2e5f1e9c
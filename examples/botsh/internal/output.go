@@ -54,6 +54,27 @@ func (o *jsonOutput) Print(v interface{}) error {
 	return err
 }
 
+// ndjsonOutput prints each value as compact JSON on a single line,
+// so output can be piped to jq or other line-based tools.
+type ndjsonOutput struct {
+	io.Writer
+}
+
+func newNDJSONOutput(w io.Writer) *ndjsonOutput {
+	return &ndjsonOutput{Writer: w}
+}
+
+func (o *ndjsonOutput) Print(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = o.Write(append(data, '\n'))
+
+	return err
+}
+
 type templateOutput struct {
 	io.Writer
 	tmpl *template.Template
@@ -76,6 +97,8 @@ func NewOutput(w io.Writer, format string) Output {
 		return newPrettyOutput(w)
 	case "json":
 		return newJSONOutput(w)
+	case "ndjson":
+		return newNDJSONOutput(w)
 	default:
 		return newTemplateOutput(format, w)
 	}
@@ -9,27 +9,41 @@ import (
 	"github.com/mr-linch/go-tg"
 )
 
+// MetadataDefaultFormat is key of App.Metadata with output format
+// used when --format is not set, e.g. format of profile.
+const MetadataDefaultFormat = "default-format"
+
+// OutputFormat returns value of --format flag or default format from app metadata.
+func OutputFormat(cliCtx *cli.Context) string {
+	if !cliCtx.IsSet("format") {
+		if format, ok := cliCtx.App.Metadata[MetadataDefaultFormat].(string); ok && format != "" {
+			return format
+		}
+	}
+
+	return cliCtx.String("format")
+}
+
 func provideOutput(cliCtx *cli.Context) Output {
 	return NewOutput(
 		cliCtx.App.Writer,
-		cliCtx.String("format"),
+		OutputFormat(cliCtx),
 	)
 }
 
 func provideClient(cliCtx *cli.Context) *tg.Client {
-	domain := cliCtx.GlobalString("api-domain")
-
-	transport := tg.NewHTTPTransport(
-		tg.WithHTTPBuildCallURLFunc(func(token, method string) string {
-			return fmt.Sprintf("https://%s/bot%s/%s", domain, token, method)
-		}),
-		tg.WithHTTPBuildFileURLFunc(func(token, path string) string {
-			return fmt.Sprintf("https://%s/file/bot%s/%s", domain, token, path)
-		}),
-	)
+	server := cliCtx.GlobalString("api-server")
+	if server == "" {
+		server = fmt.Sprintf("https://%s", cliCtx.GlobalString("api-domain"))
+	}
+
+	option := tg.WithServerURL(server)
+	if cliCtx.GlobalBool("local") {
+		option = tg.WithLocalServer(server)
+	}
 
 	return tg.NewClient(cliCtx.GlobalString("token"),
-		tg.WithTransport(transport),
+		option,
 	)
 }
 
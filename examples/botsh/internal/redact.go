@@ -0,0 +1,114 @@
+package internal
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// RedactedSecret replaces secrets in output.
+const RedactedSecret = "<REDACTED>"
+
+// RedactWriter replaces secrets (e.g. bot token) in data written to underlying writer.
+// End of written data which can be start of secret is kept until next Write or Flush,
+// so secret split between writes is redacted too.
+type RedactWriter struct {
+	w io.Writer
+
+	lock    sync.Mutex
+	secrets [][]byte
+
+	// end of written data which is start of some secret
+	pending []byte
+}
+
+// NewRedactWriter creates RedactWriter without secrets.
+func NewRedactWriter(w io.Writer) *RedactWriter {
+	return &RedactWriter{w: w}
+}
+
+// AddSecret adds secret which should be redacted.
+// Already added secrets are ignored, so it can be called on each command run (e.g. in shell).
+func (w *RedactWriter) AddSecret(secret string) {
+	if secret == "" {
+		return
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for _, v := range w.secrets {
+		if string(v) == secret {
+			return
+		}
+	}
+
+	w.secrets = append(w.secrets, []byte(secret))
+}
+
+// Write writes p with redacted secrets.
+func (w *RedactWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if len(w.secrets) == 0 {
+		return w.w.Write(p)
+	}
+
+	data := make([]byte, 0, len(w.pending)+len(p))
+	data = append(data, w.pending...)
+	data = append(data, p...)
+
+	for _, secret := range w.secrets {
+		data = bytes.Replace(data, secret, []byte(RedactedSecret), -1)
+	}
+
+	n := w.partialSecretLen(data)
+	w.pending = append(w.pending[:0], data[len(data)-n:]...)
+	data = data[:len(data)-n]
+
+	if len(data) > 0 {
+		if _, err := w.w.Write(data); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// partialSecretLen returns length of the longest end of data which is start of some secret.
+func (w *RedactWriter) partialSecretLen(data []byte) int {
+	result := 0
+
+	for _, secret := range w.secrets {
+		for n := len(secret) - 1; n > result; n-- {
+			if bytes.HasSuffix(data, secret[:n]) {
+				result = n
+				break
+			}
+		}
+	}
+
+	return result
+}
+
+// Flush writes data kept by previous Write calls.
+func (w *RedactWriter) Flush() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if len(w.pending) == 0 {
+		return nil
+	}
+
+	_, err := w.w.Write(w.pending)
+	w.pending = w.pending[:0]
+
+	return err
+}
+
+// Unredacted returns underlying writer for content which should be written as is, e.g. binary file.
+// Call Flush before writing to it to keep order of output.
+func (w *RedactWriter) Unredacted() io.Writer {
+	return w.w
+}
@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRedactWriter(t *testing.T) {
+	const token = "1234:secret"
+
+	for _, tt := range []struct {
+		Name   string
+		Writes []string
+		Want   string
+	}{
+		{"SingleWrite", []string{"token is 1234:secret\n"}, "token is <REDACTED>\n"},
+		{"SplitSecret", []string{"token is 1234:se", "cret\n"}, "token is <REDACTED>\n"},
+		{"ByteByByte", []string{"1", "2", "3", "4", ":", "s", "e", "c", "r", "e", "t"}, "<REDACTED>"},
+		{"NotSecret", []string{"id 1234", ":public\n"}, "id 1234:public\n"},
+		{"PendingFlushed", []string{"id 1234"}, "id 1234"},
+	} {
+		t.Run(tt.Name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			w := NewRedactWriter(buf)
+			w.AddSecret(token)
+			w.AddSecret(token)
+
+			for _, v := range tt.Writes {
+				n, err := w.Write([]byte(v))
+				if err != nil || n != len(v) {
+					t.Fatalf("write returns %d, %v", n, err)
+				}
+			}
+
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			if buf.String() != tt.Want {
+				t.Errorf("output is %q, want %q", buf.String(), tt.Want)
+			}
+		})
+	}
+}
+
+func TestRedactWriter_Unredacted(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	w := NewRedactWriter(buf)
+	w.AddSecret("1234:secret")
+
+	w.Write([]byte("file: 12"))
+	w.Flush()
+	w.Unredacted().Write([]byte("1234:secret"))
+
+	if want := "file: 121234:secret"; buf.String() != want {
+		t.Errorf("output is %q, want %q", buf.String(), want)
+	}
+}
@@ -8,8 +8,10 @@ import (
 )
 
 func main() {
-	if err := cmd.NewApp().Run(os.Args); err != nil {
-		fmt.Fprintln(os.Stderr, err)
+	app := cmd.NewApp()
+
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(app.ErrWriter, err)
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,309 @@
+package tg
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrExecutorBusy returned by non-blocking Executor when update queue is full.
+	ErrExecutorBusy = errors.New("executor queue is full")
+
+	// ErrExecutorClosed returned by Executor after Close.
+	ErrExecutorClosed = errors.New("executor is closed")
+)
+
+// UpdateKeyFunc returns ordering key of update.
+// Updates with equal keys are handled sequentially in order of arrival.
+type UpdateKeyFunc func(update *Update) int64
+
+// DefaultUpdateKey returns chat ID for messages and channel posts,
+// sender ID for other updates (callback and inline queries, etc.)
+// and zero for updates without sender.
+func DefaultUpdateKey(update *Update) int64 {
+	if msg := update.message(); msg != nil {
+		return int64(msg.Chat.ID)
+	}
+
+	if from := update.From(); from != nil {
+		return int64(from.ID)
+	}
+
+	return 0
+}
+
+// Executor handles updates concurrently using fixed pool of workers.
+// Each update is assigned to worker by key (see UpdateKeyFunc),
+// so updates of the same chat are handled in order, one by one.
+//
+// Executor implements Handler, so it can be used with Poller or Webhook.
+// HandleUpdate only enqueues update, and blocks if worker queue is full.
+// It's a way to slow down poller, when handlers can't keep up.
+// In non-blocking mode ErrExecutorBusy is returned instead, Webhook responds
+// with 503 in this case and Telegram will repeat delivery later.
+//
+// Executor implements Flusher too, so Poller commits offset of updates
+// only after they are handled, not just queued.
+type Executor struct {
+	handler Handler
+
+	key         UpdateKeyFunc
+	workers     int
+	queueSize   int
+	nonBlocking bool
+	onError     func(err error)
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	lock    sync.RWMutex
+	closed  bool
+	done    chan struct{}
+	senders sync.WaitGroup
+	queues  []chan *Update
+	wg      sync.WaitGroup
+
+	// number of accepted but not handled updates,
+	// idle is closed when it becomes zero
+	pendingLock sync.Mutex
+	inflight    int
+	idle        chan struct{}
+}
+
+// ExecutorOption use this for configure executor.
+type ExecutorOption func(executor *Executor)
+
+// WithExecutorKeyFunc sets function used to get ordering key of update.
+func WithExecutorKeyFunc(key UpdateKeyFunc) ExecutorOption {
+	return func(executor *Executor) {
+		executor.key = key
+	}
+}
+
+// WithExecutorWorkers sets max number of concurrently handled updates.
+func WithExecutorWorkers(n int) ExecutorOption {
+	return func(executor *Executor) {
+		executor.workers = n
+	}
+}
+
+// WithExecutorQueueSize sets max number of updates waiting for handling per worker.
+func WithExecutorQueueSize(n int) ExecutorOption {
+	return func(executor *Executor) {
+		executor.queueSize = n
+	}
+}
+
+// WithExecutorNonBlocking makes HandleUpdate return ErrExecutorBusy
+// instead of waiting when queue is full.
+func WithExecutorNonBlocking(yes bool) ExecutorOption {
+	return func(executor *Executor) {
+		executor.nonBlocking = yes
+	}
+}
+
+// WithExecutorErrorHandler sets callback for errors returned by handler.
+// By default, errors are ignored.
+func WithExecutorErrorHandler(onError func(err error)) ExecutorOption {
+	return func(executor *Executor) {
+		executor.onError = onError
+	}
+}
+
+// NewExecutor creates executor and starts workers.
+// Executor should be closed after use.
+func NewExecutor(handler Handler, opts ...ExecutorOption) *Executor {
+	executor := &Executor{
+		handler: handler,
+
+		key:       DefaultUpdateKey,
+		workers:   16,
+		queueSize: 64,
+		onError:   func(err error) {},
+	}
+
+	for _, opt := range opts {
+		opt(executor)
+	}
+
+	if executor.workers < 1 {
+		executor.workers = 1
+	}
+
+	executor.ctx, executor.cancel = context.WithCancel(context.Background())
+	executor.done = make(chan struct{})
+
+	executor.queues = make([]chan *Update, executor.workers)
+
+	for i := range executor.queues {
+		executor.queues[i] = make(chan *Update, executor.queueSize)
+
+		executor.wg.Add(1)
+		go executor.work(executor.queues[i])
+	}
+
+	return executor
+}
+
+func (executor *Executor) work(queue <-chan *Update) {
+	defer executor.wg.Done()
+
+	for update := range queue {
+		if err := executor.handler.HandleUpdate(executor.ctx, update); err != nil {
+			executor.onError(errors.Wrapf(err, "handle update %d", update.ID))
+		}
+
+		executor.release()
+	}
+}
+
+// acquire counts update as accepted.
+func (executor *Executor) acquire() {
+	executor.pendingLock.Lock()
+	defer executor.pendingLock.Unlock()
+
+	if executor.inflight == 0 {
+		executor.idle = make(chan struct{})
+	}
+
+	executor.inflight++
+}
+
+// release counts update as handled (or not accepted).
+func (executor *Executor) release() {
+	executor.pendingLock.Lock()
+	defer executor.pendingLock.Unlock()
+
+	executor.inflight--
+
+	if executor.inflight == 0 {
+		close(executor.idle)
+	}
+}
+
+func (executor *Executor) queue(update *Update) chan *Update {
+	key := uint64(executor.key(update))
+
+	return executor.queues[key%uint64(len(executor.queues))]
+}
+
+// HandleUpdate enqueues update for handling.
+// Provided context is used only for waiting, handlers receive executor context.
+func (executor *Executor) HandleUpdate(ctx context.Context, update *Update) error {
+	executor.lock.RLock()
+
+	if executor.closed {
+		executor.lock.RUnlock()
+		return ErrExecutorClosed
+	}
+
+	// queues are closed by Close only after all senders are done
+	executor.senders.Add(1)
+	defer executor.senders.Done()
+
+	executor.lock.RUnlock()
+
+	// copy update, because caller can reuse it
+	u := *update
+
+	queue := executor.queue(&u)
+
+	executor.acquire()
+
+	if executor.nonBlocking {
+		select {
+		case queue <- &u:
+			return nil
+		default:
+			executor.release()
+			return ErrExecutorBusy
+		}
+	}
+
+	select {
+	case queue <- &u:
+		return nil
+	case <-ctx.Done():
+		executor.release()
+		return ctx.Err()
+	case <-executor.done:
+		executor.release()
+		return ErrExecutorClosed
+	}
+}
+
+// Flush waits until all updates accepted by HandleUpdate are handled.
+func (executor *Executor) Flush(ctx context.Context) error {
+	executor.pendingLock.Lock()
+
+	if executor.inflight == 0 {
+		executor.pendingLock.Unlock()
+		return nil
+	}
+
+	idle := executor.idle
+
+	executor.pendingLock.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Pending returns number of updates waiting for handling.
+func (executor *Executor) Pending() int {
+	total := 0
+
+	for _, queue := range executor.queues {
+		total += len(queue)
+	}
+
+	return total
+}
+
+// Close stops accepting new updates and waits until queued updates are handled.
+// Blocked HandleUpdate calls return ErrExecutorClosed.
+// If ctx is done before, handlers context is canceled and Close returns ctx error.
+func (executor *Executor) Close(ctx context.Context) error {
+	executor.lock.Lock()
+
+	first := !executor.closed
+
+	if first {
+		executor.closed = true
+		close(executor.done)
+	}
+
+	executor.lock.Unlock()
+
+	if first {
+		go func() {
+			executor.senders.Wait()
+
+			for _, queue := range executor.queues {
+				close(queue)
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		executor.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		executor.cancel()
+		return nil
+	case <-ctx.Done():
+		executor.cancel()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,285 @@
+package tg
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMessageUpdate(id UpdateID, chat ChatID) *Update {
+	return &Update{
+		ID:      id,
+		Message: &Message{Chat: Chat{ID: chat}},
+	}
+}
+
+func TestDefaultUpdateKey(t *testing.T) {
+	for _, tt := range []struct {
+		Input Update
+		Key   int64
+	}{
+		{Update{Message: &Message{Chat: Chat{ID: -100}, From: &User{ID: 1}}}, -100},
+		{Update{ChannelPost: &Message{Chat: Chat{ID: -200}}}, -200},
+		{Update{CallbackQuery: &CallbackQuery{From: User{ID: 2}, Message: &Message{Chat: Chat{ID: -100}}}}, 2},
+		{Update{InlineQuery: &InlineQuery{From: User{ID: 3}}}, 3},
+		{Update{Poll: &Poll{}}, 0},
+	} {
+		assert.Equal(t, tt.Key, DefaultUpdateKey(&tt.Input))
+	}
+}
+
+func TestExecutor_Ordering(t *testing.T) {
+	var (
+		lock    sync.Mutex
+		handled = map[ChatID][]UpdateID{}
+	)
+
+	executor := NewExecutor(
+		HandlerFunc(func(ctx context.Context, update *Update) error {
+			// give a chance to reorder
+			time.Sleep(time.Microsecond * time.Duration(update.ID%3))
+
+			lock.Lock()
+			defer lock.Unlock()
+
+			chat := update.Message.Chat.ID
+			handled[chat] = append(handled[chat], update.ID)
+
+			return nil
+		}),
+		WithExecutorWorkers(4),
+		WithExecutorQueueSize(2),
+	)
+
+	ctx := context.Background()
+
+	chats := []ChatID{1, 2, 3, -100, -200}
+
+	for i := 0; i < 100; i++ {
+		update := newMessageUpdate(UpdateID(i), chats[i%len(chats)])
+		require.NoError(t, executor.HandleUpdate(ctx, update))
+	}
+
+	require.NoError(t, executor.Close(ctx))
+
+	for _, chat := range chats {
+		ids := handled[chat]
+
+		assert.Len(t, ids, 20)
+
+		for i := 1; i < len(ids); i++ {
+			assert.True(t, ids[i-1] < ids[i], "chat %d: %v", chat, ids)
+		}
+	}
+}
+
+func TestExecutor_Concurrency(t *testing.T) {
+	var (
+		current int32
+		max     int32
+	)
+
+	executor := NewExecutor(
+		HandlerFunc(func(ctx context.Context, update *Update) error {
+			n := atomic.AddInt32(&current, 1)
+			defer atomic.AddInt32(&current, -1)
+
+			for {
+				m := atomic.LoadInt32(&max)
+				if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+					break
+				}
+			}
+
+			time.Sleep(time.Millisecond)
+
+			return nil
+		}),
+		WithExecutorWorkers(3),
+	)
+
+	ctx := context.Background()
+
+	for i := 0; i < 30; i++ {
+		require.NoError(t, executor.HandleUpdate(ctx, newMessageUpdate(UpdateID(i), ChatID(i))))
+	}
+
+	require.NoError(t, executor.Close(ctx))
+
+	assert.True(t, max <= 3, "max concurrency is %d", max)
+}
+
+func TestExecutor_Backpressure(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+
+	handler := HandlerFunc(func(ctx context.Context, update *Update) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	})
+
+	t.Run("NonBlocking", func(t *testing.T) {
+		executor := NewExecutor(handler,
+			WithExecutorWorkers(1),
+			WithExecutorQueueSize(1),
+			WithExecutorNonBlocking(true),
+		)
+
+		ctx := context.Background()
+
+		// first is handling, second is in queue
+		require.NoError(t, executor.HandleUpdate(ctx, newMessageUpdate(1, 1)))
+		<-started
+		require.NoError(t, executor.HandleUpdate(ctx, newMessageUpdate(2, 1)))
+
+		assert.Equal(t, 1, executor.Pending())
+		assert.Equal(t, ErrExecutorBusy, executor.HandleUpdate(ctx, newMessageUpdate(3, 1)))
+
+		release <- struct{}{}
+		<-started
+		release <- struct{}{}
+
+		require.NoError(t, executor.Close(ctx))
+	})
+
+	t.Run("Blocking", func(t *testing.T) {
+		executor := NewExecutor(handler,
+			WithExecutorWorkers(1),
+			WithExecutorQueueSize(1),
+		)
+
+		ctx := context.Background()
+
+		require.NoError(t, executor.HandleUpdate(ctx, newMessageUpdate(1, 1)))
+		<-started
+		require.NoError(t, executor.HandleUpdate(ctx, newMessageUpdate(2, 1)))
+
+		waitCtx, cancel := context.WithTimeout(ctx, time.Millisecond*10)
+		defer cancel()
+
+		assert.Equal(t, context.DeadlineExceeded, executor.HandleUpdate(waitCtx, newMessageUpdate(3, 1)))
+
+		release <- struct{}{}
+		<-started
+		release <- struct{}{}
+
+		require.NoError(t, executor.Close(ctx))
+	})
+}
+
+func TestExecutor_Close(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Closed", func(t *testing.T) {
+		executor := NewExecutor(HandlerFunc(func(ctx context.Context, update *Update) error {
+			return nil
+		}))
+
+		require.NoError(t, executor.Close(ctx))
+		require.NoError(t, executor.Close(ctx))
+
+		assert.Equal(t, ErrExecutorClosed, executor.HandleUpdate(ctx, newMessageUpdate(1, 1)))
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		executor := NewExecutor(HandlerFunc(func(ctx context.Context, update *Update) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}))
+
+		require.NoError(t, executor.HandleUpdate(ctx, newMessageUpdate(1, 1)))
+
+		closeCtx, cancel := context.WithTimeout(ctx, time.Millisecond*10)
+		defer cancel()
+
+		assert.Equal(t, context.DeadlineExceeded, executor.Close(closeCtx))
+	})
+
+	t.Run("BlockedSender", func(t *testing.T) {
+		release := make(chan struct{})
+
+		executor := NewExecutor(
+			HandlerFunc(func(ctx context.Context, update *Update) error {
+				<-release
+				return nil
+			}),
+			WithExecutorWorkers(1),
+			WithExecutorQueueSize(0),
+		)
+
+		require.NoError(t, executor.HandleUpdate(ctx, newMessageUpdate(1, 1)))
+
+		blocked := make(chan error, 1)
+
+		go func() {
+			blocked <- executor.HandleUpdate(ctx, newMessageUpdate(2, 1))
+		}()
+
+		// give sender time to block on full queue
+		time.Sleep(time.Millisecond * 10)
+
+		closeCtx, cancel := context.WithTimeout(ctx, time.Millisecond*10)
+		defer cancel()
+
+		assert.Equal(t, context.DeadlineExceeded, executor.Close(closeCtx))
+		assert.Equal(t, ErrExecutorClosed, <-blocked)
+
+		close(release)
+		require.NoError(t, executor.Close(ctx))
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		var errs []string
+
+		executor := NewExecutor(
+			HandlerFunc(func(ctx context.Context, update *Update) error {
+				return errors.New("handler error")
+			}),
+			WithExecutorWorkers(1),
+			WithExecutorErrorHandler(func(err error) {
+				errs = append(errs, err.Error())
+			}),
+		)
+
+		require.NoError(t, executor.HandleUpdate(ctx, newMessageUpdate(1, 1)))
+		require.NoError(t, executor.Close(ctx))
+
+		assert.Equal(t, []string{"handle update 1: handler error"}, errs)
+	})
+}
+
+func TestExecutor_Flush(t *testing.T) {
+	ctx := context.Background()
+
+	release := make(chan struct{})
+	var handled int32
+
+	executor := NewExecutor(HandlerFunc(func(ctx context.Context, update *Update) error {
+		<-release
+		atomic.AddInt32(&handled, 1)
+		return nil
+	}))
+	defer executor.Close(ctx)
+
+	require.NoError(t, executor.Flush(ctx))
+
+	require.NoError(t, executor.HandleUpdate(ctx, newMessageUpdate(1, 1)))
+	require.NoError(t, executor.HandleUpdate(ctx, newMessageUpdate(2, 2)))
+
+	flushCtx, cancel := context.WithTimeout(ctx, time.Millisecond*10)
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, executor.Flush(flushCtx))
+
+	close(release)
+
+	require.NoError(t, executor.Flush(ctx))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&handled))
+}
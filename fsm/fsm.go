@@ -0,0 +1,331 @@
+// Package fsm implements conversations (multi-step dialogs) on top of tg.Handler.
+//
+// Each conversation is identified by pair of chat and user,
+// has current state and data collected on previous steps.
+// Updates of users without active conversation are passed to the fallback handler,
+// which usually starts conversations by commands.
+//
+// Example:
+//
+//   machine := fsm.New(fsm.NewMemoryStorage(), fsm.WithFallback(commands))
+//
+//   machine.State("name", func(ctx *fsm.Context) error {
+//       ctx.Set("name", ctx.Update.Message.Text)
+//       return ctx.Transition("phone")
+//   }, fsm.WithTransitions("phone"), fsm.WithTimeout(time.Minute*5))
+//
+//   machine.State("phone", func(ctx *fsm.Context) error {
+//       ctx.Set("phone", ctx.Update.Message.Text)
+//       return ctx.Transition("confirm")
+//   }, fsm.WithTransitions("confirm"))
+//
+package fsm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mr-linch/go-tg"
+)
+
+// State identifies step of conversation.
+type State string
+
+// StateHandler handles update received in particular state.
+type StateHandler func(ctx *Context) error
+
+// ErrTransitionNotAllowed returned by Context.Transition
+// if target state is not listed in transitions of current state.
+var ErrTransitionNotAllowed = errors.New("transition is not allowed")
+
+type stateConfig struct {
+	handler     StateHandler
+	transitions map[State]bool
+	timeout     time.Duration
+}
+
+// StateOption use this for configure state.
+type StateOption func(state *stateConfig)
+
+// WithTransitions sets list of states allowed to transit from this state.
+// By default, transition to any registered state is allowed.
+func WithTransitions(states ...State) StateOption {
+	return func(state *stateConfig) {
+		state.transitions = make(map[State]bool, len(states))
+
+		for _, s := range states {
+			state.transitions[s] = true
+		}
+	}
+}
+
+// WithTimeout sets time of inactivity after which conversation in this state is expired.
+// Timeout is checked on next update of user.
+func WithTimeout(timeout time.Duration) StateOption {
+	return func(state *stateConfig) {
+		state.timeout = timeout
+	}
+}
+
+// Machine routes updates of active conversations to state handlers.
+// Machine implements tg.Handler.
+type Machine struct {
+	storage  Storage
+	states   map[State]*stateConfig
+	fallback tg.Handler
+	expired  StateHandler
+
+	now func() time.Time
+}
+
+// Option use this for configure machine.
+type Option func(machine *Machine)
+
+// WithFallback sets handler of updates without active conversation.
+func WithFallback(handler tg.Handler) Option {
+	return func(machine *Machine) {
+		machine.fallback = handler
+	}
+}
+
+// WithExpiredHandler sets handler called when update is received in expired conversation.
+// Context contains state of expired conversation, transitions are not allowed.
+// After that, update is passed to the fallback handler.
+func WithExpiredHandler(handler StateHandler) Option {
+	return func(machine *Machine) {
+		machine.expired = handler
+	}
+}
+
+// New creates state machine with provided storage.
+func New(storage Storage, opts ...Option) *Machine {
+	machine := &Machine{
+		storage: storage,
+		states:  make(map[State]*stateConfig),
+		now:     time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(machine)
+	}
+
+	return machine
+}
+
+// State registers handler of state.
+func (machine *Machine) State(state State, handler StateHandler, opts ...StateOption) *Machine {
+	config := &stateConfig{handler: handler}
+
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	machine.states[state] = config
+
+	return machine
+}
+
+func (machine *Machine) newRecord(state State, data map[string]string) (*Record, error) {
+	config, ok := machine.states[state]
+	if !ok {
+		return nil, fmt.Errorf("state '%s' is not registered", state)
+	}
+
+	record := &Record{
+		State: state,
+		Data:  data,
+	}
+
+	if config.timeout > 0 {
+		record.Expires = machine.now().Add(config.timeout)
+	}
+
+	return record, nil
+}
+
+// Start begins conversation with key in provided state.
+// Active conversation with the same key is replaced.
+func (machine *Machine) Start(ctx context.Context, key Key, state State, data map[string]string) error {
+	record, err := machine.newRecord(state, data)
+	if err != nil {
+		return err
+	}
+
+	return machine.storage.Set(ctx, key, record)
+}
+
+// Finish ends conversation with key.
+func (machine *Machine) Finish(ctx context.Context, key Key) error {
+	return machine.storage.Delete(ctx, key)
+}
+
+// Get returns active conversation with key or nil.
+func (machine *Machine) Get(ctx context.Context, key Key) (*Record, error) {
+	record, err := machine.storage.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if record == nil || record.IsExpired(machine.now()) {
+		return nil, nil
+	}
+
+	return record, nil
+}
+
+func (machine *Machine) handleFallback(ctx context.Context, update *tg.Update) error {
+	if machine.fallback != nil {
+		return machine.fallback.HandleUpdate(ctx, update)
+	}
+
+	return nil
+}
+
+// HandleUpdate passes update to handler of current state or to fallback handler.
+func (machine *Machine) HandleUpdate(ctx context.Context, update *tg.Update) error {
+	key, ok := KeyFromUpdate(update)
+	if !ok {
+		return machine.handleFallback(ctx, update)
+	}
+
+	record, err := machine.storage.Get(ctx, key)
+	if err != nil {
+		return errors.Wrap(err, "get conversation")
+	}
+
+	if record == nil {
+		return machine.handleFallback(ctx, update)
+	}
+
+	if record.IsExpired(machine.now()) {
+		if err := machine.storage.Delete(ctx, key); err != nil {
+			return errors.Wrap(err, "delete expired conversation")
+		}
+
+		if machine.expired != nil {
+			c := newContext(ctx, machine, update, key, record)
+			c.final = true
+
+			if err := machine.expired(c); err != nil {
+				return err
+			}
+		}
+
+		return machine.handleFallback(ctx, update)
+	}
+
+	config, ok := machine.states[record.State]
+	if !ok {
+		return fmt.Errorf("state '%s' is not registered", record.State)
+	}
+
+	c := newContext(ctx, machine, update, key, record)
+
+	if err := config.handler(c); err != nil {
+		return err
+	}
+
+	return c.commit()
+}
+
+// Context passed to state handlers.
+type Context struct {
+	context.Context
+
+	// Received update.
+	Update *tg.Update
+
+	// Conversation key.
+	Key Key
+
+	machine *Machine
+	record  *Record
+
+	next     State
+	finished bool
+	final    bool
+}
+
+func newContext(ctx context.Context, machine *Machine, update *tg.Update, key Key, record *Record) *Context {
+	if record.Data == nil {
+		record.Data = make(map[string]string)
+	}
+
+	return &Context{
+		Context: ctx,
+		Update:  update,
+		Key:     key,
+		machine: machine,
+		record:  record,
+		next:    record.State,
+	}
+}
+
+// State returns current state.
+func (ctx *Context) State() State {
+	return ctx.record.State
+}
+
+// Get returns value of conversation data.
+func (ctx *Context) Get(k string) string {
+	return ctx.record.Data[k]
+}
+
+// Set sets value of conversation data.
+func (ctx *Context) Set(k, v string) {
+	ctx.record.Data[k] = v
+}
+
+// Data returns all conversation data.
+func (ctx *Context) Data() map[string]string {
+	return ctx.record.Data
+}
+
+// Transition switches conversation to state after handler returns.
+// Returns ErrTransitionNotAllowed, if state is not listed in transitions of current state.
+func (ctx *Context) Transition(state State) error {
+	if ctx.final {
+		return ErrTransitionNotAllowed
+	}
+
+	if _, ok := ctx.machine.states[state]; !ok {
+		return fmt.Errorf("state '%s' is not registered", state)
+	}
+
+	config := ctx.machine.states[ctx.record.State]
+	if config.transitions != nil && !config.transitions[state] {
+		return errors.Wrapf(ErrTransitionNotAllowed, "%s -> %s", ctx.record.State, state)
+	}
+
+	ctx.next = state
+	ctx.finished = false
+
+	return nil
+}
+
+// Finish ends conversation after handler returns.
+func (ctx *Context) Finish() {
+	ctx.finished = true
+}
+
+func (ctx *Context) commit() error {
+	if ctx.finished {
+		return errors.Wrap(
+			ctx.machine.storage.Delete(ctx, ctx.Key),
+			"delete conversation",
+		)
+	}
+
+	record, err := ctx.machine.newRecord(ctx.next, ctx.record.Data)
+	if err != nil {
+		return err
+	}
+
+	return errors.Wrap(
+		ctx.machine.storage.Set(ctx, ctx.Key, record),
+		"save conversation",
+	)
+}
@@ -0,0 +1,199 @@
+package fsm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mr-linch/go-tg"
+)
+
+func newTextUpdate(chat tg.ChatID, user tg.UserID, text string) *tg.Update {
+	return &tg.Update{
+		Message: &tg.Message{
+			Chat: tg.Chat{ID: chat},
+			From: &tg.User{ID: user},
+			Text: text,
+		},
+	}
+}
+
+func TestKeyFromUpdate(t *testing.T) {
+	key, ok := KeyFromUpdate(newTextUpdate(-100, 1, ""))
+	assert.True(t, ok)
+	assert.Equal(t, Key{ChatID: -100, UserID: 1}, key)
+	assert.Equal(t, "-100:1", key.String())
+
+	key, ok = KeyFromUpdate(&tg.Update{InlineQuery: &tg.InlineQuery{From: tg.User{ID: 2}}})
+	assert.True(t, ok)
+	assert.Equal(t, Key{UserID: 2}, key)
+
+	_, ok = KeyFromUpdate(&tg.Update{Poll: &tg.Poll{}})
+	assert.False(t, ok)
+}
+
+func newRegistrationMachine(storage Storage) *Machine {
+	var machine *Machine
+
+	machine = New(storage,
+		WithFallback(tg.HandlerFunc(func(ctx context.Context, update *tg.Update) error {
+			if update.Message.Text == "/start" {
+				key, _ := KeyFromUpdate(update)
+				return machine.Start(ctx, key, "name", nil)
+			}
+
+			return nil
+		})),
+	)
+
+	machine.State("name", func(ctx *Context) error {
+		ctx.Set("name", ctx.Update.Message.Text)
+		return ctx.Transition("phone")
+	}, WithTransitions("phone"), WithTimeout(time.Minute))
+
+	machine.State("phone", func(ctx *Context) error {
+		ctx.Set("phone", ctx.Update.Message.Text)
+		return ctx.Transition("confirm")
+	}, WithTransitions("confirm"))
+
+	machine.State("confirm", func(ctx *Context) error {
+		if ctx.Update.Message.Text == "yes" {
+			ctx.Finish()
+			return nil
+		}
+
+		return ctx.Transition("name")
+	})
+
+	return machine
+}
+
+func TestMachine_HandleUpdate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Conversation", func(t *testing.T) {
+		machine := newRegistrationMachine(NewMemoryStorage())
+
+		key := Key{ChatID: 1, UserID: 1}
+
+		for _, step := range []struct {
+			Text  string
+			State State
+		}{
+			{"/start", "name"},
+			{"Sasha", "phone"},
+			{"+380", "confirm"},
+		} {
+			require.NoError(t, machine.HandleUpdate(ctx, newTextUpdate(1, 1, step.Text)))
+
+			record, err := machine.Get(ctx, key)
+			require.NoError(t, err)
+			require.NotNil(t, record)
+			assert.Equal(t, step.State, record.State)
+		}
+
+		record, err := machine.Get(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"name": "Sasha", "phone": "+380"}, record.Data)
+
+		// other user is not affected
+		record, err = machine.Get(ctx, Key{ChatID: 1, UserID: 2})
+		require.NoError(t, err)
+		assert.Nil(t, record)
+
+		require.NoError(t, machine.HandleUpdate(ctx, newTextUpdate(1, 1, "yes")))
+
+		record, err = machine.Get(ctx, key)
+		require.NoError(t, err)
+		assert.Nil(t, record)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		storage := NewMemoryStorage()
+
+		now := time.Date(2019, 7, 27, 0, 0, 0, 0, time.UTC)
+
+		var (
+			expired  []State
+			fallback []string
+		)
+
+		machine := New(storage,
+			WithExpiredHandler(func(ctx *Context) error {
+				expired = append(expired, ctx.State())
+				assert.Equal(t, ErrTransitionNotAllowed, ctx.Transition("name"))
+				return nil
+			}),
+			WithFallback(tg.HandlerFunc(func(ctx context.Context, update *tg.Update) error {
+				fallback = append(fallback, update.Message.Text)
+				return nil
+			})),
+		)
+		machine.now = func() time.Time { return now }
+
+		machine.State("name", func(ctx *Context) error {
+			return nil
+		}, WithTimeout(time.Minute))
+
+		key := Key{ChatID: 1, UserID: 1}
+
+		require.NoError(t, machine.Start(ctx, key, "name", nil))
+
+		now = now.Add(time.Minute * 2)
+
+		record, err := machine.Get(ctx, key)
+		require.NoError(t, err)
+		assert.Nil(t, record)
+
+		require.NoError(t, machine.HandleUpdate(ctx, newTextUpdate(1, 1, "late")))
+
+		assert.Equal(t, []State{"name"}, expired)
+		assert.Equal(t, []string{"late"}, fallback)
+
+		record, err = storage.Get(ctx, key)
+		require.NoError(t, err)
+		assert.Nil(t, record)
+	})
+
+	t.Run("TransitionNotAllowed", func(t *testing.T) {
+		machine := New(NewMemoryStorage())
+
+		machine.State("a", func(ctx *Context) error {
+			return ctx.Transition("c")
+		}, WithTransitions("b"))
+		machine.State("b", func(ctx *Context) error { return nil })
+		machine.State("c", func(ctx *Context) error { return nil })
+
+		key := Key{ChatID: 1, UserID: 1}
+		require.NoError(t, machine.Start(ctx, key, "a", nil))
+
+		err := machine.HandleUpdate(ctx, newTextUpdate(1, 1, "test"))
+		assert.Equal(t, ErrTransitionNotAllowed, errors.Cause(err))
+
+		record, err := machine.Get(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, State("a"), record.State)
+	})
+
+	t.Run("UnknownState", func(t *testing.T) {
+		machine := New(NewMemoryStorage())
+
+		assert.Error(t, machine.Start(ctx, Key{ChatID: 1}, "unknown", nil))
+	})
+
+	t.Run("NoKey", func(t *testing.T) {
+		called := false
+
+		machine := New(NewMemoryStorage(), WithFallback(tg.HandlerFunc(func(ctx context.Context, update *tg.Update) error {
+			called = true
+			return nil
+		})))
+
+		require.NoError(t, machine.HandleUpdate(ctx, &tg.Update{Poll: &tg.Poll{}}))
+		assert.True(t, called)
+	})
+}
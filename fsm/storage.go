@@ -0,0 +1,127 @@
+package fsm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mr-linch/go-tg"
+)
+
+// Key identifies conversation of user in the chat.
+type Key struct {
+	ChatID tg.ChatID
+	UserID tg.UserID
+}
+
+// KeyFromUpdate returns conversation key of update.
+// Returns false if update has neither chat nor sender (e.g. poll).
+func KeyFromUpdate(update *tg.Update) (Key, bool) {
+	var key Key
+
+	chat := update.Chat()
+	if chat != nil {
+		key.ChatID = chat.ID
+	}
+
+	from := update.From()
+	if from != nil {
+		key.UserID = from.ID
+	}
+
+	return key, chat != nil || from != nil
+}
+
+// String returns key in format "chat_id:user_id".
+func (key Key) String() string {
+	return fmt.Sprintf("%d:%d", key.ChatID, key.UserID)
+}
+
+// Record represents stored conversation.
+type Record struct {
+	// Current state of conversation.
+	State State `json:"state"`
+
+	// Data collected during conversation.
+	Data map[string]string `json:"data,omitempty"`
+
+	// Time when conversation expires, zero if never.
+	Expires time.Time `json:"expires,omitempty"`
+}
+
+// IsExpired returns true if conversation is expired at the moment.
+func (record *Record) IsExpired(now time.Time) bool {
+	return !record.Expires.IsZero() && !now.Before(record.Expires)
+}
+
+// Storage define interface of conversations storage.
+type Storage interface {
+	// Get returns conversation by key or nil if not found.
+	Get(ctx context.Context, key Key) (*Record, error)
+
+	// Set creates or replaces conversation.
+	Set(ctx context.Context, key Key, record *Record) error
+
+	// Delete removes conversation. Not found conversation is not an error.
+	Delete(ctx context.Context, key Key) error
+}
+
+// MemoryStorage keeps conversations in memory.
+type MemoryStorage struct {
+	lock    sync.RWMutex
+	records map[Key]Record
+}
+
+// NewMemoryStorage creates in-memory storage.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		records: make(map[Key]Record),
+	}
+}
+
+// Get returns copy of stored record.
+func (storage *MemoryStorage) Get(ctx context.Context, key Key) (*Record, error) {
+	storage.lock.RLock()
+	defer storage.lock.RUnlock()
+
+	record, ok := storage.records[key]
+	if !ok {
+		return nil, nil
+	}
+
+	return copyRecord(&record), nil
+}
+
+// Set stores copy of record.
+func (storage *MemoryStorage) Set(ctx context.Context, key Key, record *Record) error {
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+
+	storage.records[key] = *copyRecord(record)
+
+	return nil
+}
+
+// Delete removes record.
+func (storage *MemoryStorage) Delete(ctx context.Context, key Key) error {
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+
+	delete(storage.records, key)
+
+	return nil
+}
+
+func copyRecord(record *Record) *Record {
+	result := *record
+
+	if record.Data != nil {
+		result.Data = make(map[string]string, len(record.Data))
+		for k, v := range record.Data {
+			result.Data[k] = v
+		}
+	}
+
+	return &result
+}
@@ -0,0 +1,161 @@
+package fsm
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/mr-linch/go-tg"
+	"github.com/mr-linch/go-tg/internal/fileutil"
+)
+
+// FileStorage keeps conversations in single JSON file.
+// All records are kept in memory and the whole file is rewritten atomically
+// (write to temporary file, then rename) on every change.
+// It's suitable for single process bots with moderate number of active conversations.
+type FileStorage struct {
+	lock    sync.Mutex
+	path    string
+	records map[Key]*Record
+}
+
+// NewFileStorage creates file storage and loads existing records from path.
+// File is created on first change.
+func NewFileStorage(path string) (*FileStorage, error) {
+	storage := &FileStorage{
+		path:    path,
+		records: make(map[Key]*Record),
+	}
+
+	if err := storage.load(); err != nil {
+		return nil, err
+	}
+
+	return storage, nil
+}
+
+func (storage *FileStorage) load() error {
+	content, err := ioutil.ReadFile(storage.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "read storage file")
+	}
+
+	records := make(map[string]*Record)
+
+	if err := json.Unmarshal(content, &records); err != nil {
+		return errors.Wrap(err, "unmarshal storage file")
+	}
+
+	for k, record := range records {
+		key, err := parseKey(k)
+		if err != nil {
+			return errors.Wrapf(err, "parse key '%s'", k)
+		}
+
+		storage.records[key] = record
+	}
+
+	return nil
+}
+
+func (storage *FileStorage) save() error {
+	records := make(map[string]*Record, len(storage.records))
+
+	for key, record := range storage.records {
+		records[key.String()] = record
+	}
+
+	content, err := json.Marshal(records)
+	if err != nil {
+		return errors.Wrap(err, "marshal records")
+	}
+
+	return errors.Wrap(
+		fileutil.WriteFileAtomic(storage.path, content),
+		"write storage file",
+	)
+}
+
+// Get returns copy of stored record.
+func (storage *FileStorage) Get(ctx context.Context, key Key) (*Record, error) {
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+
+	record, ok := storage.records[key]
+	if !ok {
+		return nil, nil
+	}
+
+	return copyRecord(record), nil
+}
+
+// Set stores record and writes file.
+func (storage *FileStorage) Set(ctx context.Context, key Key, record *Record) error {
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+
+	prev, exists := storage.records[key]
+
+	storage.records[key] = copyRecord(record)
+
+	if err := storage.save(); err != nil {
+		if exists {
+			storage.records[key] = prev
+		} else {
+			delete(storage.records, key)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Delete removes record and writes file.
+func (storage *FileStorage) Delete(ctx context.Context, key Key) error {
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+
+	prev, exists := storage.records[key]
+	if !exists {
+		return nil
+	}
+
+	delete(storage.records, key)
+
+	if err := storage.save(); err != nil {
+		storage.records[key] = prev
+		return err
+	}
+
+	return nil
+}
+
+func parseKey(v string) (Key, error) {
+	i := strings.LastIndex(v, ":")
+	if i == -1 {
+		return Key{}, errors.New("invalid key format")
+	}
+
+	chatID, err := strconv.ParseInt(v[:i], 10, 64)
+	if err != nil {
+		return Key{}, errors.Wrap(err, "parse chat id")
+	}
+
+	userID, err := strconv.Atoi(v[i+1:])
+	if err != nil {
+		return Key{}, errors.Wrap(err, "parse user id")
+	}
+
+	return Key{
+		ChatID: tg.ChatID(chatID),
+		UserID: tg.UserID(userID),
+	}, nil
+}
@@ -0,0 +1,97 @@
+package fsm
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testStorage(t *testing.T, storage Storage) {
+	ctx := context.Background()
+
+	key := Key{ChatID: -100, UserID: 1}
+
+	record, err := storage.Get(ctx, key)
+	require.NoError(t, err)
+	assert.Nil(t, record)
+
+	expires := time.Date(2019, 7, 27, 0, 0, 0, 0, time.UTC)
+
+	require.NoError(t, storage.Set(ctx, key, &Record{
+		State:   "name",
+		Data:    map[string]string{"k": "v"},
+		Expires: expires,
+	}))
+
+	record, err = storage.Get(ctx, key)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, State("name"), record.State)
+	assert.Equal(t, map[string]string{"k": "v"}, record.Data)
+	assert.True(t, expires.Equal(record.Expires))
+
+	// returned record is a copy
+	record.Data["k"] = "changed"
+
+	record, err = storage.Get(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, "v", record.Data["k"])
+
+	require.NoError(t, storage.Delete(ctx, key))
+	require.NoError(t, storage.Delete(ctx, key))
+
+	record, err = storage.Get(ctx, key)
+	require.NoError(t, err)
+	assert.Nil(t, record)
+}
+
+func TestMemoryStorage(t *testing.T) {
+	testStorage(t, NewMemoryStorage())
+}
+
+func TestFileStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-tg-fsm")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "fsm.json")
+
+	t.Run("Generic", func(t *testing.T) {
+		storage, err := NewFileStorage(path)
+		require.NoError(t, err)
+
+		testStorage(t, storage)
+	})
+
+	t.Run("Reopen", func(t *testing.T) {
+		ctx := context.Background()
+
+		storage, err := NewFileStorage(path)
+		require.NoError(t, err)
+
+		key := Key{ChatID: -100, UserID: 1}
+
+		require.NoError(t, storage.Set(ctx, key, &Record{State: "phone"}))
+
+		storage, err = NewFileStorage(path)
+		require.NoError(t, err)
+
+		record, err := storage.Get(ctx, key)
+		require.NoError(t, err)
+		require.NotNil(t, record)
+		assert.Equal(t, State("phone"), record.State)
+	})
+
+	t.Run("BadFile", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(path, []byte(`{"bad": {}}`), 0600))
+
+		_, err := NewFileStorage(path)
+		assert.Error(t, err)
+	})
+}
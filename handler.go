@@ -0,0 +1,22 @@
+package tg
+
+import "context"
+
+// Handler define interface of incoming updates handler.
+type Handler interface {
+	HandleUpdate(ctx context.Context, update *Update) error
+}
+
+// Flusher is implemented by handlers processing updates asynchronously, like Executor.
+// Flush waits until all updates passed to HandleUpdate are handled.
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// HandlerFunc it's adapter for use ordinary functions as Handler.
+type HandlerFunc func(ctx context.Context, update *Update) error
+
+// HandleUpdate calls f(ctx, update).
+func (f HandlerFunc) HandleUpdate(ctx context.Context, update *Update) error {
+	return f(ctx, update)
+}
@@ -3,10 +3,41 @@ package tg
 import (
 	"bytes"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+
+	"github.com/pkg/errors"
 )
 
+// ErrInputFileNotReopenable returned by InputFile.Rewind, if file can't be read again.
+var ErrInputFileNotReopenable = errors.New("input file can't be reopened")
+
+// InputFileOpener opens content of file from the beginning.
+type InputFileOpener interface {
+	Open() (io.Reader, error)
+}
+
+// InputFileOpenerFunc is an adapter to use ordinary functions as InputFileOpener.
+type InputFileOpenerFunc func() (io.Reader, error)
+
+// Open calls fn().
+func (fn InputFileOpenerFunc) Open() (io.Reader, error) {
+	return fn()
+}
+
+type bytesOpener []byte
+
+func (content bytesOpener) Open() (io.Reader, error) {
+	return bytes.NewReader(content), nil
+}
+
+type localOpener string
+
+func (path localOpener) Open() (io.Reader, error) {
+	return os.Open(string(path))
+}
+
 // InputFile represents the file that should be uploaded to the telegram.
 type InputFile struct {
 	// Filename
@@ -14,6 +45,18 @@ type InputFile struct {
 
 	// Body of file
 	Body io.Reader
+
+	// Optional. Size of file in bytes, 0 if unknown.
+	// If all files of request have size, Content-Length of upload request is set.
+	Size int64
+
+	// Optional. Opens file body again from the beginning,
+	// allows to repeat upload (e.g. on retry).
+	Reopen InputFileOpener
+
+	// Optional. Called during upload with number of sent bytes of file
+	// and total size of file (0 if unknown).
+	Progress func(sent, total int64)
 }
 
 // NewInputFile creates the InputFile from provided name and body reader.
@@ -25,19 +68,26 @@ func NewInputFile(name string, body io.Reader) InputFile {
 }
 
 // NewInputFileBytes creates input file from provided name.
+// File has size and can be reopened.
 //
 // Example:
 //   file := NewInputFileBytes("test.txt", []byte("test, test, test..."))
 func NewInputFileBytes(name string, body []byte) InputFile {
-	return NewInputFile(
+	file := NewInputFile(
 		name,
 		bytes.NewReader(body),
 	)
+
+	file.Size = int64(len(body))
+	file.Reopen = bytesOpener(body)
+
+	return file
 }
 
 // NewInputFileLocal creates the InputFile from provided local file.
 // This method just open file by provided path.
 // So, you should close it AFTER send.
+// File has size and can be reopened.
 //
 // Example:
 //
@@ -53,10 +103,21 @@ func NewInputFileLocal(path string) (InputFile, error) {
 		return InputFile{}, err
 	}
 
-	return NewInputFile(
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return InputFile{}, err
+	}
+
+	result := NewInputFile(
 		filepath.Base(file.Name()),
 		file,
-	), nil
+	)
+
+	result.Size = info.Size()
+	result.Reopen = localOpener(path)
+
+	return result, nil
 }
 
 // NewInputFileLocalBuffer creates the InputFile from provided local file path.
@@ -66,16 +127,39 @@ func NewInputFileLocalBuffer(path string) (InputFile, error) {
 	if err != nil {
 		return InputFile{}, err
 	}
+	defer file.Close()
 
-	name := filepath.Base(file.Name())
+	body, err := ioutil.ReadAll(file)
+	if err != nil {
+		return InputFile{}, err
+	}
 
-	body := &bytes.Buffer{}
+	return NewInputFileBytes(filepath.Base(file.Name()), body), nil
+}
 
-	if _, err := io.Copy(body, file); err != nil {
-		return InputFile{}, err
+// WithProgress returns copy of file with upload progress callback.
+func (file InputFile) WithProgress(progress func(sent, total int64)) InputFile {
+	file.Progress = progress
+	return file
+}
+
+// Rewind returns copy of file with body reopened from the beginning.
+// Current body is closed, so close the returned file after use.
+// Returns ErrInputFileNotReopenable if file has no Reopen function.
+func (file InputFile) Rewind() (InputFile, error) {
+	if file.Reopen == nil {
+		return file, ErrInputFileNotReopenable
+	}
+
+	body, err := file.Reopen.Open()
+	if err != nil {
+		return file, errors.Wrap(err, "reopen input file")
 	}
 
-	return NewInputFile(name, body), nil
+	file.Close()
+	file.Body = body
+
+	return file, nil
 }
 
 // Close call close method on body if it implements io.ReadCloser,
@@ -92,3 +176,23 @@ func (file InputFile) Close() error {
 func (file InputFile) AddFileToRequest(k string, r *Request) {
 	r.AddFile(k, file)
 }
+
+// progressReader reports number of read bytes to callback.
+type progressReader struct {
+	io.Reader
+
+	sent     int64
+	total    int64
+	progress func(sent, total int64)
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+
+	if n > 0 {
+		r.sent += int64(n)
+		r.progress(r.sent, r.total)
+	}
+
+	return n, err
+}
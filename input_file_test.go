@@ -2,6 +2,7 @@ package tg
 
 import (
 	"io/ioutil"
+	"os"
 	"strings"
 	"testing"
 
@@ -80,3 +81,60 @@ func TestInputFile_AddFileToRequest(t *testing.T) {
 		file,
 	)
 }
+
+func TestInputFile_Rewind(t *testing.T) {
+	t.Run("Bytes", func(t *testing.T) {
+		file := NewInputFileBytes("test.txt", []byte("test"))
+		assert.Equal(t, int64(4), file.Size)
+
+		_, err := ioutil.ReadAll(file.Body)
+		require.NoError(t, err)
+
+		file, err = file.Rewind()
+		require.NoError(t, err)
+
+		body, err := ioutil.ReadAll(file.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "test", string(body))
+	})
+
+	t.Run("Local", func(t *testing.T) {
+		file, err := NewInputFileLocal("./testdata/gopher.jpg")
+		require.NoError(t, err)
+		defer func() { file.Close() }()
+
+		info, err := os.Stat("./testdata/gopher.jpg")
+		require.NoError(t, err)
+		assert.Equal(t, info.Size(), file.Size)
+
+		first, err := ioutil.ReadAll(file.Body)
+		require.NoError(t, err)
+
+		file, err = file.Rewind()
+		require.NoError(t, err)
+
+		second, err := ioutil.ReadAll(file.Body)
+		require.NoError(t, err)
+		assert.Equal(t, first, second)
+	})
+
+	t.Run("NotReopenable", func(t *testing.T) {
+		file := NewInputFile("test.txt", strings.NewReader("test"))
+
+		_, err := file.Rewind()
+		assert.Equal(t, ErrInputFileNotReopenable, err)
+	})
+}
+
+func TestInputFile_WithProgress(t *testing.T) {
+	var calls [][2]int64
+
+	file := NewInputFileBytes("test.txt", []byte("test")).
+		WithProgress(func(sent, total int64) {
+			calls = append(calls, [2]int64{sent, total})
+		})
+
+	require.NoError(t, NewMultipartEncoder(ioutil.Discard).AddFile("document", file))
+
+	assert.Equal(t, [][2]int64{{4, 4}}, calls)
+}
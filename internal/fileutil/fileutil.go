@@ -0,0 +1,44 @@
+// Package fileutil contains file helpers shared by storages of go-tg packages.
+package fileutil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// WriteFileAtomic replaces content of file at path.
+// Content is written and synced to temporary file in the same directory,
+// then temporary file is renamed to path, so readers see old or new content only.
+func WriteFileAtomic(path string, content []byte) error {
+	// temp file is created in the same directory, so rename is atomic
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "create temp file")
+	}
+
+	// on success file is already renamed, so remove is no-op
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "write temp file")
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "sync temp file")
+	}
+
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "close temp file")
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return errors.Wrap(err, "rename temp file")
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package fileutil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fileutil")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "file")
+
+	t.Run("Replace", func(t *testing.T) {
+		require.NoError(t, WriteFileAtomic(path, []byte("first")))
+		require.NoError(t, WriteFileAtomic(path, []byte("second")))
+
+		content, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "second", string(content))
+
+		files, err := ioutil.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Len(t, files, 1, "temp files are removed")
+	})
+
+	t.Run("RelativePath", func(t *testing.T) {
+		wd, err := os.Getwd()
+		require.NoError(t, err)
+		require.NoError(t, os.Chdir(dir))
+		defer os.Chdir(wd)
+
+		require.NoError(t, WriteFileAtomic("relative", []byte("content")))
+
+		content, err := ioutil.ReadFile(filepath.Join(dir, "relative"))
+		require.NoError(t, err)
+		assert.Equal(t, "content", string(content))
+	})
+
+	t.Run("BadDirectory", func(t *testing.T) {
+		assert.Error(t, WriteFileAtomic(filepath.Join(dir, "not-exists", "file"), nil))
+	})
+}
@@ -0,0 +1,333 @@
+package tg
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrBotExists returned by BotManager.Add if bot with same key is already added.
+	ErrBotExists = errors.New("bot already exists")
+
+	// ErrBotNotFound returned by BotManager.Remove if bot with key is not added.
+	ErrBotNotFound = errors.New("bot not found")
+
+	// ErrBotManagerClosed returned by BotManager.Add after Close.
+	ErrBotManagerClosed = errors.New("bot manager is closed")
+)
+
+// BotManager runs many bots with separate tokens in one process.
+//
+// Clients of all bots share one Transport (and so one HTTP connection pool)
+// and optional RateLimiter. Updates are received by Poller (see WithManagedBotPolling)
+// or by webhook: BotManager is http.Handler which routes request to the bot
+// by last segment of path, e.g. POST /webhook/<key>.
+//
+// Bots can be added and removed at runtime, removing of bot stops its poller.
+//
+// Example:
+//
+//   manager := tg.NewBotManager(
+//       tg.WithBotManagerRateLimiter(tg.NewTokenBucketLimiter(30, time.Second, 30)),
+//   )
+//   defer manager.Close(context.Background())
+//
+//   manager.Add("support", supportToken, supportHandler, tg.WithManagedBotPolling())
+//   manager.Add("shop", shopToken, shopHandler)
+//
+//   http.Handle("/webhook/", manager)
+//
+type BotManager struct {
+	transport  Transport
+	limiter    RateLimiter
+	clientOpts []ClientOption
+	onError    func(key string, err error)
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	lock   sync.RWMutex
+	closed bool
+	bots   map[string]*managedBot
+}
+
+type managedBot struct {
+	client  *Client
+	webhook *Webhook
+
+	// nil if bot receives updates by webhook
+	poller *Poller
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// stop cancels poller of bot and waits until it's stopped or ctx is done.
+func (bot *managedBot) stop(ctx context.Context) error {
+	if bot.poller == nil {
+		return nil
+	}
+
+	bot.cancel()
+
+	select {
+	case <-bot.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// BotManagerOption use this for configure bot manager.
+type BotManagerOption func(manager *BotManager)
+
+// WithBotManagerTransport sets transport shared by all bots.
+// By default, HTTPTransport with connection pool sized for many bots is used.
+func WithBotManagerTransport(transport Transport) BotManagerOption {
+	return func(manager *BotManager) {
+		manager.transport = transport
+	}
+}
+
+// WithBotManagerRateLimiter sets limiter of API calls shared by all bots.
+// Shared transport is wrapped by RateLimitTransport regardless of options order.
+func WithBotManagerRateLimiter(limiter RateLimiter) BotManagerOption {
+	return func(manager *BotManager) {
+		manager.limiter = limiter
+	}
+}
+
+// WithBotManagerClientOptions sets options of clients created for bots.
+// Shared transport is set after provided options,
+// server URL (WithServerURL, WithLocalServer) is applied to shared transport.
+func WithBotManagerClientOptions(opts ...ClientOption) BotManagerOption {
+	return func(manager *BotManager) {
+		manager.clientOpts = opts
+	}
+}
+
+// WithBotManagerErrorHandler sets callback for poller and webhook errors of bots.
+// By default, errors are ignored.
+func WithBotManagerErrorHandler(onError func(key string, err error)) BotManagerOption {
+	return func(manager *BotManager) {
+		manager.onError = onError
+	}
+}
+
+// newSharedHTTPTransport creates HTTPTransport which keeps enough idle connections
+// to Bot API server for many bots (http.DefaultTransport keeps only two).
+func newSharedHTTPTransport() *HTTPTransport {
+	return NewHTTPTransport(
+		WithHTTPDoer(&http.Client{
+			Transport: &http.Transport{
+				Proxy: http.ProxyFromEnvironment,
+				DialContext: (&net.Dialer{
+					Timeout:   30 * time.Second,
+					KeepAlive: 30 * time.Second,
+				}).DialContext,
+				MaxIdleConns:          100,
+				MaxIdleConnsPerHost:   100,
+				IdleConnTimeout:       90 * time.Second,
+				TLSHandshakeTimeout:   10 * time.Second,
+				ExpectContinueTimeout: 1 * time.Second,
+			},
+		}),
+	)
+}
+
+// NewBotManager creates bot manager without bots.
+// Manager should be closed after use.
+func NewBotManager(opts ...BotManagerOption) *BotManager {
+	manager := &BotManager{
+		transport: newSharedHTTPTransport(),
+		onError:   func(key string, err error) {},
+		bots:      make(map[string]*managedBot),
+	}
+
+	for _, opt := range opts {
+		opt(manager)
+	}
+
+	// server URL is applied once, before transport is wrapped by limiter
+	config := &Client{}
+
+	for _, opt := range manager.clientOpts {
+		opt(config)
+	}
+
+	if config.serverURL != "" {
+		manager.transport = withTransportServerURL(manager.transport, config.serverURL)
+	}
+
+	if manager.limiter != nil {
+		manager.transport = NewRateLimitTransport(manager.transport, manager.limiter)
+	}
+
+	manager.ctx, manager.cancel = context.WithCancel(context.Background())
+
+	return manager
+}
+
+type managedBotConfig struct {
+	polling    bool
+	pollerOpts []PollerOption
+}
+
+// ManagedBotOption use this for configure bot added to BotManager.
+type ManagedBotOption func(config *managedBotConfig)
+
+// WithManagedBotPolling makes bot receive updates by Poller with provided options.
+// Error handler of poller is set by BotManager.
+func WithManagedBotPolling(opts ...PollerOption) ManagedBotOption {
+	return func(config *managedBotConfig) {
+		config.polling = true
+		config.pollerOpts = opts
+	}
+}
+
+// Add creates client for bot with token and starts receiving of its updates.
+// Key identifies bot in manager and in webhook path, don't use token as key,
+// because webhook URL can be exposed in logs.
+func (manager *BotManager) Add(key string, token string, handler Handler, opts ...ManagedBotOption) (*Client, error) {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+
+	if manager.closed {
+		return nil, ErrBotManagerClosed
+	}
+
+	if _, ok := manager.bots[key]; ok {
+		return nil, errors.Wrapf(ErrBotExists, "add bot '%s'", key)
+	}
+
+	onError := func(err error) {
+		manager.onError(key, err)
+	}
+
+	clientOpts := append(append([]ClientOption{}, manager.clientOpts...), WithTransport(manager.transport))
+
+	bot := &managedBot{
+		client:  NewClient(token, clientOpts...),
+		webhook: NewWebhook(handler, WithWebhookErrorHandler(onError)),
+	}
+
+	config := &managedBotConfig{}
+
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	if config.polling {
+		pollerOpts := append(append([]PollerOption{}, config.pollerOpts...), WithPollerErrorHandler(onError))
+
+		bot.poller = NewPoller(bot.client, handler, pollerOpts...)
+		bot.done = make(chan struct{})
+
+		var ctx context.Context
+		ctx, bot.cancel = context.WithCancel(manager.ctx)
+
+		go func() {
+			defer close(bot.done)
+
+			if err := bot.poller.Run(ctx); err != nil && ctx.Err() == nil {
+				onError(errors.Wrap(err, "run poller"))
+			}
+		}()
+	}
+
+	manager.bots[key] = bot
+
+	return bot.client, nil
+}
+
+// Remove stops receiving of bot updates and removes it from manager.
+// If bot uses polling, Remove waits until poller is stopped or ctx is done.
+func (manager *BotManager) Remove(ctx context.Context, key string) error {
+	manager.lock.Lock()
+
+	bot, ok := manager.bots[key]
+	delete(manager.bots, key)
+
+	manager.lock.Unlock()
+
+	if !ok {
+		return errors.Wrapf(ErrBotNotFound, "remove bot '%s'", key)
+	}
+
+	return bot.stop(ctx)
+}
+
+// Client returns client of bot or nil if bot with key is not added.
+func (manager *BotManager) Client(key string) *Client {
+	manager.lock.RLock()
+	defer manager.lock.RUnlock()
+
+	if bot, ok := manager.bots[key]; ok {
+		return bot.client
+	}
+
+	return nil
+}
+
+// Keys returns sorted keys of added bots.
+func (manager *BotManager) Keys() []string {
+	manager.lock.RLock()
+	defer manager.lock.RUnlock()
+
+	keys := make([]string, 0, len(manager.bots))
+
+	for key := range manager.bots {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// ServeHTTP passes webhook request to the bot with key equal to last segment of path.
+// Responds with 404 Not Found if there is no such bot.
+func (manager *BotManager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := path.Base(strings.TrimSuffix(r.URL.Path, "/"))
+
+	manager.lock.RLock()
+	bot, ok := manager.bots[key]
+	manager.lock.RUnlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	bot.webhook.ServeHTTP(w, r)
+}
+
+// Close removes all bots and waits until pollers are stopped or ctx is done.
+// Bots can't be added after Close.
+func (manager *BotManager) Close(ctx context.Context) error {
+	manager.lock.Lock()
+
+	manager.closed = true
+
+	bots := manager.bots
+	manager.bots = make(map[string]*managedBot)
+
+	manager.lock.Unlock()
+
+	manager.cancel()
+
+	for _, bot := range bots {
+		if err := bot.stop(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
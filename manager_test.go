@@ -0,0 +1,160 @@
+package tg
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBotManager(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		lock   sync.Mutex
+		tokens = map[string]int{}
+	)
+
+	transport := &TransportMock{
+		ExecuteFunc: func(ctx context.Context, r *Request) (*Response, error) {
+			lock.Lock()
+			tokens[r.Token()]++
+			calls := tokens[r.Token()]
+			lock.Unlock()
+
+			if r.Method() == "getUpdates" {
+				if calls == 1 {
+					return &Response{
+						OK:     true,
+						Result: []byte(`[{"update_id": 1, "message": {"text": "polled"}}]`),
+					}, nil
+				}
+
+				<-ctx.Done()
+
+				return nil, ctx.Err()
+			}
+
+			return ResponseResultTrue, nil
+		},
+	}
+
+	limiter := &countingLimiter{}
+
+	manager := NewBotManager(
+		WithBotManagerRateLimiter(limiter),
+		WithBotManagerTransport(transport),
+	)
+	defer manager.Close(ctx)
+
+	polled := make(chan string, 1)
+
+	first, err := manager.Add("first", "1:first", HandlerFunc(func(ctx context.Context, update *Update) error {
+		polled <- update.Message.Text
+		return nil
+	}), WithManagedBotPolling(WithPollerTimeout(time.Second)))
+	require.NoError(t, err)
+
+	var hooked []string
+
+	second, err := manager.Add("second", "2:second", HandlerFunc(func(ctx context.Context, update *Update) error {
+		hooked = append(hooked, update.Message.Text)
+		return nil
+	}))
+	require.NoError(t, err)
+
+	_, err = manager.Add("second", "3:third", HandlerFunc(func(ctx context.Context, update *Update) error {
+		return nil
+	}))
+	assert.Equal(t, ErrBotExists, errors.Cause(err))
+
+	assert.Equal(t, []string{"first", "second"}, manager.Keys())
+	assert.Equal(t, second, manager.Client("second"))
+	assert.Nil(t, manager.Client("third"))
+
+	select {
+	case text := <-polled:
+		assert.Equal(t, "polled", text)
+	case <-time.After(time.Second):
+		t.Fatal("update is not polled")
+	}
+
+	serve := func(path string) int {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"update_id": 1, "message": {"text": "hooked"}}`))
+
+		manager.ServeHTTP(w, r)
+
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve("/webhook/second"))
+	assert.Equal(t, http.StatusOK, serve("/webhook/second/"))
+	assert.Equal(t, http.StatusNotFound, serve("/webhook/third"))
+	assert.Equal(t, []string{"hooked", "hooked"}, hooked)
+
+	require.NoError(t, first.Invoke(ctx, NewRequest("sendMessage"), nil))
+	require.NoError(t, second.Invoke(ctx, NewRequest("sendMessage"), nil))
+
+	// poller is stopped
+	removeCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	require.NoError(t, manager.Remove(removeCtx, "first"))
+	assert.Equal(t, ErrBotNotFound, errors.Cause(manager.Remove(ctx, "first")))
+	assert.Equal(t, []string{"second"}, manager.Keys())
+
+	lock.Lock()
+	assert.Equal(t, map[string]int{"1:first": 3, "2:second": 1}, tokens)
+	lock.Unlock()
+
+	assert.Equal(t, int64(4), atomic.LoadInt64(&limiter.calls), "limiter is shared by all bots")
+
+	require.NoError(t, manager.Close(ctx))
+	assert.Len(t, manager.Keys(), 0)
+
+	_, err = manager.Add("third", "3:third", HandlerFunc(func(ctx context.Context, update *Update) error {
+		return nil
+	}))
+	assert.Equal(t, ErrBotManagerClosed, err)
+}
+
+func TestBotManager_ServerURL(t *testing.T) {
+	ctx := context.Background()
+
+	var calls int64
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&calls, 1)
+		assert.Equal(t, "/bot1:first/getMe", r.URL.Path)
+		w.Write([]byte(`{"ok": true, "result": {"id": 1}}`))
+	}))
+	defer server.Close()
+
+	limiter := &countingLimiter{}
+
+	manager := NewBotManager(
+		WithBotManagerRateLimiter(limiter),
+		WithBotManagerClientOptions(WithServerURL(server.URL)),
+	)
+	defer manager.Close(ctx)
+
+	client, err := manager.Add("first", "1:first", HandlerFunc(func(ctx context.Context, update *Update) error {
+		return nil
+	}))
+	require.NoError(t, err)
+
+	_, err = client.GetMe(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1), atomic.LoadInt64(&calls), "server URL is applied to rate limited transport")
+	assert.Equal(t, int64(1), atomic.LoadInt64(&limiter.calls))
+}
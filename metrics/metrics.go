@@ -0,0 +1,243 @@
+// Package metrics implements tg.Observer collects Prometheus-style metrics of Bot API calls.
+//
+// Package does not depend on Prometheus client,
+// Collector exposes metrics in Prometheus text exposition format.
+//
+// Collected metrics:
+//  - tg_requests_total{method} - number of calls;
+//  - tg_errors_total{method,error_code} - number of failed calls, error_code is 0 for transport errors;
+//  - tg_request_duration_seconds{method} - histogram of calls latency;
+//  - tg_upload_bytes_total{method} - number of uploaded bytes.
+//
+// Downloads are reported with method "download".
+//
+// Example:
+//
+//   collector := metrics.New()
+//
+//   client := tg.NewClient(token, tg.WithObserver(collector))
+//
+//   http.Handle("/metrics", collector)
+//
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/mr-linch/go-tg"
+)
+
+// DefaultBuckets of latency histogram in seconds.
+var DefaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+type errorKey struct {
+	method    string
+	errorCode int
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Collector collects metrics of client calls. It's safe for concurrent use.
+type Collector struct {
+	namespace string
+	buckets   []float64
+
+	lock      sync.Mutex
+	requests  map[string]uint64
+	errors    map[errorKey]uint64
+	durations map[string]*histogram
+	uploads   map[string]uint64
+}
+
+// Option use this for configure Collector.
+type Option func(collector *Collector)
+
+// WithNamespace sets prefix of metric names, default is "tg".
+func WithNamespace(namespace string) Option {
+	return func(collector *Collector) {
+		collector.namespace = namespace
+	}
+}
+
+// WithBuckets sets upper bounds of latency histogram buckets in seconds.
+// Buckets should be sorted in increasing order.
+func WithBuckets(buckets ...float64) Option {
+	return func(collector *Collector) {
+		collector.buckets = buckets
+	}
+}
+
+// New creates collector.
+func New(opts ...Option) *Collector {
+	collector := &Collector{
+		namespace: "tg",
+		buckets:   DefaultBuckets,
+		requests:  make(map[string]uint64),
+		errors:    make(map[errorKey]uint64),
+		durations: make(map[string]*histogram),
+		uploads:   make(map[string]uint64),
+	}
+
+	for _, opt := range opts {
+		opt(collector)
+	}
+
+	return collector
+}
+
+// BeforeCall does nothing, all metrics are collected in AfterCall.
+func (collector *Collector) BeforeCall(ctx context.Context, info *tg.CallInfo) context.Context {
+	return ctx
+}
+
+// AfterCall updates metrics.
+func (collector *Collector) AfterCall(ctx context.Context, info *tg.CallInfo, result *tg.CallResult) {
+	method := info.Method
+	if info.IsDownload() {
+		method = "download"
+	}
+
+	seconds := result.Duration.Seconds()
+
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+
+	collector.requests[method]++
+
+	if result.Err != nil {
+		collector.errors[errorKey{method, result.ErrorCode}]++
+	}
+
+	h, ok := collector.durations[method]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(collector.buckets))}
+		collector.durations[method] = h
+	}
+
+	for i, bound := range collector.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+
+	h.count++
+	h.sum += seconds
+
+	if result.UploadBytes > 0 {
+		collector.uploads[method] += uint64(result.UploadBytes)
+	}
+}
+
+func sortedKeys(m map[string]uint64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// WriteTo writes metrics in Prometheus text exposition format.
+func (collector *Collector) WriteTo(w io.Writer) (int64, error) {
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+
+	ns := collector.namespace
+
+	fmt.Fprintf(cw, "# HELP %s_requests_total Total number of Bot API calls.\n", ns)
+	fmt.Fprintf(cw, "# TYPE %s_requests_total counter\n", ns)
+	for _, method := range sortedKeys(collector.requests) {
+		fmt.Fprintf(cw, "%s_requests_total{method=%q} %d\n", ns, method, collector.requests[method])
+	}
+
+	errorKeys := make([]errorKey, 0, len(collector.errors))
+	for k := range collector.errors {
+		errorKeys = append(errorKeys, k)
+	}
+	sort.Slice(errorKeys, func(i, j int) bool {
+		if errorKeys[i].method != errorKeys[j].method {
+			return errorKeys[i].method < errorKeys[j].method
+		}
+		return errorKeys[i].errorCode < errorKeys[j].errorCode
+	})
+
+	fmt.Fprintf(cw, "# HELP %s_errors_total Total number of failed Bot API calls.\n", ns)
+	fmt.Fprintf(cw, "# TYPE %s_errors_total counter\n", ns)
+	for _, k := range errorKeys {
+		fmt.Fprintf(cw, "%s_errors_total{method=%q,error_code=\"%d\"} %d\n", ns, k.method, k.errorCode, collector.errors[k])
+	}
+
+	methods := make([]string, 0, len(collector.durations))
+	for method := range collector.durations {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	fmt.Fprintf(cw, "# HELP %s_request_duration_seconds Latency of Bot API calls.\n", ns)
+	fmt.Fprintf(cw, "# TYPE %s_request_duration_seconds histogram\n", ns)
+	for _, method := range methods {
+		h := collector.durations[method]
+
+		for i, bound := range collector.buckets {
+			fmt.Fprintf(cw, "%s_request_duration_seconds_bucket{method=%q,le=%q} %d\n", ns, method, formatFloat(bound), h.counts[i])
+		}
+
+		fmt.Fprintf(cw, "%s_request_duration_seconds_bucket{method=%q,le=\"+Inf\"} %d\n", ns, method, h.count)
+		fmt.Fprintf(cw, "%s_request_duration_seconds_sum{method=%q} %s\n", ns, method, formatFloat(h.sum))
+		fmt.Fprintf(cw, "%s_request_duration_seconds_count{method=%q} %d\n", ns, method, h.count)
+	}
+
+	fmt.Fprintf(cw, "# HELP %s_upload_bytes_total Total number of bytes uploaded with Bot API calls.\n", ns)
+	fmt.Fprintf(cw, "# TYPE %s_upload_bytes_total counter\n", ns)
+	for _, method := range sortedKeys(collector.uploads) {
+		fmt.Fprintf(cw, "%s_upload_bytes_total{method=%q} %d\n", ns, method, collector.uploads[method])
+	}
+
+	if cw.err != nil {
+		return cw.n, cw.err
+	}
+
+	return cw.n, cw.w.Flush()
+}
+
+// ServeHTTP writes metrics, so collector can be used as handler of /metrics endpoint.
+func (collector *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	collector.WriteTo(w)
+}
+
+// countingWriter counts written bytes and keeps first error.
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+
+	return n, err
+}
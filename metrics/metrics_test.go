@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mr-linch/go-tg"
+)
+
+func TestCollector(t *testing.T) {
+	collector := New(WithBuckets(0.1, 1))
+
+	ctx := context.Background()
+
+	call := func(method string, duration time.Duration, result tg.CallResult) {
+		info := &tg.CallInfo{Method: method}
+
+		ctx := collector.BeforeCall(ctx, info)
+
+		result.Duration = duration
+
+		collector.AfterCall(ctx, info, &result)
+	}
+
+	call("sendMessage", time.Millisecond*50, tg.CallResult{StatusCode: 200})
+	call("sendMessage", time.Millisecond*500, tg.CallResult{
+		StatusCode: 429,
+		ErrorCode:  429,
+		Err:        errors.New("Too Many Requests: retry after 5"),
+	})
+	call("sendPhoto", time.Second*2, tg.CallResult{
+		StatusCode:  200,
+		UploadBytes: 1024,
+	})
+	call("", time.Millisecond*50, tg.CallResult{Err: errors.New("timeout")})
+
+	buf := &bytes.Buffer{}
+
+	n, err := collector.WriteTo(buf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+
+	assert.Equal(t, `# HELP tg_requests_total Total number of Bot API calls.
+# TYPE tg_requests_total counter
+tg_requests_total{method="download"} 1
+tg_requests_total{method="sendMessage"} 2
+tg_requests_total{method="sendPhoto"} 1
+# HELP tg_errors_total Total number of failed Bot API calls.
+# TYPE tg_errors_total counter
+tg_errors_total{method="download",error_code="0"} 1
+tg_errors_total{method="sendMessage",error_code="429"} 1
+# HELP tg_request_duration_seconds Latency of Bot API calls.
+# TYPE tg_request_duration_seconds histogram
+tg_request_duration_seconds_bucket{method="download",le="0.1"} 1
+tg_request_duration_seconds_bucket{method="download",le="1"} 1
+tg_request_duration_seconds_bucket{method="download",le="+Inf"} 1
+tg_request_duration_seconds_sum{method="download"} 0.05
+tg_request_duration_seconds_count{method="download"} 1
+tg_request_duration_seconds_bucket{method="sendMessage",le="0.1"} 1
+tg_request_duration_seconds_bucket{method="sendMessage",le="1"} 2
+tg_request_duration_seconds_bucket{method="sendMessage",le="+Inf"} 2
+tg_request_duration_seconds_sum{method="sendMessage"} 0.55
+tg_request_duration_seconds_count{method="sendMessage"} 2
+tg_request_duration_seconds_bucket{method="sendPhoto",le="0.1"} 0
+tg_request_duration_seconds_bucket{method="sendPhoto",le="1"} 0
+tg_request_duration_seconds_bucket{method="sendPhoto",le="+Inf"} 1
+tg_request_duration_seconds_sum{method="sendPhoto"} 2
+tg_request_duration_seconds_count{method="sendPhoto"} 1
+# HELP tg_upload_bytes_total Total number of bytes uploaded with Bot API calls.
+# TYPE tg_upload_bytes_total counter
+tg_upload_bytes_total{method="sendPhoto"} 1024
+`, buf.String())
+}
+
+func TestCollector_ServeHTTP(t *testing.T) {
+	collector := New(WithNamespace("bot"))
+
+	collector.AfterCall(context.Background(), &tg.CallInfo{Method: "getMe"}, &tg.CallResult{})
+
+	w := httptest.NewRecorder()
+
+	collector.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/plain; version=0.0.4", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `bot_requests_total{method="getMe"} 1`)
+}
@@ -0,0 +1,123 @@
+package tg
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// CallInfo contains information about Bot API call or file download passed to Observer.
+type CallInfo struct {
+	// Bot API method, empty for downloads.
+	Method string
+
+	// Path of downloaded file, empty for API calls.
+	Path string
+
+	// Time when call is started.
+	Start time.Time
+}
+
+// IsDownload returns true if call is file download.
+func (info *CallInfo) IsDownload() bool {
+	return info.Method == ""
+}
+
+// CallResult contains result of call passed to Observer.
+type CallResult struct {
+	// Duration of call.
+	Duration time.Duration
+
+	// HTTP status code of response, 0 if it's unknown.
+	StatusCode int
+
+	// Error code returned by Bot API, 0 if request is successful.
+	ErrorCode int
+
+	// Number of bytes of files uploaded with request.
+	// Only bytes read by transport are counted, so it can be less than size of files for failed request.
+	UploadBytes int64
+
+	// Error of call: transport or Bot API error.
+	Err error
+}
+
+// Observer receives events about each call of Client.Invoke and Client.DownloadFile.
+// Use it for collect metrics or tracing.
+type Observer interface {
+	// BeforeCall is called before call.
+	// Returned context is used for call and passed to AfterCall,
+	// so observer can store own data in it (e.g. span).
+	BeforeCall(ctx context.Context, info *CallInfo) context.Context
+
+	// AfterCall is called after call completes.
+	AfterCall(ctx context.Context, info *CallInfo, result *CallResult)
+}
+
+// WithObserver adds observer of client calls.
+// Can be used multiple times, BeforeCall of observers is called in order of adding
+// and AfterCall in reverse order.
+func WithObserver(observer Observer) ClientOption {
+	return func(c *Client) {
+		c.observers = append(c.observers, observer)
+	}
+}
+
+// countingReader counts bytes read from underlying reader.
+type countingReader struct {
+	io.Reader
+	n *int64
+}
+
+func (r countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	atomic.AddInt64(r.n, int64(n))
+	return n, err
+}
+
+func (r countingReader) Close() error {
+	if closer, ok := r.Reader.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// observeUploads returns copy of request with file bodies wrapped for counting bytes
+// and function returns total number of bytes read by transport.
+func observeUploads(r *Request) (*Request, func() int64) {
+	if len(r.files) == 0 {
+		return r, func() int64 { return 0 }
+	}
+
+	counted := new(int64)
+
+	observed := *r
+	observed.files = make(map[string]InputFile, len(r.files))
+
+	for k, file := range r.files {
+		file.Body = countingReader{Reader: file.Body, n: counted}
+		observed.files[k] = file
+	}
+
+	return &observed, func() int64 {
+		return atomic.LoadInt64(counted)
+	}
+}
+
+func (client *Client) beforeCall(ctx context.Context, info *CallInfo) context.Context {
+	for _, observer := range client.observers {
+		ctx = observer.BeforeCall(ctx, info)
+	}
+
+	return ctx
+}
+
+func (client *Client) afterCall(ctx context.Context, info *CallInfo, result *CallResult) {
+	result.Duration = time.Since(info.Start)
+
+	for i := len(client.observers) - 1; i >= 0; i-- {
+		client.observers[i].AfterCall(ctx, info, result)
+	}
+}
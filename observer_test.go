@@ -0,0 +1,154 @@
+package tg
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type observerKey string
+
+type recordingObserver struct {
+	name   string
+	events *[]string
+
+	infos   []CallInfo
+	results []CallResult
+}
+
+func (observer *recordingObserver) BeforeCall(ctx context.Context, info *CallInfo) context.Context {
+	*observer.events = append(*observer.events, "before "+observer.name)
+	return context.WithValue(ctx, observerKey(observer.name), true)
+}
+
+func (observer *recordingObserver) AfterCall(ctx context.Context, info *CallInfo, result *CallResult) {
+	*observer.events = append(*observer.events, "after "+observer.name)
+
+	if ctx.Value(observerKey(observer.name)) == nil {
+		panic("context of BeforeCall is not passed to AfterCall")
+	}
+
+	observer.infos = append(observer.infos, *info)
+	observer.results = append(observer.results, *result)
+}
+
+func TestClient_Observer(t *testing.T) {
+	ctx := context.Background()
+
+	var events []string
+
+	first := &recordingObserver{name: "first", events: &events}
+	second := &recordingObserver{name: "second", events: &events}
+
+	transportErr := errors.New("timeout")
+
+	transport := &TransportMock{
+		ExecuteFunc: func(ctx context.Context, r *Request) (*Response, error) {
+			assert.NotNil(t, ctx.Value(observerKey("second")), "context of observers is passed to transport")
+
+			switch r.Method() {
+			case "getMe":
+				return nil, transportErr
+			case "getChat", "sendPhoto":
+				return &Response{
+					StatusCode:  400,
+					ErrorCode:   400,
+					Description: "Bad Request: chat not found",
+				}, nil
+			default:
+				// read files to count uploaded bytes
+				for _, file := range extractFiles(r) {
+					if _, err := ioutil.ReadAll(file.Body); err != nil {
+						return nil, err
+					}
+				}
+
+				return &Response{
+					OK:         true,
+					StatusCode: 200,
+					Result:     json.RawMessage(`true`),
+				}, nil
+			}
+		},
+		DownloadFunc: func(ctx context.Context, token string, path string) (io.ReadCloser, error) {
+			return ioutil.NopCloser(strings.NewReader("content")), nil
+		},
+	}
+
+	client := NewClient("1234:secret",
+		WithTransport(transport),
+		WithObserver(first),
+		WithObserver(second),
+	)
+
+	_, err := client.GetMe(ctx)
+	assert.Equal(t, transportErr, err)
+
+	_, err = client.GetChat(ctx, ChatID(1))
+	assert.EqualError(t, err, "Bad Request: chat not found")
+
+	file := NewInputFile("stream.txt", ioutil.NopCloser(strings.NewReader("streamed")))
+
+	err = client.Invoke(ctx,
+		NewRequest("sendDocument").
+			AddChatID(ChatID(1)).
+			AddFile("document", NewInputFileBytes("test.txt", []byte("hello"))).
+			AddFile("thumb", file),
+		nil,
+	)
+	require.NoError(t, err)
+
+	// request is failed before files are sent
+	err = client.Invoke(ctx,
+		NewRequest("sendPhoto").
+			AddChatID(ChatID(1)).
+			AddFile("photo", NewInputFileBytes("photo.jpg", []byte("photo"))),
+		nil,
+	)
+	require.Error(t, err)
+
+	_, err = client.DownloadFile(ctx, "photos/1.jpg")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"before first", "before second", "after second", "after first",
+		"before first", "before second", "after second", "after first",
+		"before first", "before second", "after second", "after first",
+		"before first", "before second", "after second", "after first",
+		"before first", "before second", "after second", "after first",
+	}, events)
+
+	require.Len(t, first.results, 5)
+
+	assert.Equal(t, "getMe", first.infos[0].Method)
+	assert.Equal(t, transportErr, first.results[0].Err)
+
+	assert.Equal(t, 400, first.results[1].StatusCode)
+	assert.Equal(t, 400, first.results[1].ErrorCode)
+	assert.EqualError(t, first.results[1].Err, "Bad Request: chat not found")
+
+	assert.Equal(t, 200, first.results[2].StatusCode)
+	assert.Equal(t, int64(len("hello")+len("streamed")), first.results[2].UploadBytes)
+	assert.NoError(t, first.results[2].Err)
+
+	assert.Equal(t, "sendPhoto", first.infos[3].Method)
+	assert.Equal(t, int64(0), first.results[3].UploadBytes, "only sent bytes are counted")
+
+	assert.True(t, first.infos[4].IsDownload())
+	assert.Equal(t, "photos/1.jpg", first.infos[4].Path)
+
+	for _, result := range first.results {
+		assert.True(t, result.Duration > 0)
+	}
+
+	// original request file is not replaced
+	_, ok := file.Body.(countingReader)
+	assert.False(t, ok)
+}
@@ -0,0 +1,103 @@
+package tg
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/mr-linch/go-tg/internal/fileutil"
+)
+
+// OffsetStore define interface of storage for ID of last handled update.
+// It's used by Poller for resume receiving updates after restart.
+type OffsetStore interface {
+	// Load returns ID of last handled update.
+	// If nothing was saved yet, returns zero.
+	Load(ctx context.Context) (UpdateID, error)
+
+	// Save commits ID of last handled update.
+	Save(ctx context.Context, id UpdateID) error
+}
+
+// MemoryOffsetStore keeps offset in memory.
+// It's default OffsetStore of Poller.
+type MemoryOffsetStore struct {
+	lock sync.RWMutex
+	id   UpdateID
+}
+
+// NewMemoryOffsetStore creates in-memory offset store.
+func NewMemoryOffsetStore() *MemoryOffsetStore {
+	return &MemoryOffsetStore{}
+}
+
+// Load returns saved ID.
+func (store *MemoryOffsetStore) Load(ctx context.Context) (UpdateID, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+
+	return store.id, nil
+}
+
+// Save stores ID in memory.
+func (store *MemoryOffsetStore) Save(ctx context.Context, id UpdateID) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	store.id = id
+
+	return nil
+}
+
+// FileOffsetStore keeps offset in local file.
+// File is written atomically: content is written to temporary file
+// in the same directory and then renamed to target path.
+type FileOffsetStore struct {
+	lock sync.Mutex
+	path string
+}
+
+// NewFileOffsetStore creates offset store backed by file at provided path.
+// File is created on first save.
+func NewFileOffsetStore(path string) *FileOffsetStore {
+	return &FileOffsetStore{path: path}
+}
+
+// Load reads ID from file.
+// Returns zero if file does not exist.
+func (store *FileOffsetStore) Load(ctx context.Context) (UpdateID, error) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	content, err := ioutil.ReadFile(store.path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, errors.Wrap(err, "read offset file")
+	}
+
+	id, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		return 0, errors.Wrap(err, "parse offset file")
+	}
+
+	return UpdateID(id), nil
+}
+
+// Save writes ID to the file.
+func (store *FileOffsetStore) Save(ctx context.Context, id UpdateID) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	content := []byte(strconv.Itoa(int(id)) + "\n")
+
+	return errors.Wrap(
+		fileutil.WriteFileAtomic(store.path, content),
+		"write offset file",
+	)
+}
@@ -0,0 +1,87 @@
+package tg
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryOffsetStore(t *testing.T) {
+	ctx := context.Background()
+
+	store := NewMemoryOffsetStore()
+
+	id, err := store.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, UpdateID(0), id)
+
+	require.NoError(t, store.Save(ctx, UpdateID(1234)))
+
+	id, err = store.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, UpdateID(1234), id)
+}
+
+func TestFileOffsetStore(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "go-tg-offset")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "offset")
+
+	t.Run("NotExists", func(t *testing.T) {
+		id, err := NewFileOffsetStore(path).Load(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, UpdateID(0), id)
+	})
+
+	t.Run("SaveAndLoad", func(t *testing.T) {
+		store := NewFileOffsetStore(path)
+
+		require.NoError(t, store.Save(ctx, UpdateID(100)))
+		require.NoError(t, store.Save(ctx, UpdateID(101)))
+
+		// new store should read saved value
+		id, err := NewFileOffsetStore(path).Load(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, UpdateID(101), id)
+
+		// temp files should be cleaned up
+		files, err := ioutil.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Len(t, files, 1)
+	})
+
+	t.Run("RelativePath", func(t *testing.T) {
+		wd, err := os.Getwd()
+		require.NoError(t, err)
+		require.NoError(t, os.Chdir(dir))
+		defer os.Chdir(wd)
+
+		require.NoError(t, NewFileOffsetStore("relative").Save(ctx, UpdateID(1)))
+
+		id, err := NewFileOffsetStore(filepath.Join(dir, "relative")).Load(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, UpdateID(1), id)
+	})
+
+	t.Run("BadContent", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(path, []byte("bad"), 0600))
+
+		_, err := NewFileOffsetStore(path).Load(ctx)
+		assert.Error(t, err)
+	})
+
+	t.Run("BadDirectory", func(t *testing.T) {
+		store := NewFileOffsetStore(filepath.Join(dir, "not-exists", "offset"))
+
+		assert.Error(t, store.Save(ctx, UpdateID(1)))
+	})
+}
@@ -0,0 +1,164 @@
+package tg
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Poller receives updates using long polling (GetUpdates) and passes them to the handler one by one.
+// After each handled update, its ID is committed to OffsetStore,
+// so after restart Poller continues from the next update.
+//
+// If handler implements Flusher (e.g. Executor), updates of received batch are passed to handler
+// without waiting, and offset is committed after Flush, when all of them are handled.
+// Updates not handled before stop are received again after restart.
+type Poller struct {
+	client  *Client
+	handler Handler
+	store   OffsetStore
+
+	limit          int
+	timeout        time.Duration
+	allowedUpdates []UpdateType
+	retryDelay     time.Duration
+
+	onError func(err error)
+}
+
+// PollerOption use this for configure poller.
+type PollerOption func(poller *Poller)
+
+// WithPollerOffsetStore sets storage of last handled update ID.
+// By default, MemoryOffsetStore is used.
+func WithPollerOffsetStore(store OffsetStore) PollerOption {
+	return func(poller *Poller) {
+		poller.store = store
+	}
+}
+
+// WithPollerLimit sets limit of updates received by one request (1-100).
+func WithPollerLimit(limit int) PollerOption {
+	return func(poller *Poller) {
+		poller.limit = limit
+	}
+}
+
+// WithPollerTimeout sets timeout of long polling.
+func WithPollerTimeout(timeout time.Duration) PollerOption {
+	return func(poller *Poller) {
+		poller.timeout = timeout
+	}
+}
+
+// WithPollerAllowedUpdates sets types of updates bot want to receive.
+func WithPollerAllowedUpdates(types ...UpdateType) PollerOption {
+	return func(poller *Poller) {
+		poller.allowedUpdates = types
+	}
+}
+
+// WithPollerRetryDelay sets delay between failed GetUpdates calls.
+func WithPollerRetryDelay(delay time.Duration) PollerOption {
+	return func(poller *Poller) {
+		poller.retryDelay = delay
+	}
+}
+
+// WithPollerErrorHandler sets callback for non-fatal errors (GetUpdates and handler errors).
+// By default, errors are ignored.
+func WithPollerErrorHandler(onError func(err error)) PollerOption {
+	return func(poller *Poller) {
+		poller.onError = onError
+	}
+}
+
+// NewPoller creates poller with provided client and handler.
+func NewPoller(client *Client, handler Handler, opts ...PollerOption) *Poller {
+	poller := &Poller{
+		client:  client,
+		handler: handler,
+		store:   NewMemoryOffsetStore(),
+
+		limit:      100,
+		timeout:    time.Second * 30,
+		retryDelay: time.Second * 5,
+
+		onError: func(err error) {},
+	}
+
+	for _, opt := range opts {
+		opt(poller)
+	}
+
+	return poller
+}
+
+// Run receives and handles updates until context is done or offset can't be loaded or saved.
+func (poller *Poller) Run(ctx context.Context) error {
+	last, err := poller.store.Load(ctx)
+	if err != nil {
+		return errors.Wrap(err, "load offset")
+	}
+
+	opts := &UpdatesOptions{
+		Limit:          poller.limit,
+		Timeout:        poller.timeout,
+		AllowedUpdates: poller.allowedUpdates,
+	}
+
+	if last != 0 {
+		opts.Offset = last.Next()
+	}
+
+	for {
+		updates, err := poller.client.GetUpdates(ctx, opts)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			poller.onError(errors.Wrap(err, "get updates"))
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(poller.retryDelay):
+				continue
+			}
+		}
+
+		flusher, async := poller.handler.(Flusher)
+
+		for i := range updates {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			update := &updates[i]
+
+			if err := poller.handler.HandleUpdate(ctx, update); err != nil {
+				poller.onError(errors.Wrapf(err, "handle update %d", update.ID))
+			}
+
+			if !async {
+				if err := poller.store.Save(ctx, update.ID); err != nil {
+					return errors.Wrap(err, "save offset")
+				}
+			}
+
+			opts.Offset = update.ID.Next()
+		}
+
+		if async && len(updates) > 0 {
+			if err := flusher.Flush(ctx); err != nil {
+				return err
+			}
+
+			if err := poller.store.Save(ctx, updates[len(updates)-1].ID); err != nil {
+				return errors.Wrap(err, "save offset")
+			}
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package tg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoller_Run(t *testing.T) {
+	t.Run("ResumeFromStore", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		store := NewMemoryOffsetStore()
+		require.NoError(t, store.Save(ctx, UpdateID(10)))
+
+		offsets := []string{}
+
+		transport := &TransportMock{
+			ExecuteFunc: func(ctx context.Context, r *Request) (*Response, error) {
+				args := extractArgs(r)
+
+				assert.Equal(t, "getUpdates", r.Method())
+				assert.Equal(t, "5", args["timeout"])
+				assert.Equal(t, "[\"message\"]", args["allowed_updates"])
+
+				offsets = append(offsets, args["offset"])
+
+				if len(offsets) == 1 {
+					return &Response{
+						OK:     true,
+						Result: []byte(`[{"update_id": 11, "message": {}}, {"update_id": 12, "message": {}}]`),
+					}, nil
+				}
+
+				cancel()
+
+				return nil, ctx.Err()
+			},
+		}
+
+		handled := []UpdateID{}
+
+		poller := NewPoller(
+			NewClient("1234:secret", WithTransport(transport)),
+			HandlerFunc(func(ctx context.Context, update *Update) error {
+				handled = append(handled, update.ID)
+				return nil
+			}),
+			WithPollerOffsetStore(store),
+			WithPollerTimeout(time.Second*5),
+			WithPollerAllowedUpdates(UpdateMessage),
+		)
+
+		err := poller.Run(ctx)
+		assert.Equal(t, context.Canceled, err)
+
+		assert.Equal(t, []string{"11", "13"}, offsets)
+		assert.Equal(t, []UpdateID{11, 12}, handled)
+
+		id, err := store.Load(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, UpdateID(12), id)
+	})
+
+	t.Run("Flusher", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		store := NewMemoryOffsetStore()
+
+		release := make(chan struct{})
+
+		transport := &TransportMock{
+			ExecuteFunc: func(ctx context.Context, r *Request) (*Response, error) {
+				if extractArgs(r)["offset"] == "" {
+					return &Response{
+						OK:     true,
+						Result: []byte(`[{"update_id": 1, "message": {"chat": {"id": 1}}}, {"update_id": 2, "message": {"chat": {"id": 2}}}]`),
+					}, nil
+				}
+
+				cancel()
+
+				return nil, ctx.Err()
+			},
+		}
+
+		executor := NewExecutor(HandlerFunc(func(ctx context.Context, update *Update) error {
+			<-release
+			return nil
+		}))
+		defer executor.Close(context.Background())
+
+		poller := NewPoller(
+			NewClient("1234:secret", WithTransport(transport)),
+			executor,
+			WithPollerOffsetStore(store),
+		)
+
+		done := make(chan error, 1)
+
+		go func() {
+			done <- poller.Run(ctx)
+		}()
+
+		// updates are queued, but not handled
+		time.Sleep(time.Millisecond * 10)
+
+		id, err := store.Load(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, UpdateID(0), id)
+
+		close(release)
+
+		assert.Equal(t, context.Canceled, <-done)
+
+		id, err = store.Load(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, UpdateID(2), id)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		calls := 0
+
+		transport := &TransportMock{
+			ExecuteFunc: func(ctx context.Context, r *Request) (*Response, error) {
+				calls++
+
+				switch calls {
+				case 1:
+					return nil, errors.New("network error")
+				case 2:
+					return &Response{
+						OK:     true,
+						Result: []byte(`[{"update_id": 1, "message": {}}]`),
+					}, nil
+				default:
+					cancel()
+					return nil, ctx.Err()
+				}
+			},
+		}
+
+		errs := []string{}
+
+		poller := NewPoller(
+			NewClient("1234:secret", WithTransport(transport)),
+			HandlerFunc(func(ctx context.Context, update *Update) error {
+				return errors.New("handler error")
+			}),
+			WithPollerRetryDelay(time.Millisecond),
+			WithPollerErrorHandler(func(err error) {
+				errs = append(errs, err.Error())
+			}),
+		)
+
+		err := poller.Run(ctx)
+		assert.Equal(t, context.Canceled, err)
+
+		assert.Equal(t, []string{
+			"get updates: network error",
+			"handle update 1: handler error",
+		}, errs)
+	})
+
+	t.Run("LoadError", func(t *testing.T) {
+		poller := NewPoller(
+			NewClient("1234:secret", WithTransport(&TransportMock{})),
+			HandlerFunc(func(ctx context.Context, update *Update) error { return nil }),
+			WithPollerOffsetStore(NewFileOffsetStore("testdata")),
+		)
+
+		assert.Error(t, poller.Run(context.Background()))
+	})
+}
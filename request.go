@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"strconv"
 	"time"
+
+	"github.com/pkg/errors"
 )
 
 // Encoder represents request encoder.
@@ -177,6 +179,36 @@ func (r *Request) AddPart(part RequestPart) *Request {
 	return r
 }
 
+// IsReplayable returns true if request can be sent again, i.e. all files can be reopened.
+func (r *Request) IsReplayable() bool {
+	for _, file := range r.files {
+		if file.Reopen == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Rewind reopens all request files, so request can be sent again.
+// Returns ErrInputFileNotReopenable if some file can't be reopened.
+func (r *Request) Rewind() error {
+	if !r.IsReplayable() {
+		return ErrInputFileNotReopenable
+	}
+
+	for k, file := range r.files {
+		rewound, err := file.Rewind()
+		if err != nil {
+			return errors.Wrapf(err, "rewind file '%s'", k)
+		}
+
+		r.files[k] = rewound
+	}
+
+	return nil
+}
+
 // HasFiles returns true if request contains files.
 func (r *Request) HasFiles() bool {
 	return len(r.files) > 0
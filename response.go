@@ -7,11 +7,11 @@ import (
 // ResponseParameters contains information about why a request was unsuccessful.
 type ResponseParameters struct {
 	// The group has been migrated to a supergroup with the specified identifier.
-	MigrateToChatID int
+	MigrateToChatID int `json:"migrate_to_chat_id,omitempty"`
 
 	// Optional. In case of exceeding flood control,
 	// the time left to wait before request can be repeated.
-	RetryAfter int64
+	RetryAfter int64 `json:"retry_after,omitempty"`
 }
 
 // Response represents Telegram Bot API response.
@@ -42,3 +42,21 @@ type Response struct {
 func (response Response) UnmarshalResult(dst interface{}) error {
 	return json.Unmarshal(response.Result, dst)
 }
+
+// Error represents unsuccessful response of Telegram Bot API.
+// Returned by Client.Invoke, so use type assertion to get error code and parameters.
+type Error struct {
+	// Error code from Telegram.
+	Code int `json:"error_code"`
+
+	// Human-readable description of error.
+	Description string `json:"description"`
+
+	// Optional. Information about why a request was unsuccessful.
+	Parameters *ResponseParameters `json:"parameters,omitempty"`
+}
+
+// Error returns description of error.
+func (err *Error) Error() string {
+	return err.Description
+}
@@ -1,6 +1,7 @@
 package tg
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -21,3 +22,21 @@ func TestResponseParameters_UnmarshalResult(t *testing.T) {
 		assert.True(t, dst.Test)
 	}
 }
+
+func TestResponse_Unmarshal(t *testing.T) {
+	response := Response{}
+
+	err := json.Unmarshal([]byte(`{
+		"ok": false,
+		"error_code": 400,
+		"description": "Bad Request: group chat was upgraded to a supergroup chat",
+		"parameters": {"migrate_to_chat_id": -1001234567890, "retry_after": 5}
+	}`), &response)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, &ResponseParameters{
+			MigrateToChatID: -1001234567890,
+			RetryAfter:      5,
+		}, response.Parameters)
+	}
+}
@@ -0,0 +1,228 @@
+// Package session provides per-user and per-chat key-value storage for handlers.
+//
+// Manager wraps tg.Handler and attaches sessions to the handler context.
+// Session is loaded from Storage on first access and saved after the handler returns,
+// only if it was changed.
+//
+// Example:
+//
+//   handler := session.NewManager(session.NewMemoryStorage(time.Hour*24), tg.HandlerFunc(
+//       func(ctx context.Context, update *tg.Update) error {
+//           var lang string
+//
+//           if _, err := session.User(ctx).Get(ctx, "lang", &lang); err != nil {
+//               return err
+//           }
+//
+//           return session.User(ctx).Set(ctx, "lang", "uk")
+//       },
+//   ))
+//
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/mr-linch/go-tg"
+)
+
+// Session contains values associated with user or chat.
+// Values are serialized to JSON.
+// Session is not safe for concurrent use.
+type Session struct {
+	key     string
+	storage Storage
+
+	values map[string]json.RawMessage
+	loaded bool
+	dirty  bool
+}
+
+func newSession(storage Storage, key string) *Session {
+	return &Session{
+		key:     key,
+		storage: storage,
+	}
+}
+
+// Key returns storage key of session.
+func (session *Session) Key() string {
+	return session.key
+}
+
+func (session *Session) load(ctx context.Context) error {
+	if session.loaded {
+		return nil
+	}
+
+	data, err := session.storage.Load(ctx, session.key)
+	if err != nil {
+		return errors.Wrapf(err, "load session '%s'", session.key)
+	}
+
+	values := make(map[string]json.RawMessage)
+
+	if data != nil {
+		if err := json.Unmarshal(data, &values); err != nil {
+			return errors.Wrapf(err, "unmarshal session '%s'", session.key)
+		}
+	}
+
+	session.values = values
+	session.loaded = true
+
+	return nil
+}
+
+// Get unmarshal value of k to dst.
+// Returns false if value is not set.
+func (session *Session) Get(ctx context.Context, k string, dst interface{}) (bool, error) {
+	if err := session.load(ctx); err != nil {
+		return false, err
+	}
+
+	raw, ok := session.values[k]
+	if !ok {
+		return false, nil
+	}
+
+	if err := json.Unmarshal(raw, dst); err != nil {
+		return false, errors.Wrapf(err, "unmarshal value '%s'", k)
+	}
+
+	return true, nil
+}
+
+// Set sets value of k.
+func (session *Session) Set(ctx context.Context, k string, v interface{}) error {
+	if err := session.load(ctx); err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrapf(err, "marshal value '%s'", k)
+	}
+
+	session.values[k] = raw
+	session.dirty = true
+
+	return nil
+}
+
+// Delete removes value of k.
+func (session *Session) Delete(ctx context.Context, k string) error {
+	if err := session.load(ctx); err != nil {
+		return err
+	}
+
+	if _, ok := session.values[k]; ok {
+		delete(session.values, k)
+		session.dirty = true
+	}
+
+	return nil
+}
+
+// Clear removes all values of session.
+func (session *Session) Clear() {
+	session.values = make(map[string]json.RawMessage)
+	session.loaded = true
+	session.dirty = true
+}
+
+// save writes session to storage if it was changed.
+func (session *Session) save(ctx context.Context) error {
+	if !session.dirty {
+		return nil
+	}
+
+	if len(session.values) == 0 {
+		return errors.Wrapf(
+			session.storage.Delete(ctx, session.key),
+			"delete session '%s'", session.key,
+		)
+	}
+
+	data, err := json.Marshal(session.values)
+	if err != nil {
+		return errors.Wrapf(err, "marshal session '%s'", session.key)
+	}
+
+	if err := session.storage.Save(ctx, session.key, data); err != nil {
+		return errors.Wrapf(err, "save session '%s'", session.key)
+	}
+
+	session.dirty = false
+
+	return nil
+}
+
+type contextKey int
+
+const (
+	userSessionKey contextKey = iota
+	chatSessionKey
+)
+
+// User returns session of update sender or nil if update has no sender.
+func User(ctx context.Context) *Session {
+	session, _ := ctx.Value(userSessionKey).(*Session)
+	return session
+}
+
+// Chat returns session of update chat or nil if update has no chat.
+func Chat(ctx context.Context) *Session {
+	session, _ := ctx.Value(chatSessionKey).(*Session)
+	return session
+}
+
+// Manager attaches sessions to context and saves them after handler returns.
+// Manager implements tg.Handler.
+//
+// If the handler returns error, changes of sessions are discarded.
+type Manager struct {
+	storage Storage
+	next    tg.Handler
+}
+
+// NewManager creates sessions manager.
+func NewManager(storage Storage, next tg.Handler) *Manager {
+	return &Manager{
+		storage: storage,
+		next:    next,
+	}
+}
+
+// HandleUpdate calls next handler with sessions attached to context.
+func (manager *Manager) HandleUpdate(ctx context.Context, update *tg.Update) error {
+	var sessions []*Session
+
+	if from := update.From(); from != nil {
+		session := newSession(manager.storage, fmt.Sprintf("user:%d", from.ID))
+		sessions = append(sessions, session)
+		ctx = context.WithValue(ctx, userSessionKey, session)
+	}
+
+	if chat := update.Chat(); chat != nil {
+		session := newSession(manager.storage, fmt.Sprintf("chat:%d", chat.ID))
+		sessions = append(sessions, session)
+		ctx = context.WithValue(ctx, chatSessionKey, session)
+	}
+
+	if err := manager.next.HandleUpdate(ctx, update); err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if err := session.save(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
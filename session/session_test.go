@@ -0,0 +1,154 @@
+package session
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mr-linch/go-tg"
+)
+
+type storageMock struct {
+	*MemoryStorage
+	loads int
+	saves int
+}
+
+func (mock *storageMock) Load(ctx context.Context, key string) ([]byte, error) {
+	mock.loads++
+	return mock.MemoryStorage.Load(ctx, key)
+}
+
+func (mock *storageMock) Save(ctx context.Context, key string, data []byte) error {
+	mock.saves++
+	return mock.MemoryStorage.Save(ctx, key, data)
+}
+
+func newTextUpdate(chat tg.ChatID, user tg.UserID) *tg.Update {
+	return &tg.Update{
+		Message: &tg.Message{
+			Chat: tg.Chat{ID: chat},
+			From: &tg.User{ID: user},
+		},
+	}
+}
+
+func TestManager_HandleUpdate(t *testing.T) {
+	ctx := context.Background()
+
+	type settings struct {
+		Lang   string `json:"lang"`
+		MenuID int    `json:"menu_id"`
+	}
+
+	t.Run("SaveAndLoad", func(t *testing.T) {
+		storage := &storageMock{MemoryStorage: NewMemoryStorage(0)}
+
+		manager := NewManager(storage, tg.HandlerFunc(func(ctx context.Context, update *tg.Update) error {
+			var counter int
+
+			if _, err := Chat(ctx).Get(ctx, "counter", &counter); err != nil {
+				return err
+			}
+
+			if err := Chat(ctx).Set(ctx, "counter", counter+1); err != nil {
+				return err
+			}
+
+			var s settings
+
+			ok, err := User(ctx).Get(ctx, "settings", &s)
+			if err != nil {
+				return err
+			}
+
+			if !ok {
+				return User(ctx).Set(ctx, "settings", settings{Lang: "uk", MenuID: 10})
+			}
+
+			return nil
+		}))
+
+		require.NoError(t, manager.HandleUpdate(ctx, newTextUpdate(-100, 1)))
+		require.NoError(t, manager.HandleUpdate(ctx, newTextUpdate(-100, 2)))
+
+		assert.Equal(t, 4, storage.loads)
+		assert.Equal(t, 4, storage.saves)
+
+		data, err := storage.Load(ctx, "chat:-100")
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"counter": 2}`, string(data))
+
+		data, err = storage.Load(ctx, "user:1")
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"settings": {"lang": "uk", "menu_id": 10}}`, string(data))
+	})
+
+	t.Run("Lazy", func(t *testing.T) {
+		storage := &storageMock{MemoryStorage: NewMemoryStorage(0)}
+
+		manager := NewManager(storage, tg.HandlerFunc(func(ctx context.Context, update *tg.Update) error {
+			assert.NotNil(t, User(ctx))
+			assert.Nil(t, Chat(ctx))
+			return nil
+		}))
+
+		update := &tg.Update{InlineQuery: &tg.InlineQuery{From: tg.User{ID: 1}}}
+
+		require.NoError(t, manager.HandleUpdate(ctx, update))
+
+		assert.Equal(t, 0, storage.loads)
+		assert.Equal(t, 0, storage.saves)
+	})
+
+	t.Run("DeleteAndClear", func(t *testing.T) {
+		storage := NewMemoryStorage(0)
+		require.NoError(t, storage.Save(ctx, "user:1", []byte(`{"a": 1, "b": 2}`)))
+		require.NoError(t, storage.Save(ctx, "chat:1", []byte(`{"a": 1}`)))
+
+		manager := NewManager(storage, tg.HandlerFunc(func(ctx context.Context, update *tg.Update) error {
+			Chat(ctx).Clear()
+			return User(ctx).Delete(ctx, "a")
+		}))
+
+		require.NoError(t, manager.HandleUpdate(ctx, newTextUpdate(1, 1)))
+
+		data, err := storage.Load(ctx, "user:1")
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"b": 2}`, string(data))
+
+		data, err = storage.Load(ctx, "chat:1")
+		require.NoError(t, err)
+		assert.Nil(t, data)
+	})
+
+	t.Run("HandlerError", func(t *testing.T) {
+		storage := NewMemoryStorage(0)
+
+		manager := NewManager(storage, tg.HandlerFunc(func(ctx context.Context, update *tg.Update) error {
+			if err := User(ctx).Set(ctx, "a", 1); err != nil {
+				return err
+			}
+			return errors.New("handler error")
+		}))
+
+		assert.EqualError(t, manager.HandleUpdate(ctx, newTextUpdate(1, 1)), "handler error")
+		assert.Equal(t, 0, storage.Len())
+	})
+
+	t.Run("BadData", func(t *testing.T) {
+		storage := NewMemoryStorage(0)
+		require.NoError(t, storage.Save(ctx, "user:1", []byte(`{`)))
+
+		manager := NewManager(storage, tg.HandlerFunc(func(ctx context.Context, update *tg.Update) error {
+			var v int
+			_, err := User(ctx).Get(ctx, "a", &v)
+			return err
+		}))
+
+		assert.Error(t, manager.HandleUpdate(ctx, newTextUpdate(1, 1)))
+	})
+}
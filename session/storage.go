@@ -0,0 +1,119 @@
+package session
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Storage define interface of serialized sessions storage.
+type Storage interface {
+	// Load returns session data by key or nil if not found.
+	Load(ctx context.Context, key string) ([]byte, error)
+
+	// Save creates or replaces session data.
+	Save(ctx context.Context, key string, data []byte) error
+
+	// Delete removes session. Not found session is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+type memoryItem struct {
+	data    []byte
+	expires time.Time
+}
+
+// MemoryStorage keeps sessions in memory.
+// Sessions not saved during TTL are expired and removed on access or by Cleanup.
+type MemoryStorage struct {
+	lock  sync.RWMutex
+	items map[string]memoryItem
+	ttl   time.Duration
+
+	now func() time.Time
+}
+
+// NewMemoryStorage creates in-memory storage with provided TTL.
+// Zero TTL means sessions never expire.
+func NewMemoryStorage(ttl time.Duration) *MemoryStorage {
+	return &MemoryStorage{
+		items: make(map[string]memoryItem),
+		ttl:   ttl,
+		now:   time.Now,
+	}
+}
+
+func (storage *MemoryStorage) isExpired(item memoryItem) bool {
+	return !item.expires.IsZero() && !storage.now().Before(item.expires)
+}
+
+// Load returns session data if it's not expired.
+func (storage *MemoryStorage) Load(ctx context.Context, key string) ([]byte, error) {
+	storage.lock.RLock()
+	item, ok := storage.items[key]
+	storage.lock.RUnlock()
+
+	if !ok {
+		return nil, nil
+	}
+
+	if storage.isExpired(item) {
+		storage.lock.Lock()
+		// item can be saved again while lock is released
+		if item, ok := storage.items[key]; ok && storage.isExpired(item) {
+			delete(storage.items, key)
+		}
+		storage.lock.Unlock()
+
+		return nil, nil
+	}
+
+	return item.data, nil
+}
+
+// Save stores session data and prolongs its TTL.
+func (storage *MemoryStorage) Save(ctx context.Context, key string, data []byte) error {
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+
+	item := memoryItem{data: data}
+
+	if storage.ttl > 0 {
+		item.expires = storage.now().Add(storage.ttl)
+	}
+
+	storage.items[key] = item
+
+	return nil
+}
+
+// Delete removes session.
+func (storage *MemoryStorage) Delete(ctx context.Context, key string) error {
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+
+	delete(storage.items, key)
+
+	return nil
+}
+
+// Cleanup removes all expired sessions.
+// Call it periodically, if sessions of inactive users should not occupy memory.
+func (storage *MemoryStorage) Cleanup() {
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+
+	for key, item := range storage.items {
+		if storage.isExpired(item) {
+			delete(storage.items, key)
+		}
+	}
+}
+
+// Len returns number of stored sessions, including expired but not removed yet.
+func (storage *MemoryStorage) Len() int {
+	storage.lock.RLock()
+	defer storage.lock.RUnlock()
+
+	return len(storage.items)
+}
@@ -0,0 +1,47 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStorage(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Date(2019, 7, 27, 0, 0, 0, 0, time.UTC)
+
+	storage := NewMemoryStorage(time.Hour)
+	storage.now = func() time.Time { return now }
+
+	data, err := storage.Load(ctx, "user:1")
+	require.NoError(t, err)
+	assert.Nil(t, data)
+
+	require.NoError(t, storage.Save(ctx, "user:1", []byte(`{}`)))
+	require.NoError(t, storage.Save(ctx, "user:2", []byte(`{}`)))
+
+	now = now.Add(time.Minute * 30)
+
+	// prolong TTL of user:2
+	require.NoError(t, storage.Save(ctx, "user:2", []byte(`{"a": 1}`)))
+
+	now = now.Add(time.Minute * 31)
+
+	data, err = storage.Load(ctx, "user:1")
+	require.NoError(t, err)
+	assert.Nil(t, data)
+
+	data, err = storage.Load(ctx, "user:2")
+	require.NoError(t, err)
+	assert.Equal(t, `{"a": 1}`, string(data))
+
+	require.NoError(t, storage.Save(ctx, "user:3", []byte(`{}`)))
+	now = now.Add(time.Hour)
+	storage.Cleanup()
+
+	assert.Equal(t, 0, storage.Len())
+}
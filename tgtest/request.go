@@ -0,0 +1,146 @@
+package tgtest
+
+import (
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mr-linch/go-tg"
+)
+
+// File represents file uploaded by bot.
+type File struct {
+	// Name of file in multipart request.
+	Name string
+
+	// Content of file.
+	Content []byte
+}
+
+// Call represents Bot API call received by server.
+type Call struct {
+	// Bot API method.
+	Method string
+
+	// String arguments.
+	Args map[string]string
+
+	// Uploaded files.
+	Files map[string]File
+}
+
+// apiError represents Bot API error response.
+type apiError struct {
+	Code        int
+	Description string
+	Parameters  *tg.ResponseParameters
+}
+
+func (err *apiError) Error() string {
+	return err.Description
+}
+
+func newBadRequest(description string) *apiError {
+	return &apiError{
+		Code:        http.StatusBadRequest,
+		Description: "Bad Request: " + description,
+	}
+}
+
+func parseCall(method string, r *http.Request) (*Call, error) {
+	call := &Call{
+		Method: method,
+		Args:   make(map[string]string),
+		Files:  make(map[string]File),
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	if mediaType == "multipart/form-data" {
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			return nil, errors.Wrap(err, "parse multipart form")
+		}
+
+		for k, headers := range r.MultipartForm.File {
+			if len(headers) == 0 {
+				continue
+			}
+
+			f, err := headers[0].Open()
+			if err != nil {
+				return nil, errors.Wrapf(err, "open file '%s'", k)
+			}
+
+			content, err := ioutil.ReadAll(f)
+			f.Close()
+			if err != nil {
+				return nil, errors.Wrapf(err, "read file '%s'", k)
+			}
+
+			call.Files[k] = File{
+				Name:    headers[0].Filename,
+				Content: content,
+			}
+		}
+	} else if err := r.ParseForm(); err != nil {
+		return nil, errors.Wrap(err, "parse form")
+	}
+
+	for k, vs := range r.Form {
+		if len(vs) > 0 {
+			call.Args[k] = vs[0]
+		}
+	}
+
+	return call, nil
+}
+
+// String returns argument k.
+func (call *Call) String(k string) string {
+	return call.Args[k]
+}
+
+// Has returns true if argument k is present.
+func (call *Call) Has(k string) bool {
+	_, ok := call.Args[k]
+	return ok
+}
+
+func (call *Call) int64(k string) (int64, error) {
+	v, ok := call.Args[k]
+	if !ok || v == "" {
+		return 0, nil
+	}
+
+	result, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, newBadRequest(k + " is invalid")
+	}
+
+	return result, nil
+}
+
+func (call *Call) int(k string) (int, error) {
+	v, err := call.int64(k)
+	return int(v), err
+}
+
+// file returns uploaded file passed as k directly or by attach:// reference.
+// Returns nil if argument k is not an upload.
+func (call *Call) file(k string) *File {
+	if f, ok := call.Files[k]; ok {
+		return &f
+	}
+
+	if v := call.Args[k]; strings.HasPrefix(v, "attach://") {
+		if f, ok := call.Files[strings.TrimPrefix(v, "attach://")]; ok {
+			return &f
+		}
+	}
+
+	return nil
+}
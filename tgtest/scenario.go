@@ -0,0 +1,484 @@
+package tgtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mr-linch/go-tg"
+)
+
+// UpdateGoldenEnv is the name of environment variable,
+// if it's not empty Scenario.AssertGolden rewrites golden files instead of comparing.
+const UpdateGoldenEnv = "TGTEST_UPDATE_GOLDEN"
+
+// T is a subset of testing.TB used by Scenario.
+type T interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+	FailNow()
+}
+
+// Scenario drives bot handler through the real tg.Client and fake Server
+// and allows to describe conversation in terms of user actions and bot responses.
+//
+// Example:
+//
+//   sc := tgtest.NewScenario(t, func(client *tg.Client) tg.Handler {
+//       return newBot(client)
+//   })
+//   defer sc.Close()
+//
+//   user := sc.User(tg.User{ID: 42, FirstName: "Sasha"})
+//
+//   user.Sends("/start")
+//   user.ExpectReply().Contains("Welcome").Buttons(2)
+//
+//   user.Clicks("Next")
+//   sc.ExpectCall("answerCallbackQuery")
+//   user.ExpectEdit().Text("Step 2")
+//
+//   sc.ExpectNoCalls()
+//   sc.AssertGolden("start")
+//
+type Scenario struct {
+	t T
+
+	server  *Server
+	client  *tg.Client
+	handler tg.Handler
+
+	offset tg.UpdateID
+	cursor int
+}
+
+// NewScenario creates scenario with new fake Server.
+// Handler is created by factory with client of the server.
+func NewScenario(t T, factory func(client *tg.Client) tg.Handler, opts ...Option) *Scenario {
+	server := NewServer(opts...)
+	client := server.Client()
+
+	return &Scenario{
+		t:       t,
+		server:  server,
+		client:  client,
+		handler: factory(client),
+	}
+}
+
+// Close shutdowns scenario server.
+func (sc *Scenario) Close() {
+	sc.server.Close()
+}
+
+// Server returns underlying fake server.
+func (sc *Scenario) Server() *Server {
+	return sc.server
+}
+
+// Client returns client used by handler.
+func (sc *Scenario) Client() *tg.Client {
+	return sc.client
+}
+
+func (sc *Scenario) fatalf(format string, args ...interface{}) {
+	sc.t.Helper()
+	sc.t.Errorf(format, args...)
+	sc.t.FailNow()
+}
+
+// Process fetches pending updates using getUpdates and passes them to the handler.
+// It's called automatically after each user action.
+func (sc *Scenario) Process() {
+	sc.t.Helper()
+
+	ctx := context.Background()
+
+	for {
+		updates, err := sc.client.GetUpdates(ctx, &tg.UpdatesOptions{
+			Offset: sc.offset,
+		})
+		if err != nil {
+			sc.fatalf("get updates: %v", err)
+			return
+		}
+
+		if len(updates) == 0 {
+			return
+		}
+
+		for i := range updates {
+			update := &updates[i]
+
+			if err := sc.handler.HandleUpdate(ctx, update); err != nil {
+				sc.t.Errorf("handle update %d: %v", update.ID, err)
+			}
+
+			sc.offset = update.ID.Next()
+		}
+	}
+}
+
+// User registers user with private chat and returns actor to act as this user.
+func (sc *Scenario) User(user tg.User) *Actor {
+	chat := sc.server.AddUser(user)
+
+	return &Actor{
+		sc:   sc,
+		User: user,
+		Chat: chat.ID,
+	}
+}
+
+// isScenarioCall returns true, if call is made by scenario itself.
+func isScenarioCall(call Call) bool {
+	return strings.EqualFold(call.Method, "getUpdates")
+}
+
+// botCalls returns calls made by bot, starting from i.
+func (sc *Scenario) botCalls(i int) []Call {
+	all := sc.server.Calls()
+
+	var result []Call
+
+	for ; i < len(all); i++ {
+		if !isScenarioCall(all[i]) {
+			result = append(result, all[i])
+		}
+	}
+
+	return result
+}
+
+// nextCall consumes next call made by bot or returns nil if there is no such call.
+func (sc *Scenario) nextCall() *Call {
+	all := sc.server.Calls()
+
+	for ; sc.cursor < len(all); sc.cursor++ {
+		if !isScenarioCall(all[sc.cursor]) {
+			call := all[sc.cursor]
+			sc.cursor++
+			return &call
+		}
+	}
+
+	return nil
+}
+
+// ExpectCall consumes next call made by bot and checks its method.
+// Returns nil if expectation is failed.
+func (sc *Scenario) ExpectCall(method string) *Call {
+	sc.t.Helper()
+
+	call := sc.nextCall()
+	if call == nil {
+		sc.t.Errorf("expected call %s, but bot made no more calls", method)
+		return nil
+	}
+
+	if !strings.EqualFold(call.Method, method) {
+		sc.t.Errorf("expected call %s, but got:\n%s", method, formatCall(*call))
+		return nil
+	}
+
+	return call
+}
+
+// ExpectNoCalls checks that all calls made by bot are consumed by expectations.
+func (sc *Scenario) ExpectNoCalls() {
+	sc.t.Helper()
+
+	calls := sc.botCalls(sc.cursor)
+	if len(calls) > 0 {
+		sc.t.Errorf("expected no more calls, but got:\n%s", formatCalls(calls))
+	}
+
+	sc.cursor = len(sc.server.Calls())
+}
+
+// AssertGolden compares all calls made by bot during scenario
+// with golden file testdata/<name>.golden.
+// Set environment variable TGTEST_UPDATE_GOLDEN to create or update golden file.
+func (sc *Scenario) AssertGolden(name string) {
+	sc.t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	actual := formatCalls(sc.botCalls(0))
+
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			sc.t.Errorf("create golden file dir: %v", err)
+			return
+		}
+
+		if err := ioutil.WriteFile(path, []byte(actual), 0644); err != nil {
+			sc.t.Errorf("write golden file: %v", err)
+		}
+
+		return
+	}
+
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		sc.t.Errorf("read golden file (set %s=1 to create it): %v", UpdateGoldenEnv, err)
+		return
+	}
+
+	assert.Equal(sc.t, string(expected), actual, "calls does not match golden file %s", path)
+}
+
+// formatCall returns human readable representation of call
+// with sorted arguments and indented JSON values.
+func formatCall(call Call) string {
+	buf := &bytes.Buffer{}
+
+	buf.WriteString(call.Method)
+	buf.WriteString("\n")
+
+	keys := make([]string, 0, len(call.Args))
+	for k := range call.Args {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := call.Args[k]
+
+		indented := &bytes.Buffer{}
+		if strings.HasPrefix(v, "{") || strings.HasPrefix(v, "[") {
+			if err := json.Indent(indented, []byte(v), "    ", "  "); err == nil {
+				v = indented.String()
+			}
+		}
+
+		fmt.Fprintf(buf, "  %s: %s\n", k, v)
+	}
+
+	names := make([]string, 0, len(call.Files))
+	for k := range call.Files {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, k := range names {
+		file := call.Files[k]
+		fmt.Fprintf(buf, "  %s: <file %s, %d bytes>\n", k, file.Name, len(file.Content))
+	}
+
+	return buf.String()
+}
+
+func formatCalls(calls []Call) string {
+	items := make([]string, len(calls))
+
+	for i, call := range calls {
+		items[i] = formatCall(call)
+	}
+
+	return strings.Join(items, "\n")
+}
+
+// Actor acts in scenario as user.
+type Actor struct {
+	sc *Scenario
+
+	// User profile.
+	User tg.User
+
+	// Chat where user acts.
+	Chat tg.ChatID
+}
+
+// In returns actor of same user acting in other chat.
+func (actor *Actor) In(chatID tg.ChatID) *Actor {
+	result := *actor
+	result.Chat = chatID
+	return &result
+}
+
+// Sends sends text message to the chat and processes updates.
+func (actor *Actor) Sends(text string) *tg.Message {
+	actor.sc.t.Helper()
+
+	msg, err := actor.sc.server.SendText(actor.User, actor.Chat, text)
+	if err != nil {
+		actor.sc.fatalf("send text: %v", err)
+		return nil
+	}
+
+	actor.sc.Process()
+
+	return msg
+}
+
+// Clicks clicks callback button with provided text of the latest message in chat and processes updates.
+func (actor *Actor) Clicks(text string) *tg.CallbackQuery {
+	actor.sc.t.Helper()
+
+	msgs := actor.sc.server.Messages(actor.Chat)
+
+	for i := len(msgs) - 1; i >= 0; i-- {
+		for _, row := range msgs[i].ReplyMarkup.InlineKeyboard {
+			for _, button := range row {
+				if button.Text != text || button.CallbackData == "" {
+					continue
+				}
+
+				query, err := actor.sc.server.Click(actor.User, msgs[i], button.CallbackData)
+				if err != nil {
+					actor.sc.fatalf("click: %v", err)
+					return nil
+				}
+
+				actor.sc.Process()
+
+				return query
+			}
+		}
+	}
+
+	actor.sc.fatalf("button '%s' not found in chat %d", text, actor.Chat)
+
+	return nil
+}
+
+func (actor *Actor) expectMessageCall(kind string, match func(method string) bool) *MessageExpectation {
+	actor.sc.t.Helper()
+
+	call := actor.sc.nextCall()
+
+	exp := &MessageExpectation{t: actor.sc.t}
+
+	switch {
+	case call == nil:
+		actor.sc.t.Errorf("expected %s, but bot made no more calls", kind)
+	case !match(call.Method):
+		actor.sc.t.Errorf("expected %s, but got:\n%s", kind, formatCall(*call))
+	case call.String("chat_id") != fmt.Sprint(actor.Chat):
+		actor.sc.t.Errorf("expected %s to chat %d, but got:\n%s", kind, actor.Chat, formatCall(*call))
+	default:
+		exp.call = call
+	}
+
+	return exp
+}
+
+// ExpectReply consumes next call made by bot and checks that it's a message sent to the actor chat.
+func (actor *Actor) ExpectReply() *MessageExpectation {
+	actor.sc.t.Helper()
+
+	return actor.expectMessageCall("reply", func(method string) bool {
+		return strings.HasPrefix(strings.ToLower(method), "send") ||
+			strings.EqualFold(method, "forwardMessage")
+	})
+}
+
+// ExpectEdit consumes next call made by bot and checks that it's an edit of message in the actor chat.
+func (actor *Actor) ExpectEdit() *MessageExpectation {
+	actor.sc.t.Helper()
+
+	return actor.expectMessageCall("edit", func(method string) bool {
+		return strings.HasPrefix(strings.ToLower(method), "editmessage")
+	})
+}
+
+// MessageExpectation checks content of sent or edited message.
+// If message call expectation is failed, all checks are skipped.
+type MessageExpectation struct {
+	t    T
+	call *Call
+}
+
+// Call returns checked call or nil if expectation is failed.
+func (exp *MessageExpectation) Call() *Call {
+	return exp.call
+}
+
+func (exp *MessageExpectation) text() string {
+	if v, ok := exp.call.Args["text"]; ok {
+		return v
+	}
+
+	return exp.call.Args["caption"]
+}
+
+func (exp *MessageExpectation) buttons() []tg.InlineKeyboardButton {
+	markup, err := parseInlineKeyboard(exp.call)
+	if err != nil {
+		exp.t.Errorf("parse reply markup: %v", err)
+		return nil
+	}
+
+	var result []tg.InlineKeyboardButton
+
+	for _, row := range markup.InlineKeyboard {
+		result = append(result, row...)
+	}
+
+	return result
+}
+
+// Text checks that message text (or caption) is equal to expected.
+func (exp *MessageExpectation) Text(expected string) *MessageExpectation {
+	exp.t.Helper()
+
+	if exp.call != nil {
+		assert.Equal(exp.t, expected, exp.text(), "message text")
+	}
+
+	return exp
+}
+
+// Contains checks that message text (or caption) contains substring.
+func (exp *MessageExpectation) Contains(substr string) *MessageExpectation {
+	exp.t.Helper()
+
+	if exp.call != nil {
+		assert.Contains(exp.t, exp.text(), substr, "message text")
+	}
+
+	return exp
+}
+
+// Buttons checks number of inline keyboard buttons.
+func (exp *MessageExpectation) Buttons(n int) *MessageExpectation {
+	exp.t.Helper()
+
+	if exp.call != nil {
+		buttons := exp.buttons()
+
+		texts := make([]string, len(buttons))
+		for i, button := range buttons {
+			texts[i] = button.Text
+		}
+
+		assert.Len(exp.t, texts, n, "inline keyboard buttons")
+	}
+
+	return exp
+}
+
+// Button checks that message has inline keyboard button with provided text.
+func (exp *MessageExpectation) Button(text string) *MessageExpectation {
+	exp.t.Helper()
+
+	if exp.call != nil {
+		var texts []string
+
+		for _, button := range exp.buttons() {
+			texts = append(texts, button.Text)
+		}
+
+		assert.Contains(exp.t, texts, text, "inline keyboard buttons")
+	}
+
+	return exp
+}
@@ -0,0 +1,169 @@
+package tgtest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mr-linch/go-tg"
+)
+
+// recorderT records failures instead of failing test.
+type recorderT struct {
+	errors []string
+	failed bool
+}
+
+func (t *recorderT) Helper() {}
+
+func (t *recorderT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *recorderT) FailNow() {
+	t.failed = true
+}
+
+func newWizardBot(client *tg.Client) tg.Handler {
+	return tg.HandlerFunc(func(ctx context.Context, update *tg.Update) error {
+		switch {
+		case update.Message != nil && strings.HasPrefix(update.Message.Text, "/start"):
+			return client.Send(ctx,
+				tg.NewTextMessage(update.Message.Chat.ID, "Welcome, "+update.Message.From.FirstName+"!").
+					WithReplyMarkup(tg.NewInlineKeyboardMarkup(
+						tg.NewInlineKeyboardRow(
+							tg.NewInlineKeyboardButtonCallback("Next", "next"),
+							tg.NewInlineKeyboardButtonCallback("Cancel", "cancel"),
+						),
+					)),
+				nil,
+			)
+		case update.CallbackQuery != nil:
+			query := update.CallbackQuery
+
+			if err := client.Invoke(ctx,
+				tg.NewRequest("answerCallbackQuery").
+					AddString("callback_query_id", string(query.ID)),
+				nil,
+			); err != nil {
+				return err
+			}
+
+			return client.Invoke(ctx,
+				tg.NewRequest("editMessageText").
+					AddChatID(query.Message.Chat.ID).
+					AddInt("message_id", int(query.Message.ID)).
+					AddString("text", "Step 2"),
+				nil,
+			)
+		default:
+			return nil
+		}
+	})
+}
+
+func TestScenario(t *testing.T) {
+	sc := NewScenario(t, newWizardBot)
+	defer sc.Close()
+
+	user := sc.User(tg.User{ID: 42, FirstName: "Sasha"})
+
+	user.Sends("/start")
+	user.ExpectReply().
+		Contains("Welcome").
+		Text("Welcome, Sasha!").
+		Buttons(2).
+		Button("Next")
+
+	user.Clicks("Next")
+	sc.ExpectCall("answerCallbackQuery")
+	user.ExpectEdit().Text("Step 2").Buttons(0)
+
+	sc.ExpectNoCalls()
+	sc.AssertGolden("scenario_wizard")
+}
+
+func TestScenario_Failures(t *testing.T) {
+	rt := &recorderT{}
+
+	sc := NewScenario(rt, newWizardBot)
+	defer sc.Close()
+
+	user := sc.User(tg.User{ID: 42, FirstName: "Sasha"})
+
+	user.Sends("/start")
+	user.ExpectReply().Text("Welcome!").Buttons(3)
+
+	if assert.Len(t, rt.errors, 2) {
+		assert.Contains(t, rt.errors[0], "-Welcome!")
+		assert.Contains(t, rt.errors[0], "+Welcome, Sasha!")
+		assert.Contains(t, rt.errors[1], "should have 3 item(s), but has 2")
+	}
+
+	rt.errors = nil
+
+	user.Sends("/start")
+	user.ExpectEdit().Text("skipped")
+
+	if assert.Len(t, rt.errors, 1) {
+		assert.Contains(t, rt.errors[0], "expected edit, but got:\nsendMessage\n  chat_id: 42\n")
+	}
+
+	rt.errors = nil
+
+	user.ExpectReply()
+	sc.ExpectCall("sendMessage")
+
+	assert.Equal(t, []string{
+		"expected reply, but bot made no more calls",
+		"expected call sendMessage, but bot made no more calls",
+	}, rt.errors)
+
+	rt.errors = nil
+
+	user.Clicks("Missing")
+
+	assert.True(t, rt.failed)
+	assert.Equal(t, []string{"button 'Missing' not found in chat 42"}, rt.errors)
+
+	rt.errors = nil
+
+	user.Clicks("Next")
+	sc.ExpectNoCalls()
+
+	if assert.Len(t, rt.errors, 1) {
+		assert.Contains(t, rt.errors[0], "expected no more calls, but got:\nanswerCallbackQuery\n")
+	}
+
+	rt.errors = nil
+
+	sc.AssertGolden("not_exists")
+
+	if assert.Len(t, rt.errors, 1) {
+		assert.Contains(t, rt.errors[0], "read golden file")
+	}
+}
+
+func TestFormatCall(t *testing.T) {
+	assert.Equal(t,
+		"sendPhoto\n"+
+			"  chat_id: 42\n"+
+			"  reply_markup: {\n"+
+			"      \"inline_keyboard\": []\n"+
+			"    }\n"+
+			"  photo: <file photo.jpg, 4 bytes>\n",
+		formatCall(Call{
+			Method: "sendPhoto",
+			Args: map[string]string{
+				"reply_markup": `{"inline_keyboard":[]}`,
+				"chat_id":      "42",
+			},
+			Files: map[string]File{
+				"photo": {Name: "photo.jpg", Content: []byte("JPEG")},
+			},
+		}),
+	)
+}
@@ -0,0 +1,515 @@
+// Package tgtest provides utilities for testing bots without access to Telegram.
+//
+// Server is an in-process fake of Telegram Bot API.
+// It keeps chats, messages, files and updates in memory,
+// allows tests to act as users (send messages, click buttons)
+// and records every call made by bot.
+//
+// Example:
+//
+//   server := tgtest.NewServer()
+//   defer server.Close()
+//
+//   user := tg.User{ID: 42, FirstName: "Sasha"}
+//   server.AddUser(user)
+//
+//   client := server.Client()
+//
+//   server.SendText(user, tg.ChatID(user.ID), "/start")
+//
+//   updates, _ := client.GetUpdates(ctx, nil)
+//   // handle updates...
+//
+//   sent := server.Sent()
+//
+package tgtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+
+	"github.com/pkg/errors"
+
+	"github.com/mr-linch/go-tg"
+)
+
+const (
+	// DefaultToken used by Server if no other is provided.
+	DefaultToken = "123456:TEST-TOKEN"
+)
+
+// DefaultBot is a profile of bot returned by getMe by default.
+var DefaultBot = tg.User{
+	ID:        123456,
+	IsBot:     true,
+	FirstName: "Test Bot",
+	Username:  "test_bot",
+}
+
+type storedFile struct {
+	ID      tg.FileID
+	Path    string
+	Content []byte
+}
+
+type webhookConfig struct {
+	URL            string
+	MaxConnections int
+	AllowedUpdates []tg.UpdateType
+
+	LastErrorMessage string
+	LastErrorDate    int64
+}
+
+// Server is a fake Telegram Bot API server.
+// All methods are safe for concurrent use.
+type Server struct {
+	token string
+	bot   tg.User
+
+	server *httptest.Server
+
+	lock sync.Mutex
+
+	users    map[tg.UserID]*tg.User
+	chats    map[tg.ChatID]*tg.Chat
+	members  map[tg.ChatID]map[tg.UserID]bool
+	messages map[tg.ChatID][]*tg.Message
+
+	files   map[tg.FileID]*storedFile
+	fileSeq int
+
+	updates   []tg.Update
+	updateSeq tg.UpdateID
+	notify    chan struct{}
+
+	webhook *webhookConfig
+
+	calls []Call
+	sent  []*tg.Message
+
+	now func() time.Time
+}
+
+// Option use this for configure server.
+type Option func(server *Server)
+
+// WithToken sets token accepted by server.
+func WithToken(token string) Option {
+	return func(server *Server) {
+		server.token = token
+	}
+}
+
+// WithBot sets bot profile.
+func WithBot(bot tg.User) Option {
+	return func(server *Server) {
+		server.bot = bot
+	}
+}
+
+// WithClock sets function used to get current time.
+func WithClock(now func() time.Time) Option {
+	return func(server *Server) {
+		server.now = now
+	}
+}
+
+// NewServer creates and starts fake server.
+// Server should be closed after use.
+func NewServer(opts ...Option) *Server {
+	server := &Server{
+		token: DefaultToken,
+		bot:   DefaultBot,
+
+		users:    make(map[tg.UserID]*tg.User),
+		chats:    make(map[tg.ChatID]*tg.Chat),
+		members:  make(map[tg.ChatID]map[tg.UserID]bool),
+		messages: make(map[tg.ChatID][]*tg.Message),
+		files:    make(map[tg.FileID]*storedFile),
+		notify:   make(chan struct{}),
+
+		updateSeq: 100000,
+
+		now: time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(server)
+	}
+
+	server.server = httptest.NewServer(server)
+
+	return server
+}
+
+// Close shutdowns server.
+func (server *Server) Close() {
+	server.server.Close()
+}
+
+// URL returns base URL of server.
+func (server *Server) URL() string {
+	return server.server.URL
+}
+
+// Token returns token accepted by server.
+func (server *Server) Token() string {
+	return server.token
+}
+
+// Bot returns bot profile.
+func (server *Server) Bot() tg.User {
+	return server.bot
+}
+
+// Transport returns tg.HTTPTransport configured to send requests to the server.
+func (server *Server) Transport() *tg.HTTPTransport {
+	return tg.NewHTTPTransport(
+		tg.WithHTTPServerURL(server.URL()),
+	)
+}
+
+// Client returns client of server bot.
+func (server *Server) Client(opts ...tg.ClientOption) *tg.Client {
+	opts = append([]tg.ClientOption{
+		tg.WithTransport(server.Transport()),
+	}, opts...)
+
+	return tg.NewClient(server.token, opts...)
+}
+
+// AddUser registers user and private chat with bot.
+func (server *Server) AddUser(user tg.User) *tg.Chat {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	u := user
+	server.users[user.ID] = &u
+
+	chat := &tg.Chat{
+		ID:        tg.ChatID(user.ID),
+		Type:      tg.PrivateChat,
+		Username:  user.Username,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+	}
+
+	server.chats[chat.ID] = chat
+	server.addMember(chat.ID, user.ID)
+
+	result := *chat
+	return &result
+}
+
+// AddChat registers group, supergroup or channel with provided members.
+func (server *Server) AddChat(chat tg.Chat, members ...tg.User) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	c := chat
+	server.chats[chat.ID] = &c
+
+	for _, member := range members {
+		m := member
+		server.users[member.ID] = &m
+		server.addMember(chat.ID, member.ID)
+	}
+}
+
+func (server *Server) addMember(chatID tg.ChatID, userID tg.UserID) {
+	if server.members[chatID] == nil {
+		server.members[chatID] = make(map[tg.UserID]bool)
+	}
+
+	server.members[chatID][userID] = true
+}
+
+// AddFile stores file, so it can be used by FileID and downloaded.
+func (server *Server) AddFile(name string, content []byte) tg.FileID {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	return server.addFile(name, content).ID
+}
+
+func (server *Server) addFile(name string, content []byte) *storedFile {
+	server.fileSeq++
+
+	file := &storedFile{
+		ID:      tg.FileID(fmt.Sprintf("file-%d", server.fileSeq)),
+		Path:    fmt.Sprintf("documents/file_%d_%s", server.fileSeq, name),
+		Content: content,
+	}
+
+	server.files[file.ID] = file
+
+	return file
+}
+
+func (server *Server) newMessage(chatID tg.ChatID, from *tg.User) *tg.Message {
+	chat := server.chats[chatID]
+
+	msgs := server.messages[chatID]
+
+	msg := &tg.Message{
+		ID:   tg.MessageID(len(msgs) + 1),
+		From: from,
+		Date: server.now().Unix(),
+		Chat: *chat,
+	}
+
+	server.messages[chatID] = append(msgs, msg)
+
+	return msg
+}
+
+func (server *Server) findMessage(chatID tg.ChatID, id tg.MessageID) *tg.Message {
+	msgs := server.messages[chatID]
+
+	if id < 1 || int(id) > len(msgs) || msgs[id-1] == nil {
+		return nil
+	}
+
+	return msgs[id-1]
+}
+
+// SendText acts as user: sends text message to the chat and enqueues update for bot.
+// Commands (text started with "/") get bot_command entity.
+func (server *Server) SendText(from tg.User, chatID tg.ChatID, text string) (*tg.Message, error) {
+	server.lock.Lock()
+
+	if _, ok := server.chats[chatID]; !ok {
+		server.lock.Unlock()
+		return nil, fmt.Errorf("chat %d is not registered", chatID)
+	}
+
+	sender := from
+	msg := server.newMessage(chatID, &sender)
+	msg.Text = text
+
+	if strings.HasPrefix(text, "/") {
+		command := strings.Fields(text)[0]
+
+		msg.Entities = tg.MessageEntitySlice{{
+			Type:   "bot_command",
+			Offset: 0,
+			Length: len(utf16.Encode([]rune(command))),
+		}}
+	}
+
+	update := tg.Update{Message: copyMessage(msg)}
+	if msg.Chat.Type == tg.ChannelChat {
+		update = tg.Update{ChannelPost: update.Message}
+	}
+
+	server.pushUpdate(update)
+
+	server.lock.Unlock()
+
+	server.deliverWebhook()
+
+	return copyMessage(msg), nil
+}
+
+// Click acts as user: clicks inline keyboard button with callback data
+// of message and enqueues callback query update.
+func (server *Server) Click(from tg.User, msg *tg.Message, data string) (*tg.CallbackQuery, error) {
+	server.lock.Lock()
+
+	stored := server.findMessage(msg.Chat.ID, msg.ID)
+	if stored == nil {
+		server.lock.Unlock()
+		return nil, fmt.Errorf("message %d not found in chat %d", msg.ID, msg.Chat.ID)
+	}
+
+	found := false
+
+	for _, row := range stored.ReplyMarkup.InlineKeyboard {
+		for _, button := range row {
+			if button.CallbackData == data {
+				found = true
+			}
+		}
+	}
+
+	if !found {
+		server.lock.Unlock()
+		return nil, fmt.Errorf("message %d has no button with callback data '%s'", msg.ID, data)
+	}
+
+	query := &tg.CallbackQuery{
+		ID:           tg.CallbackQueryID(fmt.Sprintf("%d", server.updateSeq+1)),
+		From:         from,
+		Message:      copyMessage(stored),
+		ChatInstance: fmt.Sprintf("%d", msg.Chat.ID),
+		Data:         data,
+	}
+
+	server.pushUpdate(tg.Update{CallbackQuery: query})
+
+	server.lock.Unlock()
+
+	server.deliverWebhook()
+
+	result := *query
+	return &result, nil
+}
+
+// PushUpdate enqueues arbitrary update for bot. Update ID is assigned by server.
+func (server *Server) PushUpdate(update tg.Update) tg.UpdateID {
+	server.lock.Lock()
+	id := server.pushUpdate(update)
+	server.lock.Unlock()
+
+	server.deliverWebhook()
+
+	return id
+}
+
+func (server *Server) pushUpdate(update tg.Update) tg.UpdateID {
+	server.updateSeq++
+	update.ID = server.updateSeq
+
+	server.updates = append(server.updates, update)
+
+	// wake up long polling requests
+	close(server.notify)
+	server.notify = make(chan struct{})
+
+	return update.ID
+}
+
+// DeliverWebhook sends pending updates to webhook, if it is set.
+// It's called automatically on each new update,
+// so call it only for repeat delivery after webhook failure.
+func (server *Server) DeliverWebhook() error {
+	return server.deliverWebhook()
+}
+
+func (server *Server) deliverWebhook() error {
+	for {
+		server.lock.Lock()
+
+		if server.webhook == nil || len(server.updates) == 0 {
+			server.lock.Unlock()
+			return nil
+		}
+
+		url := server.webhook.URL
+		update := server.updates[0]
+
+		server.lock.Unlock()
+
+		err := postUpdate(url, update)
+
+		server.lock.Lock()
+
+		if err != nil {
+			if server.webhook != nil {
+				server.webhook.LastErrorMessage = err.Error()
+				server.webhook.LastErrorDate = server.now().Unix()
+			}
+			server.lock.Unlock()
+			return err
+		}
+
+		if len(server.updates) > 0 && server.updates[0].ID == update.ID {
+			server.updates = server.updates[1:]
+		}
+
+		server.lock.Unlock()
+	}
+}
+
+func postUpdate(url string, update tg.Update) error {
+	body, err := json.Marshal(update)
+	if err != nil {
+		return errors.Wrap(err, "marshal update")
+	}
+
+	res, err := http.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "post update")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("Wrong response from the webhook: %s", res.Status)
+	}
+
+	return nil
+}
+
+// Calls returns all calls received by server.
+func (server *Server) Calls() []Call {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	result := make([]Call, len(server.calls))
+	copy(result, server.calls)
+
+	return result
+}
+
+// Sent returns all messages sent by bot in order of sending.
+// Messages are returned in state at the moment of sending.
+func (server *Server) Sent() []*tg.Message {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	result := make([]*tg.Message, len(server.sent))
+	for i, msg := range server.sent {
+		result[i] = copyMessage(msg)
+	}
+
+	return result
+}
+
+// Messages returns current state of messages in the chat, deleted messages are skipped.
+func (server *Server) Messages(chatID tg.ChatID) []*tg.Message {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	result := make([]*tg.Message, 0, len(server.messages[chatID]))
+
+	for _, msg := range server.messages[chatID] {
+		if msg != nil {
+			result = append(result, copyMessage(msg))
+		}
+	}
+
+	return result
+}
+
+// Message returns current state of message or nil if it does not exist.
+func (server *Server) Message(chatID tg.ChatID, id tg.MessageID) *tg.Message {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	if msg := server.findMessage(chatID, id); msg != nil {
+		return copyMessage(msg)
+	}
+
+	return nil
+}
+
+// PendingUpdates returns number of updates not received by bot yet.
+func (server *Server) PendingUpdates() int {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	return len(server.updates)
+}
+
+func copyMessage(msg *tg.Message) *tg.Message {
+	result := *msg
+	return &result
+}
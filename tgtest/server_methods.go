@@ -0,0 +1,680 @@
+package tgtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mr-linch/go-tg"
+)
+
+type methodHandler func(server *Server, r *http.Request, call *Call) (interface{}, error)
+
+var methods = map[string]methodHandler{
+	"getme":                  (*Server).getMe,
+	"getupdates":             (*Server).getUpdates,
+	"setwebhook":             (*Server).setWebhook,
+	"deletewebhook":          (*Server).deleteWebhook,
+	"getwebhookinfo":         (*Server).getWebhookInfo,
+	"getchat":                (*Server).getChat,
+	"getchatmemberscount":    (*Server).getChatMembersCount,
+	"getfile":                (*Server).getFile,
+	"sendmessage":            (*Server).sendMessage,
+	"sendphoto":              (*Server).sendPhoto,
+	"sendaudio":              (*Server).sendAudio,
+	"senddocument":           (*Server).sendDocument,
+	"forwardmessage":         (*Server).forwardMessage,
+	"editmessagetext":        (*Server).editMessageText,
+	"editmessagecaption":     (*Server).editMessageCaption,
+	"editmessagereplymarkup": (*Server).editMessageReplyMarkup,
+	"deletemessage":          (*Server).deleteMessage,
+	"answercallbackquery":    (*Server).answerCallbackQuery,
+	"logout":                 (*Server).acknowledge,
+	"close":                  (*Server).acknowledge,
+}
+
+func writeResponse(w http.ResponseWriter, result interface{}, err error) {
+	res := struct {
+		OK          bool                   `json:"ok"`
+		Result      interface{}            `json:"result,omitempty"`
+		ErrorCode   int                    `json:"error_code,omitempty"`
+		Description string                 `json:"description,omitempty"`
+		Parameters  *tg.ResponseParameters `json:"parameters,omitempty"`
+	}{}
+
+	status := http.StatusOK
+
+	if err != nil {
+		apiErr, ok := err.(*apiError)
+		if !ok {
+			apiErr = &apiError{
+				Code:        http.StatusInternalServerError,
+				Description: "Internal Server Error: " + err.Error(),
+			}
+		}
+
+		status = apiErr.Code
+		res.ErrorCode = apiErr.Code
+		res.Description = apiErr.Description
+		res.Parameters = apiErr.Parameters
+	} else {
+		res.OK = true
+		res.Result = result
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(res)
+}
+
+// ServeHTTP handles Bot API calls and file downloads.
+func (server *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+
+	if prefix := "/file/bot" + server.token + "/"; strings.HasPrefix(path, prefix) {
+		server.serveFile(w, r, strings.TrimPrefix(path, prefix))
+		return
+	}
+
+	if !strings.HasPrefix(path, "/bot") {
+		writeResponse(w, nil, &apiError{Code: http.StatusNotFound, Description: "Not Found"})
+		return
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(path, "/bot"), "/", 2)
+	if len(parts) != 2 || parts[0] != server.token {
+		writeResponse(w, nil, &apiError{Code: http.StatusUnauthorized, Description: "Unauthorized"})
+		return
+	}
+
+	handler, ok := methods[strings.ToLower(parts[1])]
+	if !ok {
+		writeResponse(w, nil, &apiError{Code: http.StatusNotFound, Description: "Not Found: method not found"})
+		return
+	}
+
+	call, err := parseCall(parts[1], r)
+	if err != nil {
+		writeResponse(w, nil, newBadRequest(err.Error()))
+		return
+	}
+
+	server.lock.Lock()
+	server.calls = append(server.calls, *call)
+	server.lock.Unlock()
+
+	result, err := handler(server, r, call)
+
+	writeResponse(w, result, err)
+}
+
+// serveFile serves content of added file, Range requests are supported.
+func (server *Server) serveFile(w http.ResponseWriter, r *http.Request, path string) {
+	server.lock.Lock()
+
+	var content []byte
+	for _, file := range server.files {
+		if file.Path == path {
+			content = file.Content
+		}
+	}
+
+	server.lock.Unlock()
+
+	if content == nil {
+		writeResponse(w, nil, &apiError{Code: http.StatusNotFound, Description: "Not Found"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(w, r, path, time.Time{}, bytes.NewReader(content))
+}
+
+func (server *Server) getMe(r *http.Request, call *Call) (interface{}, error) {
+	return server.bot, nil
+}
+
+func (server *Server) getUpdates(r *http.Request, call *Call) (interface{}, error) {
+	offset, err := call.int64("offset")
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := call.int("limit")
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+
+	timeout, err := call.int("timeout")
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.After(time.Duration(timeout) * time.Second)
+
+	for {
+		server.lock.Lock()
+
+		if server.webhook != nil {
+			server.lock.Unlock()
+			return nil, &apiError{
+				Code:        http.StatusConflict,
+				Description: "Conflict: can't use getUpdates method while webhook is active; use deleteWebhook to delete the webhook first",
+			}
+		}
+
+		// confirm updates
+		for len(server.updates) > 0 && int64(server.updates[0].ID) < offset {
+			server.updates = server.updates[1:]
+		}
+
+		if len(server.updates) > 0 || timeout == 0 {
+			n := len(server.updates)
+			if n > limit {
+				n = limit
+			}
+
+			result := make([]tg.Update, n)
+			copy(result, server.updates[:n])
+
+			server.lock.Unlock()
+
+			return result, nil
+		}
+
+		notify := server.notify
+
+		server.lock.Unlock()
+
+		select {
+		case <-notify:
+		case <-deadline:
+			timeout = 0
+		case <-r.Context().Done():
+			return nil, r.Context().Err()
+		}
+	}
+}
+
+func (server *Server) setWebhook(r *http.Request, call *Call) (interface{}, error) {
+	url := call.String("url")
+
+	if url == "" {
+		return server.deleteWebhook(r, call)
+	}
+
+	maxConnections, err := call.int("max_connections")
+	if err != nil {
+		return nil, err
+	}
+
+	if maxConnections == 0 {
+		maxConnections = 40
+	}
+
+	var allowedUpdates []tg.UpdateType
+
+	if v := call.String("allowed_updates"); v != "" {
+		if err := json.Unmarshal([]byte(v), &allowedUpdates); err != nil {
+			return nil, newBadRequest("can't parse allowed updates")
+		}
+	}
+
+	server.lock.Lock()
+
+	server.webhook = &webhookConfig{
+		URL:            url,
+		MaxConnections: maxConnections,
+		AllowedUpdates: allowedUpdates,
+	}
+
+	server.lock.Unlock()
+
+	// deliver pending updates in background, like Telegram does
+	go server.deliverWebhook()
+
+	return true, nil
+}
+
+func (server *Server) deleteWebhook(r *http.Request, call *Call) (interface{}, error) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	server.webhook = nil
+
+	return true, nil
+}
+
+// acknowledge handles methods without state changes, call is recorded only.
+func (server *Server) acknowledge(r *http.Request, call *Call) (interface{}, error) {
+	return true, nil
+}
+
+func (server *Server) getWebhookInfo(r *http.Request, call *Call) (interface{}, error) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	info := map[string]interface{}{
+		"url":                    "",
+		"has_custom_certificate": false,
+		"pending_update_count":   len(server.updates),
+	}
+
+	if server.webhook != nil {
+		info["url"] = server.webhook.URL
+		info["max_connections"] = server.webhook.MaxConnections
+
+		if server.webhook.AllowedUpdates != nil {
+			info["allowed_updates"] = server.webhook.AllowedUpdates
+		}
+
+		if server.webhook.LastErrorMessage != "" {
+			info["last_error_message"] = server.webhook.LastErrorMessage
+			info["last_error_date"] = server.webhook.LastErrorDate
+		}
+	}
+
+	return info, nil
+}
+
+// resolveChat returns chat by chat_id like argument k (ID or @username).
+// Must be called with lock held.
+func (server *Server) resolveChat(call *Call, k string) (*tg.Chat, error) {
+	v := call.String(k)
+
+	if v == "" {
+		return nil, newBadRequest(k + " is empty")
+	}
+
+	peer, err := tg.ParsePeer(v)
+	if err != nil {
+		return nil, newBadRequest("chat not found")
+	}
+
+	switch peer := peer.(type) {
+	case tg.ChatID:
+		if chat, ok := server.chats[peer]; ok {
+			return chat, nil
+		}
+	case tg.Username:
+		for _, chat := range server.chats {
+			if chat.Username == peer {
+				return chat, nil
+			}
+		}
+	}
+
+	return nil, newBadRequest("chat not found")
+}
+
+// resolveMessage returns message identified by chat_id and message_id arguments.
+// Must be called with lock held.
+func (server *Server) resolveMessage(call *Call, chatKey, messageKey string) (*tg.Message, error) {
+	if call.Has("inline_message_id") {
+		return nil, newBadRequest("inline messages are not supported by tgtest")
+	}
+
+	chat, err := server.resolveChat(call, chatKey)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := call.int(messageKey)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := server.findMessage(chat.ID, tg.MessageID(id))
+	if msg == nil {
+		return nil, newBadRequest("message to edit not found")
+	}
+
+	return msg, nil
+}
+
+func (server *Server) getChat(r *http.Request, call *Call) (interface{}, error) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	return server.resolveChat(call, "chat_id")
+}
+
+func (server *Server) getChatMembersCount(r *http.Request, call *Call) (interface{}, error) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	chat, err := server.resolveChat(call, "chat_id")
+	if err != nil {
+		return nil, err
+	}
+
+	// bot itself is a member too
+	return len(server.members[chat.ID]) + 1, nil
+}
+
+func (server *Server) getFile(r *http.Request, call *Call) (interface{}, error) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	file, ok := server.files[tg.FileID(call.String("file_id"))]
+	if !ok {
+		return nil, newBadRequest("invalid file id")
+	}
+
+	return tg.File{
+		ID:   file.ID,
+		Size: len(file.Content),
+		Path: file.Path,
+	}, nil
+}
+
+// newBotMessage creates message from bot using common send arguments.
+// Must be called with lock held.
+func (server *Server) newBotMessage(call *Call) (*tg.Message, error) {
+	chat, err := server.resolveChat(call, "chat_id")
+	if err != nil {
+		return nil, err
+	}
+
+	var replyTo *tg.Message
+
+	if call.Has("reply_to_message_id") {
+		id, err := call.int("reply_to_message_id")
+		if err != nil {
+			return nil, err
+		}
+
+		replyTo = server.findMessage(chat.ID, tg.MessageID(id))
+		if replyTo == nil {
+			return nil, newBadRequest("reply message not found")
+		}
+	}
+
+	markup, err := parseInlineKeyboard(call)
+	if err != nil {
+		return nil, err
+	}
+
+	bot := server.bot
+
+	msg := server.newMessage(chat.ID, &bot)
+	msg.ReplyMarkup = markup
+
+	if replyTo != nil {
+		msg.ReplyToMessage = copyMessage(replyTo)
+	}
+
+	return msg, nil
+}
+
+func parseInlineKeyboard(call *Call) (tg.InlineKeyboardMarkup, error) {
+	var markup tg.InlineKeyboardMarkup
+
+	if v := call.String("reply_markup"); v != "" {
+		if err := json.Unmarshal([]byte(v), &markup); err != nil {
+			return markup, newBadRequest("can't parse reply keyboard markup JSON object")
+		}
+	}
+
+	return markup, nil
+}
+
+// sent records message sent by bot and returns its copy.
+// Must be called with lock held.
+func (server *Server) recordSent(msg *tg.Message) *tg.Message {
+	server.sent = append(server.sent, copyMessage(msg))
+	return copyMessage(msg)
+}
+
+func (server *Server) sendMessage(r *http.Request, call *Call) (interface{}, error) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	text := call.String("text")
+	if text == "" {
+		return nil, newBadRequest("message text is empty")
+	}
+
+	msg, err := server.newBotMessage(call)
+	if err != nil {
+		return nil, err
+	}
+
+	msg.Text = text
+
+	return server.recordSent(msg), nil
+}
+
+// media returns file sent as argument k: uploaded, existing FileID or URL.
+// Must be called with lock held.
+func (server *Server) media(call *Call, k string) (*storedFile, error) {
+	if upload := call.file(k); upload != nil {
+		return server.addFile(upload.Name, upload.Content), nil
+	}
+
+	v := call.String(k)
+
+	switch {
+	case v == "":
+		return nil, newBadRequest("there is no " + k + " in the request")
+	case strings.HasPrefix(v, "http://") || strings.HasPrefix(v, "https://"):
+		return server.addFile("remote", []byte(v)), nil
+	}
+
+	file, ok := server.files[tg.FileID(v)]
+	if !ok {
+		return nil, newBadRequest("wrong file identifier/HTTP URL specified")
+	}
+
+	return file, nil
+}
+
+func (server *Server) sendMedia(call *Call, k string, fill func(msg *tg.Message, file *storedFile)) (interface{}, error) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	file, err := server.media(call, k)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := server.newBotMessage(call)
+	if err != nil {
+		return nil, err
+	}
+
+	msg.Caption = call.String("caption")
+
+	fill(msg, file)
+
+	return server.recordSent(msg), nil
+}
+
+func (server *Server) sendPhoto(r *http.Request, call *Call) (interface{}, error) {
+	return server.sendMedia(call, "photo", func(msg *tg.Message, file *storedFile) {
+		msg.Photo = tg.PhotoSizeSlice{{
+			FileID:   file.ID,
+			FileSize: len(file.Content),
+		}}
+	})
+}
+
+func (server *Server) sendAudio(r *http.Request, call *Call) (interface{}, error) {
+	duration, err := call.int("duration")
+	if err != nil {
+		return nil, err
+	}
+
+	return server.sendMedia(call, "audio", func(msg *tg.Message, file *storedFile) {
+		msg.Audio = &tg.Audio{
+			FileID:          file.ID,
+			DurationSeconds: duration,
+			Performer:       call.String("performer"),
+			Title:           call.String("title"),
+			FileSize:        len(file.Content),
+		}
+	})
+}
+
+func (server *Server) sendDocument(r *http.Request, call *Call) (interface{}, error) {
+	return server.sendMedia(call, "document", func(msg *tg.Message, file *storedFile) {
+		name := file.Path[strings.LastIndex(file.Path, "/")+1:]
+
+		if upload := call.file("document"); upload != nil {
+			name = upload.Name
+		}
+
+		msg.Document = &tg.Document{
+			FileID:   string(file.ID),
+			FileName: name,
+			FileSize: len(file.Content),
+		}
+	})
+}
+
+func (server *Server) forwardMessage(r *http.Request, call *Call) (interface{}, error) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	src, err := server.resolveMessage(call, "from_chat_id", "message_id")
+	if err != nil {
+		return nil, newBadRequest("message to forward not found")
+	}
+
+	msg, err := server.newBotMessage(call)
+	if err != nil {
+		return nil, err
+	}
+
+	msg.Text = src.Text
+	msg.Entities = src.Entities
+	msg.Caption = src.Caption
+	msg.Photo = src.Photo
+	msg.Audio = src.Audio
+	msg.Document = src.Document
+	msg.ForwardDate = src.Date
+
+	if src.Chat.Type == tg.ChannelChat {
+		chat := src.Chat
+		msg.ForwardFromChat = &chat
+		msg.ForwardFromMessageID = src.ID
+	} else {
+		msg.ForwardFrom = src.From
+	}
+
+	return server.recordSent(msg), nil
+}
+
+// editMessage applies edit to message identified by call arguments.
+// Returns error "message is not modified" if edit does not change anything, like Telegram does.
+func (server *Server) editMessage(call *Call, edit func(msg *tg.Message) error) (interface{}, error) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	msg, err := server.resolveMessage(call, "chat_id", "message_id")
+	if err != nil {
+		return nil, err
+	}
+
+	if msg.From == nil || msg.From.ID != server.bot.ID {
+		return nil, newBadRequest("message can't be edited")
+	}
+
+	before, _ := json.Marshal(msg)
+
+	edited := copyMessage(msg)
+
+	if err := edit(edited); err != nil {
+		return nil, err
+	}
+
+	after, _ := json.Marshal(edited)
+
+	if string(before) == string(after) {
+		return nil, newBadRequest("message is not modified: specified new message content and reply markup are exactly the same as a current content and reply markup of the message")
+	}
+
+	edited.EditDate = server.now().Unix()
+
+	*msg = *edited
+
+	return copyMessage(msg), nil
+}
+
+func (server *Server) editMessageText(r *http.Request, call *Call) (interface{}, error) {
+	return server.editMessage(call, func(msg *tg.Message) error {
+		if msg.Text == "" {
+			return newBadRequest("there is no text in the message to edit")
+		}
+
+		text := call.String("text")
+		if text == "" {
+			return newBadRequest("message text is empty")
+		}
+
+		markup, err := parseInlineKeyboard(call)
+		if err != nil {
+			return err
+		}
+
+		msg.Text = text
+		msg.ReplyMarkup = markup
+
+		return nil
+	})
+}
+
+func (server *Server) editMessageCaption(r *http.Request, call *Call) (interface{}, error) {
+	return server.editMessage(call, func(msg *tg.Message) error {
+		markup, err := parseInlineKeyboard(call)
+		if err != nil {
+			return err
+		}
+
+		msg.Caption = call.String("caption")
+		msg.ReplyMarkup = markup
+
+		return nil
+	})
+}
+
+func (server *Server) editMessageReplyMarkup(r *http.Request, call *Call) (interface{}, error) {
+	return server.editMessage(call, func(msg *tg.Message) error {
+		markup, err := parseInlineKeyboard(call)
+		if err != nil {
+			return err
+		}
+
+		msg.ReplyMarkup = markup
+
+		return nil
+	})
+}
+
+func (server *Server) deleteMessage(r *http.Request, call *Call) (interface{}, error) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	msg, err := server.resolveMessage(call, "chat_id", "message_id")
+	if err != nil {
+		return nil, newBadRequest("message to delete not found")
+	}
+
+	server.messages[msg.Chat.ID][msg.ID-1] = nil
+
+	return true, nil
+}
+
+func (server *Server) answerCallbackQuery(r *http.Request, call *Call) (interface{}, error) {
+	if call.String("callback_query_id") == "" {
+		return nil, newBadRequest("query is too old and response timeout expired or query ID is invalid")
+	}
+
+	return true, nil
+}
@@ -0,0 +1,381 @@
+package tgtest
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mr-linch/go-tg"
+)
+
+var testUser = tg.User{
+	ID:        42,
+	FirstName: "Sasha",
+	Username:  "sasha",
+}
+
+func newTestServer(t *testing.T) (*Server, *tg.Client) {
+	t.Helper()
+
+	now := time.Date(2019, 7, 27, 0, 0, 0, 0, time.UTC)
+
+	server := NewServer(WithClock(func() time.Time { return now }))
+	server.AddUser(testUser)
+
+	return server, server.Client()
+}
+
+func TestServer_GetMe(t *testing.T) {
+	server, client := newTestServer(t)
+	defer server.Close()
+
+	me, err := client.GetMe(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, DefaultBot, *me)
+
+	other := tg.NewClient("654321:WRONG", tg.WithTransport(server.Transport()))
+
+	_, err = other.GetMe(context.Background())
+	assert.EqualError(t, err, "Unauthorized")
+
+	calls := server.Calls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, "getMe", calls[0].Method)
+}
+
+func TestServer_UnknownMethod(t *testing.T) {
+	server, client := newTestServer(t)
+	defer server.Close()
+
+	err := client.Invoke(context.Background(), tg.NewRequest("sendSticker"), nil)
+	assert.EqualError(t, err, "Not Found: method not found")
+}
+
+func TestServer_SendMessage(t *testing.T) {
+	ctx := context.Background()
+
+	server, client := newTestServer(t)
+	defer server.Close()
+
+	chatID := tg.ChatID(testUser.ID)
+
+	var msg tg.Message
+
+	err := client.Send(ctx,
+		tg.NewTextMessage(chatID, "Hello").
+			WithReplyMarkup(tg.NewInlineKeyboardMarkup(
+				tg.NewInlineKeyboardRow(
+					tg.NewInlineKeyboardButtonCallback("Yes", "yes"),
+				),
+			)),
+		&msg,
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, tg.MessageID(1), msg.ID)
+	assert.Equal(t, "Hello", msg.Text)
+	assert.Equal(t, DefaultBot.ID, msg.From.ID)
+	assert.Equal(t, chatID, msg.Chat.ID)
+	assert.Equal(t, "yes", msg.ReplyMarkup.InlineKeyboard[0][0].CallbackData)
+
+	sent := server.Sent()
+	require.Len(t, sent, 1)
+	assert.Equal(t, "Hello", sent[0].Text)
+
+	err = client.Send(ctx, tg.NewTextMessage(tg.ChatID(1), "Hello"), nil)
+	assert.EqualError(t, err, "Bad Request: chat not found")
+
+	err = client.Send(ctx, tg.NewTextMessage(tg.Username("sasha"), "Hi"), nil)
+	assert.NoError(t, err)
+
+	assert.Len(t, server.Messages(chatID), 2)
+}
+
+func TestServer_SendPhoto(t *testing.T) {
+	ctx := context.Background()
+
+	server, client := newTestServer(t)
+	defer server.Close()
+
+	chatID := tg.ChatID(testUser.ID)
+
+	var msg tg.Message
+
+	err := client.Send(ctx,
+		tg.NewPhotoMessage(chatID, tg.NewInputFileBytes("photo.jpg", []byte("JPEG"))).
+			WithCaption("Look"),
+		&msg,
+	)
+	require.NoError(t, err)
+	require.Len(t, msg.Photo, 1)
+	assert.Equal(t, "Look", msg.Caption)
+
+	file, err := client.GetFile(ctx, msg.Photo[0].FileID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, file.Size)
+
+	body, err := client.DownloadFile(ctx, file.Path)
+	require.NoError(t, err)
+	defer body.Close()
+
+	content, err := ioutil.ReadAll(body)
+	require.NoError(t, err)
+	assert.Equal(t, "JPEG", string(content))
+
+	// send again by file id
+	err = client.Send(ctx, tg.NewPhotoMessage(chatID, msg.Photo[0].FileID), nil)
+	require.NoError(t, err)
+
+	err = client.Send(ctx, tg.NewPhotoMessage(chatID, tg.FileID("unknown")), nil)
+	assert.EqualError(t, err, "Bad Request: wrong file identifier/HTTP URL specified")
+}
+
+func TestServer_DownloadFile(t *testing.T) {
+	ctx := context.Background()
+
+	server, client := newTestServer(t)
+	defer server.Close()
+
+	id := server.AddFile("doc.txt", []byte("test, test"))
+
+	dir, err := ioutil.TempDir("", "tgtest")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "doc.txt")
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("test, "), 0644))
+
+	_, err = client.DownloadFileToPath(ctx, id, path, &tg.DownloadOptions{Resume: true})
+	require.NoError(t, err)
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "test, test", string(content))
+
+	_, err = client.DownloadFile(ctx, "documents/unknown.txt")
+	downloadErr, ok := err.(*tg.DownloadError)
+	require.True(t, ok, "error should be *tg.DownloadError")
+	assert.Equal(t, http.StatusNotFound, downloadErr.StatusCode)
+	assert.Equal(t, "Not Found", downloadErr.Description)
+}
+
+func TestServer_GetUpdates(t *testing.T) {
+	ctx := context.Background()
+
+	server, client := newTestServer(t)
+	defer server.Close()
+
+	updates, err := client.GetUpdates(ctx, nil)
+	require.NoError(t, err)
+	assert.Len(t, updates, 0)
+
+	_, err = server.SendText(testUser, tg.ChatID(testUser.ID), "/start now")
+	require.NoError(t, err)
+
+	updates, err = client.GetUpdates(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, updates, 1)
+
+	msg := updates[0].Message
+	require.NotNil(t, msg)
+	assert.Equal(t, "/start now", msg.Text)
+	assert.Equal(t, testUser.ID, msg.From.ID)
+	require.Len(t, msg.Entities, 1)
+	assert.Equal(t, 6, msg.Entities[0].Length)
+
+	// not confirmed yet
+	assert.Equal(t, 1, server.PendingUpdates())
+
+	updates, err = client.GetUpdates(ctx, &tg.UpdatesOptions{
+		Offset: updates[0].ID.Next(),
+	})
+	require.NoError(t, err)
+	assert.Len(t, updates, 0)
+	assert.Equal(t, 0, server.PendingUpdates())
+
+	_, err = server.SendText(tg.User{ID: 1}, tg.ChatID(1), "hello")
+	assert.Error(t, err)
+}
+
+func TestServer_GetUpdatesLongPolling(t *testing.T) {
+	ctx := context.Background()
+
+	server, client := newTestServer(t)
+	defer server.Close()
+
+	go func() {
+		time.Sleep(time.Millisecond * 50)
+		server.SendText(testUser, tg.ChatID(testUser.ID), "ping")
+	}()
+
+	updates, err := client.GetUpdates(ctx, &tg.UpdatesOptions{
+		Timeout: time.Second * 5,
+	})
+	require.NoError(t, err)
+	require.Len(t, updates, 1)
+	assert.Equal(t, "ping", updates[0].Message.Text)
+}
+
+func TestServer_Click(t *testing.T) {
+	ctx := context.Background()
+
+	server, client := newTestServer(t)
+	defer server.Close()
+
+	chatID := tg.ChatID(testUser.ID)
+
+	var msg tg.Message
+
+	err := client.Send(ctx,
+		tg.NewTextMessage(chatID, "Continue?").
+			WithReplyMarkup(tg.NewInlineKeyboardMarkup(
+				tg.NewInlineKeyboardRow(
+					tg.NewInlineKeyboardButtonCallback("Yes", "yes"),
+				),
+			)),
+		&msg,
+	)
+	require.NoError(t, err)
+
+	_, err = server.Click(testUser, &msg, "no")
+	assert.Error(t, err)
+
+	query, err := server.Click(testUser, &msg, "yes")
+	require.NoError(t, err)
+
+	updates, err := client.GetUpdates(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, updates, 1)
+	require.NotNil(t, updates[0].CallbackQuery)
+	assert.Equal(t, query.ID, updates[0].CallbackQuery.ID)
+	assert.Equal(t, "yes", updates[0].CallbackQuery.Data)
+
+	err = client.Invoke(ctx,
+		tg.NewRequest("answerCallbackQuery").
+			AddString("callback_query_id", string(query.ID)),
+		nil,
+	)
+	require.NoError(t, err)
+
+	edit := func(text string) error {
+		return client.Invoke(ctx,
+			tg.NewRequest("editMessageText").
+				AddChatID(chatID).
+				AddInt("message_id", int(msg.ID)).
+				AddString("text", text),
+			nil,
+		)
+	}
+
+	require.NoError(t, edit("Done"))
+	assert.EqualError(t, edit("Done"), "Bad Request: message is not modified: specified new message content and reply markup are exactly the same as a current content and reply markup of the message")
+
+	edited := server.Message(chatID, msg.ID)
+	require.NotNil(t, edited)
+	assert.Equal(t, "Done", edited.Text)
+	assert.Len(t, edited.ReplyMarkup.InlineKeyboard, 0)
+	assert.NotZero(t, edited.EditDate)
+
+	// sent keeps original state
+	assert.Equal(t, "Continue?", server.Sent()[0].Text)
+
+	err = client.Invoke(ctx,
+		tg.NewRequest("deleteMessage").
+			AddChatID(chatID).
+			AddInt("message_id", int(msg.ID)),
+		nil,
+	)
+	require.NoError(t, err)
+	assert.Nil(t, server.Message(chatID, msg.ID))
+}
+
+func TestServer_Webhook(t *testing.T) {
+	ctx := context.Background()
+
+	server, client := newTestServer(t)
+	defer server.Close()
+
+	received := make(chan tg.Update, 1)
+	fail := true
+
+	bot := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var update tg.Update
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		received <- update
+	}))
+	defer bot.Close()
+
+	require.NoError(t, client.SetWebhook(ctx, bot.URL, nil))
+
+	_, err := client.GetUpdates(ctx, nil)
+	assert.Contains(t, err.Error(), "Conflict")
+
+	_, err = server.SendText(testUser, tg.ChatID(testUser.ID), "hello")
+	require.NoError(t, err)
+
+	info, err := client.GetWebhookInfo(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, bot.URL, info.URL)
+	assert.Equal(t, 1, info.PendingUpdateCount)
+	assert.True(t, info.HasError())
+
+	fail = false
+
+	require.NoError(t, server.DeliverWebhook())
+
+	select {
+	case update := <-received:
+		assert.Equal(t, "hello", update.Message.Text)
+	case <-time.After(time.Second):
+		t.Fatal("update is not delivered")
+	}
+
+	assert.Equal(t, 0, server.PendingUpdates())
+
+	require.NoError(t, client.DeleteWebhook(ctx))
+
+	info, err = client.GetWebhookInfo(ctx)
+	require.NoError(t, err)
+	assert.False(t, info.IsSet())
+}
+
+func TestServer_GetChat(t *testing.T) {
+	ctx := context.Background()
+
+	server, client := newTestServer(t)
+	defer server.Close()
+
+	server.AddChat(tg.Chat{
+		ID:       -100,
+		Type:     tg.SupergroupChat,
+		Title:    "Group",
+		Username: "group",
+	}, testUser)
+
+	chat, err := client.GetChat(ctx, tg.Username("group"))
+	require.NoError(t, err)
+	assert.Equal(t, tg.ChatID(-100), chat.ID)
+
+	count, err := client.GetChatMembersCount(ctx, chat.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
@@ -0,0 +1,135 @@
+// Package tracing implements tg.Observer creates span for each Bot API call.
+//
+// Package does not depend on any tracing library,
+// Tracer and Span interfaces are subset of OpenTelemetry API,
+// so adapter to any OpenTelemetry-like tracer takes a few lines.
+//
+// Example:
+//
+//   type otelTracer struct{ tracer trace.Tracer }
+//
+//   func (t otelTracer) Start(ctx context.Context, name string) (context.Context, tracing.Span) {
+//       ctx, span := t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+//       return ctx, otelSpan{span}
+//   }
+//
+//   client := tg.NewClient(token, tg.WithObserver(tracing.New(otelTracer{tracer})))
+//
+package tracing
+
+import (
+	"context"
+
+	"github.com/mr-linch/go-tg"
+)
+
+// Attribute keys set on spans.
+const (
+	AttrMethod      = "tg.method"
+	AttrPath        = "tg.file_path"
+	AttrStatusCode  = "http.status_code"
+	AttrErrorCode   = "tg.error_code"
+	AttrUploadBytes = "tg.upload_bytes"
+)
+
+// Span define interface of started span.
+type Span interface {
+	// SetAttribute sets attribute of span. Value is string, int, int64 or bool.
+	SetAttribute(key string, value interface{})
+
+	// RecordError marks span as failed.
+	RecordError(err error)
+
+	// End completes span.
+	End()
+}
+
+// Tracer define interface of span factory.
+type Tracer interface {
+	// Start creates span and returns context contains it.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// TracerFunc is an adapter to use ordinary functions as Tracer.
+type TracerFunc func(ctx context.Context, name string) (context.Context, Span)
+
+// Start calls fn(ctx, name).
+func (fn TracerFunc) Start(ctx context.Context, name string) (context.Context, Span) {
+	return fn(ctx, name)
+}
+
+type spanKey struct{}
+
+// Observer creates span for each call.
+type Observer struct {
+	tracer Tracer
+	prefix string
+}
+
+// Option use this for configure Observer.
+type Option func(observer *Observer)
+
+// WithSpanPrefix sets prefix of span names, default is "tg.".
+func WithSpanPrefix(prefix string) Option {
+	return func(observer *Observer) {
+		observer.prefix = prefix
+	}
+}
+
+// New creates tracing observer.
+func New(tracer Tracer, opts ...Option) *Observer {
+	observer := &Observer{
+		tracer: tracer,
+		prefix: "tg.",
+	}
+
+	for _, opt := range opts {
+		opt(observer)
+	}
+
+	return observer
+}
+
+// BeforeCall starts span named by method or "download" for downloads.
+func (observer *Observer) BeforeCall(ctx context.Context, info *tg.CallInfo) context.Context {
+	name := info.Method
+	if info.IsDownload() {
+		name = "download"
+	}
+
+	ctx, span := observer.tracer.Start(ctx, observer.prefix+name)
+
+	if info.IsDownload() {
+		span.SetAttribute(AttrPath, info.Path)
+	} else {
+		span.SetAttribute(AttrMethod, info.Method)
+	}
+
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// AfterCall sets result attributes and ends span.
+func (observer *Observer) AfterCall(ctx context.Context, info *tg.CallInfo, result *tg.CallResult) {
+	span, ok := ctx.Value(spanKey{}).(Span)
+	if !ok {
+		return
+	}
+
+	if result.StatusCode != 0 {
+		span.SetAttribute(AttrStatusCode, result.StatusCode)
+	}
+
+	if result.ErrorCode != 0 {
+		span.SetAttribute(AttrErrorCode, result.ErrorCode)
+	}
+
+	if result.UploadBytes != 0 {
+		span.SetAttribute(AttrUploadBytes, result.UploadBytes)
+	}
+
+	if result.Err != nil {
+		span.RecordError(result.Err)
+	}
+
+	span.End()
+}
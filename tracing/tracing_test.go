@@ -0,0 +1,113 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mr-linch/go-tg"
+)
+
+type testSpan struct {
+	Name  string
+	Attrs map[string]interface{}
+	Err   error
+	Ended bool
+}
+
+func (span *testSpan) SetAttribute(key string, value interface{}) {
+	span.Attrs[key] = value
+}
+
+func (span *testSpan) RecordError(err error) {
+	span.Err = err
+}
+
+func (span *testSpan) End() {
+	span.Ended = true
+}
+
+type testTransport struct{}
+
+func (testTransport) Execute(ctx context.Context, r *tg.Request) (*tg.Response, error) {
+	if r.Method() == "getChat" {
+		return &tg.Response{
+			StatusCode:  400,
+			ErrorCode:   400,
+			Description: "Bad Request: chat not found",
+		}, nil
+	}
+
+	// files are read like by real transport, so uploaded bytes are counted
+	if err := r.Encode(tg.NewMultipartEncoder(ioutil.Discard)); err != nil {
+		return nil, err
+	}
+
+	return &tg.Response{OK: true, StatusCode: 200, Result: json.RawMessage(`true`)}, nil
+}
+
+func (testTransport) Download(ctx context.Context, token string, path string) (io.ReadCloser, error) {
+	return nil, errors.New("not found")
+}
+
+func TestObserver(t *testing.T) {
+	var spans []*testSpan
+
+	tracer := TracerFunc(func(ctx context.Context, name string) (context.Context, Span) {
+		span := &testSpan{Name: name, Attrs: make(map[string]interface{})}
+		spans = append(spans, span)
+		return ctx, span
+	})
+
+	client := tg.NewClient("1234:secret",
+		tg.WithTransport(testTransport{}),
+		tg.WithObserver(New(tracer, WithSpanPrefix("bot."))),
+	)
+
+	ctx := context.Background()
+
+	_, err := client.GetChat(ctx, tg.ChatID(1))
+	assert.Error(t, err)
+
+	err = client.Invoke(ctx,
+		tg.NewRequest("sendDocument").
+			AddFile("document", tg.NewInputFileBytes("test.txt", []byte("hello"))),
+		nil,
+	)
+	require.NoError(t, err)
+
+	_, err = client.DownloadFile(ctx, "photos/1.jpg")
+	assert.Error(t, err)
+
+	require.Len(t, spans, 3)
+
+	assert.Equal(t, "bot.getChat", spans[0].Name)
+	assert.Equal(t, map[string]interface{}{
+		AttrMethod:     "getChat",
+		AttrStatusCode: 400,
+		AttrErrorCode:  400,
+	}, spans[0].Attrs)
+	assert.EqualError(t, spans[0].Err, "Bad Request: chat not found")
+	assert.True(t, spans[0].Ended)
+
+	assert.Equal(t, map[string]interface{}{
+		AttrMethod:      "sendDocument",
+		AttrStatusCode:  200,
+		AttrUploadBytes: int64(5),
+	}, spans[1].Attrs)
+	assert.NoError(t, spans[1].Err)
+
+	assert.Equal(t, "bot.download", spans[2].Name)
+	assert.Equal(t, map[string]interface{}{
+		AttrPath: "photos/1.jpg",
+	}, spans[2].Attrs)
+	assert.EqualError(t, spans[2].Err, "not found")
+	assert.True(t, spans[2].Ended)
+}
+
@@ -10,3 +10,26 @@ type Transport interface {
 	Execute(ctx context.Context, r *Request) (*Response, error)
 	Download(ctx context.Context, token string, path string) (io.ReadCloser, error)
 }
+
+// RangeDownloader is implemented by transports supporting download of file part.
+type RangeDownloader interface {
+	// DownloadRange downloads content of file starting from offset byte.
+	DownloadRange(ctx context.Context, token string, path string, offset int64) (io.ReadCloser, error)
+}
+
+// downloadFromTransport downloads file from offset using transport,
+// transports without range support download whole file and skip first offset bytes.
+func downloadFromTransport(ctx context.Context, transport Transport, token string, path string, offset int64) (io.ReadCloser, error) {
+	if offset > 0 {
+		if downloader, ok := transport.(RangeDownloader); ok {
+			return downloader.DownloadRange(ctx, token, path, offset)
+		}
+	}
+
+	body, err := transport.Download(ctx, token, path)
+	if err != nil || offset == 0 {
+		return body, err
+	}
+
+	return skipContent(body, offset)
+}
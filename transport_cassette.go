@@ -0,0 +1,429 @@
+package tg
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// CassetteTokenPlaceholder replaces bot token in recorded cassettes.
+const CassetteTokenPlaceholder = "<TOKEN>"
+
+// ErrCassetteUnmatched returned by ReplayTransport,
+// if cassette does not contain interaction for request.
+var ErrCassetteUnmatched = errors.New("cassette does not contain matching interaction")
+
+// CassetteFile represents uploaded file in cassette.
+// Content of file is not stored, only its hash.
+type CassetteFile struct {
+	// Name of file.
+	Name string `json:"name"`
+
+	// Hex encoded SHA-256 of file content.
+	SHA256 string `json:"sha256"`
+}
+
+// CassetteInteraction represents single recorded call of Transport.
+type CassetteInteraction struct {
+	// Download is true for Transport.Download and DownloadRange calls, false for Transport.Execute.
+	Download bool `json:"download,omitempty"`
+
+	// Bot API method of Execute call.
+	Method string `json:"method,omitempty"`
+
+	// String arguments of Execute call.
+	Args map[string]string `json:"args,omitempty"`
+
+	// Files of Execute call.
+	Files map[string]CassetteFile `json:"files,omitempty"`
+
+	// Path of Download call.
+	Path string `json:"path,omitempty"`
+
+	// Offset of DownloadRange call, zero for Download.
+	Offset int64 `json:"offset,omitempty"`
+
+	// HTTP status code of Execute response.
+	StatusCode int `json:"status_code,omitempty"`
+
+	// Execute response.
+	Response *Response `json:"response,omitempty"`
+
+	// Content returned by Download.
+	Body []byte `json:"body,omitempty"`
+
+	// Error returned by Transport.
+	Error string `json:"error,omitempty"`
+}
+
+// matches returns true if interaction is recorded for same call as other.
+func (interaction CassetteInteraction) matches(other CassetteInteraction) bool {
+	if interaction.Download != other.Download {
+		return false
+	}
+
+	if interaction.Download {
+		return interaction.Path == other.Path && interaction.Offset == other.Offset
+	}
+
+	return interaction.Method == other.Method &&
+		reflect.DeepEqual(nilIfEmptyArgs(interaction.Args), nilIfEmptyArgs(other.Args)) &&
+		reflect.DeepEqual(nilIfEmptyFiles(interaction.Files), nilIfEmptyFiles(other.Files))
+}
+
+func nilIfEmptyArgs(args map[string]string) map[string]string {
+	if len(args) == 0 {
+		return nil
+	}
+	return args
+}
+
+func nilIfEmptyFiles(files map[string]CassetteFile) map[string]CassetteFile {
+	if len(files) == 0 {
+		return nil
+	}
+	return files
+}
+
+// String returns short description of interaction.
+func (interaction CassetteInteraction) String() string {
+	if interaction.Download && interaction.Offset > 0 {
+		return fmt.Sprintf("download %s offset=%d", interaction.Path, interaction.Offset)
+	} else if interaction.Download {
+		return fmt.Sprintf("download %s", interaction.Path)
+	}
+
+	keys := make([]string, 0, len(interaction.Args)+len(interaction.Files))
+
+	for k, v := range interaction.Args {
+		keys = append(keys, fmt.Sprintf("%s=%s", k, v))
+	}
+
+	for k, v := range interaction.Files {
+		keys = append(keys, fmt.Sprintf("%s=<%s %s>", k, v.Name, v.SHA256))
+	}
+
+	sort.Strings(keys)
+
+	return fmt.Sprintf("%s(%s)", interaction.Method, strings.Join(keys, ", "))
+}
+
+// Cassette contains recorded Transport interactions.
+type Cassette struct {
+	// Arbitrary data saved with cassette, e.g. test configuration.
+	Meta map[string]string `json:"meta,omitempty"`
+
+	// Recorded interactions in order of calls.
+	Interactions []CassetteInteraction `json:"interactions"`
+}
+
+// LoadCassette reads cassette from JSON file.
+func LoadCassette(path string) (*Cassette, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read cassette")
+	}
+
+	cassette := &Cassette{}
+
+	if err := json.Unmarshal(content, cassette); err != nil {
+		return nil, errors.Wrap(err, "unmarshal cassette")
+	}
+
+	return cassette, nil
+}
+
+// Save writes cassette to JSON file, parent directories are created if needed.
+func (cassette *Cassette) Save(path string) error {
+	content, err := json.MarshalIndent(cassette, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshal cassette")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrap(err, "create cassette dir")
+	}
+
+	if err := ioutil.WriteFile(path, content, 0644); err != nil {
+		return errors.Wrap(err, "write cassette")
+	}
+
+	return nil
+}
+
+// requestInteraction returns interaction without result for request.
+// Request files are read to calculate hash, so returned request should be used instead of original.
+func requestInteraction(r *Request) (CassetteInteraction, *Request, error) {
+	interaction := CassetteInteraction{
+		Method: r.method,
+	}
+
+	if len(r.args) > 0 {
+		interaction.Args = make(map[string]string, len(r.args))
+
+		for k, v := range r.args {
+			interaction.Args[k] = v
+		}
+	}
+
+	if len(r.files) == 0 {
+		return interaction, r, nil
+	}
+
+	replaced := *r
+	replaced.files = make(map[string]InputFile, len(r.files))
+	interaction.Files = make(map[string]CassetteFile, len(r.files))
+
+	for k, file := range r.files {
+		content, err := ioutil.ReadAll(file.Body)
+		if err != nil {
+			return interaction, nil, errors.Wrapf(err, "read file '%s'", k)
+		}
+
+		hash := sha256.Sum256(content)
+
+		interaction.Files[k] = CassetteFile{
+			Name:   file.Name,
+			SHA256: hex.EncodeToString(hash[:]),
+		}
+
+		replayable := NewInputFileBytes(file.Name, content)
+		replayable.Progress = file.Progress
+
+		replaced.files[k] = replayable
+	}
+
+	return interaction, &replaced, nil
+}
+
+// RecordingTransport records all calls of wrapped Transport to the cassette.
+// Bot token is replaced with CassetteTokenPlaceholder in recorded data.
+type RecordingTransport struct {
+	next Transport
+
+	lock     sync.Mutex
+	cassette *Cassette
+}
+
+// NewRecordingTransport creates RecordingTransport with empty cassette.
+func NewRecordingTransport(next Transport) *RecordingTransport {
+	return &RecordingTransport{
+		next:     next,
+		cassette: &Cassette{},
+	}
+}
+
+// Cassette returns recorded cassette.
+func (t *RecordingTransport) Cassette() *Cassette {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	result := *t.cassette
+	result.Interactions = append([]CassetteInteraction(nil), t.cassette.Interactions...)
+
+	return &result
+}
+
+// Save writes recorded cassette to file.
+func (t *RecordingTransport) Save(path string) error {
+	return t.Cassette().Save(path)
+}
+
+func (t *RecordingTransport) record(token string, interaction CassetteInteraction) error {
+	// interaction is marshaled to redact token in all fields at once
+	content, err := json.Marshal(interaction)
+	if err != nil {
+		return errors.Wrap(err, "marshal interaction")
+	}
+
+	if token != "" {
+		content = bytes.Replace(content, []byte(token), []byte(CassetteTokenPlaceholder), -1)
+	}
+
+	redacted := CassetteInteraction{}
+	if err := json.Unmarshal(content, &redacted); err != nil {
+		return errors.Wrap(err, "unmarshal interaction")
+	}
+
+	t.lock.Lock()
+	t.cassette.Interactions = append(t.cassette.Interactions, redacted)
+	t.lock.Unlock()
+
+	return nil
+}
+
+// Execute request using wrapped transport and record it.
+func (t *RecordingTransport) Execute(ctx context.Context, r *Request) (*Response, error) {
+	interaction, r, err := requestInteraction(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "record request")
+	}
+
+	res, err := t.next.Execute(ctx, r)
+	if err != nil {
+		interaction.Error = err.Error()
+	} else {
+		interaction.StatusCode = res.StatusCode
+		interaction.Response = res
+	}
+
+	if err := t.record(r.token, interaction); err != nil {
+		return nil, err
+	}
+
+	return res, err
+}
+
+// Download file using wrapped transport and record it.
+// File content is read into memory to be recorded.
+func (t *RecordingTransport) Download(ctx context.Context, token string, path string) (io.ReadCloser, error) {
+	return t.download(ctx, token, path, 0)
+}
+
+// DownloadRange downloads file from offset using wrapped transport and record it.
+func (t *RecordingTransport) DownloadRange(ctx context.Context, token string, path string, offset int64) (io.ReadCloser, error) {
+	return t.download(ctx, token, path, offset)
+}
+
+func (t *RecordingTransport) download(ctx context.Context, token string, path string, offset int64) (io.ReadCloser, error) {
+	interaction := CassetteInteraction{
+		Download: true,
+		Path:     path,
+		Offset:   offset,
+	}
+
+	body, err := downloadFromTransport(ctx, t.next, token, path, offset)
+	if err == nil {
+		defer body.Close()
+
+		interaction.Body, err = ioutil.ReadAll(body)
+		if err != nil {
+			err = errors.Wrap(err, "read body")
+		}
+	}
+
+	if err != nil {
+		interaction.Body = nil
+		interaction.Error = err.Error()
+	}
+
+	if err := t.record(token, interaction); err != nil {
+		return nil, err
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(interaction.Body)), nil
+}
+
+// ReplayTransport serves interactions recorded in cassette.
+// Each interaction is served once, for request is used first unused matching interaction.
+// Requests without matching interaction are failed with ErrCassetteUnmatched.
+type ReplayTransport struct {
+	lock         sync.Mutex
+	interactions []CassetteInteraction
+	used         []bool
+}
+
+// NewReplayTransport creates ReplayTransport for cassette.
+func NewReplayTransport(cassette *Cassette) *ReplayTransport {
+	return &ReplayTransport{
+		interactions: cassette.Interactions,
+		used:         make([]bool, len(cassette.Interactions)),
+	}
+}
+
+// Unused returns interactions which was not requested yet.
+func (t *ReplayTransport) Unused() []CassetteInteraction {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var result []CassetteInteraction
+
+	for i, interaction := range t.interactions {
+		if !t.used[i] {
+			result = append(result, interaction)
+		}
+	}
+
+	return result
+}
+
+func (t *ReplayTransport) take(request CassetteInteraction) (CassetteInteraction, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for i, interaction := range t.interactions {
+		if !t.used[i] && interaction.matches(request) {
+			t.used[i] = true
+			return interaction, nil
+		}
+	}
+
+	return CassetteInteraction{}, errors.Wrap(ErrCassetteUnmatched, request.String())
+}
+
+// Execute returns recorded response for request.
+func (t *ReplayTransport) Execute(ctx context.Context, r *Request) (*Response, error) {
+	request, _, err := requestInteraction(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "replay request")
+	}
+
+	interaction, err := t.take(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if interaction.Error != "" {
+		return nil, errors.New(interaction.Error)
+	}
+
+	if interaction.Response == nil {
+		return nil, errors.Errorf("cassette interaction %s has no response", interaction)
+	}
+
+	res := *interaction.Response
+	res.Method = interaction.Method
+	res.StatusCode = interaction.StatusCode
+
+	return &res, nil
+}
+
+// Download returns recorded content of file.
+func (t *ReplayTransport) Download(ctx context.Context, token string, path string) (io.ReadCloser, error) {
+	return t.DownloadRange(ctx, token, path, 0)
+}
+
+// DownloadRange returns recorded content of file part.
+func (t *ReplayTransport) DownloadRange(ctx context.Context, token string, path string, offset int64) (io.ReadCloser, error) {
+	interaction, err := t.take(CassetteInteraction{
+		Download: true,
+		Path:     path,
+		Offset:   offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if interaction.Error != "" {
+		return nil, errors.New(interaction.Error)
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(interaction.Body)), nil
+}
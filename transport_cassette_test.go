@@ -0,0 +1,210 @@
+package tg
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const cassetteTestToken = "1234:secret"
+
+func newCassetteTestTransport() *TransportMock {
+	return &TransportMock{
+		ExecuteFunc: func(ctx context.Context, r *Request) (*Response, error) {
+			switch r.Method() {
+			case "sendDocument":
+				content, err := ioutil.ReadAll(extractFiles(r)["document"].Body)
+				if err != nil {
+					return nil, err
+				}
+
+				return &Response{
+					OK:         true,
+					StatusCode: 200,
+					Result:     json.RawMessage(`"` + string(content) + `"`),
+				}, nil
+			case "getChat":
+				return &Response{
+					OK:          false,
+					StatusCode:  400,
+					ErrorCode:   400,
+					Description: "Bad Request: chat not found",
+				}, nil
+			default:
+				return nil, errors.New("Post https://api.telegram.org/bot" + r.Token() + "/" + r.Method() + ": timeout")
+			}
+		},
+		DownloadFunc: func(ctx context.Context, token string, path string) (io.ReadCloser, error) {
+			return ioutil.NopCloser(strings.NewReader("content of " + path)), nil
+		},
+	}
+}
+
+func sendCassetteDocument(client *Client, content string, dst interface{}) error {
+	return client.Invoke(context.Background(),
+		NewRequest("sendDocument").
+			AddChatID(ChatID(1)).
+			AddFile("document", NewInputFileBytes("test.txt", []byte(content))),
+		dst,
+	)
+}
+
+func recordCassette(t *testing.T) *Cassette {
+	t.Helper()
+
+	recorder := NewRecordingTransport(newCassetteTestTransport())
+	client := NewClient(cassetteTestToken, WithTransport(recorder))
+	ctx := context.Background()
+
+	var result string
+	err := sendCassetteDocument(client, "hello", &result)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", result)
+
+	_, err = client.GetChat(ctx, ChatID(2))
+	assert.EqualError(t, err, "Bad Request: chat not found")
+
+	_, err = client.GetMe(ctx)
+	assert.EqualError(t, err, "Post https://api.telegram.org/bot1234:secret/getMe: timeout")
+
+	body, err := client.DownloadFile(ctx, "photos/1.jpg")
+	require.NoError(t, err)
+	content, err := ioutil.ReadAll(body)
+	require.NoError(t, err)
+	assert.Equal(t, "content of photos/1.jpg", string(content))
+
+	return recorder.Cassette()
+}
+
+func TestRecordingTransport(t *testing.T) {
+	cassette := recordCassette(t)
+
+	require.Len(t, cassette.Interactions, 4)
+
+	assert.Equal(t, CassetteInteraction{
+		Method: "sendDocument",
+		Args:   map[string]string{"chat_id": "1"},
+		Files: map[string]CassetteFile{
+			"document": {
+				Name:   "test.txt",
+				SHA256: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+			},
+		},
+		StatusCode: 200,
+		Response: &Response{
+			OK:     true,
+			Result: json.RawMessage(`"hello"`),
+		},
+	}, cassette.Interactions[0])
+
+	assert.Equal(t, 400, cassette.Interactions[1].StatusCode)
+	assert.Equal(t, "Post https://api.telegram.org/bot<TOKEN>/getMe: timeout", cassette.Interactions[2].Error)
+	assert.Equal(t, CassetteInteraction{
+		Download: true,
+		Path:     "photos/1.jpg",
+		Body:     []byte("content of photos/1.jpg"),
+	}, cassette.Interactions[3])
+
+	dir, err := ioutil.TempDir("", "cassette")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "nested", "cassette.json")
+
+	require.NoError(t, cassette.Save(path))
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), cassetteTestToken)
+
+	loaded, err := LoadCassette(path)
+	require.NoError(t, err)
+	assert.Equal(t, cassette, loaded)
+}
+
+func TestReplayTransport(t *testing.T) {
+	cassette := recordCassette(t)
+
+	replay := NewReplayTransport(cassette)
+	client := NewClient(cassetteTestToken, WithTransport(replay))
+	ctx := context.Background()
+
+	// order of calls does not matter
+	body, err := client.DownloadFile(ctx, "photos/1.jpg")
+	require.NoError(t, err)
+	content, err := ioutil.ReadAll(body)
+	require.NoError(t, err)
+	assert.Equal(t, "content of photos/1.jpg", string(content))
+
+	var result string
+	err = sendCassetteDocument(client, "hello", &result)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", result)
+
+	_, err = client.GetChat(ctx, ChatID(2))
+	assert.EqualError(t, err, "Bad Request: chat not found")
+
+	// interaction is served once
+	_, err = client.GetChat(ctx, ChatID(2))
+	assert.Equal(t, ErrCassetteUnmatched, errors.Cause(err))
+	assert.EqualError(t, err, "getChat(chat_id=2): cassette does not contain matching interaction")
+
+	// file content is part of request identity
+	err = sendCassetteDocument(client, "world", nil)
+	assert.Equal(t, ErrCassetteUnmatched, errors.Cause(err))
+
+	unused := replay.Unused()
+	require.Len(t, unused, 1)
+	assert.Equal(t, "getMe()", unused[0].String())
+
+	_, err = client.GetMe(ctx)
+	assert.EqualError(t, err, "Post https://api.telegram.org/bot<TOKEN>/getMe: timeout")
+
+	assert.Empty(t, replay.Unused())
+}
+
+func TestCassette_DownloadRange(t *testing.T) {
+	ctx := context.Background()
+
+	transport := &rangeTransportMock{Content: "content"}
+	recorder := NewRecordingTransport(transport)
+
+	body, err := recorder.DownloadRange(ctx, cassetteTestToken, "photos/1.jpg", 3)
+	require.NoError(t, err)
+	body.Close()
+
+	assert.Equal(t, []int64{3}, transport.Offsets, "range is downloaded by wrapped transport")
+
+	cassette := recorder.Cassette()
+	assert.Equal(t, []CassetteInteraction{
+		{
+			Download: true,
+			Path:     "photos/1.jpg",
+			Offset:   3,
+			Body:     []byte("tent"),
+		},
+	}, cassette.Interactions)
+
+	replay := NewReplayTransport(cassette)
+
+	// offset is part of request identity
+	_, err = replay.Download(ctx, cassetteTestToken, "photos/1.jpg")
+	assert.Equal(t, ErrCassetteUnmatched, errors.Cause(err))
+	assert.EqualError(t, err, "download photos/1.jpg: cassette does not contain matching interaction")
+
+	body, err = replay.DownloadRange(ctx, cassetteTestToken, "photos/1.jpg", 3)
+	require.NoError(t, err)
+
+	content, err := ioutil.ReadAll(body)
+	require.NoError(t, err)
+	assert.Equal(t, "tent", string(content))
+}
@@ -51,13 +51,71 @@ func (enc *MultipartEncoder) AddFile(k string, file InputFile) error {
 		return errors.Wrapf(err, "create form file '%s'", k)
 	}
 
-	if _, err := io.Copy(writer, file.Body); err != nil {
+	var body io.Reader = file.Body
+
+	if file.Progress != nil {
+		body = &progressReader{
+			Reader:   body,
+			total:    file.Size,
+			progress: file.Progress,
+		}
+	}
+
+	if _, err := io.Copy(writer, body); err != nil {
 		return errors.Wrapf(err, "copy to form file '%s'", k)
 	}
 
 	return nil
 }
 
+// ContentLength returns size of encoded request r in bytes,
+// if all files of request have size, otherwise returns -1.
+// Files are not read.
+func (enc *MultipartEncoder) ContentLength(r *Request) int64 {
+	counter := &countingWriter{}
+
+	sized := &MultipartEncoder{w: multipart.NewWriter(counter)}
+	if err := sized.w.SetBoundary(enc.w.Boundary()); err != nil {
+		return -1
+	}
+
+	var files int64
+
+	for k, file := range r.files {
+		if file.Size <= 0 {
+			return -1
+		}
+
+		files += file.Size
+
+		if _, err := sized.w.CreateFormFile(k, file.Name); err != nil {
+			return -1
+		}
+	}
+
+	for k, v := range r.args {
+		if err := sized.AddString(k, v); err != nil {
+			return -1
+		}
+	}
+
+	if err := sized.Close(); err != nil {
+		return -1
+	}
+
+	return counter.n + files
+}
+
+// countingWriter discards written data and counts its size.
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
 // ContentType returns HTTP request content type.
 func (enc *MultipartEncoder) ContentType() string {
 	return enc.w.FormDataContentType()
@@ -127,15 +185,25 @@ type HTTPTransport struct {
 	buildDownloadURL func(token string, path string) string
 }
 
+// DefaultServerURL is URL of cloud Bot API server.
+const DefaultServerURL = "https://api.telegram.org"
+
 var (
-	defaultBuildCallURL = func(token, method string) string {
-		return fmt.Sprintf("https://api.telegram.org/bot%s/%s", token, method)
+	defaultBuildCallURL = newBuildCallURL(DefaultServerURL)
+	defaultBuildFileURL = newBuildFileURL(DefaultServerURL)
+)
+
+func newBuildCallURL(server string) func(token, method string) string {
+	return func(token, method string) string {
+		return fmt.Sprintf("%s/bot%s/%s", server, token, method)
 	}
+}
 
-	defaultBuildFileURL = func(token, path string) string {
-		return fmt.Sprintf("https://api.telegram.org/file/bot%s/%s", token, path)
+func newBuildFileURL(server string) func(token, path string) string {
+	return func(token, path string) string {
+		return fmt.Sprintf("%s/file/bot%s/%s", server, token, path)
 	}
-)
+}
 
 // HTTPTransportOption use this for configure transport.
 type HTTPTransportOption func(t *HTTPTransport)
@@ -161,6 +229,17 @@ func WithHTTPBuildFileURLFunc(f func(token, path string) string) HTTPTransportOp
 	}
 }
 
+// WithHTTPServerURL sets URL of Bot API server used to build API call and download URLs,
+// e.g. URL of self-hosted telegram-bot-api server.
+func WithHTTPServerURL(server string) HTTPTransportOption {
+	server = strings.TrimSuffix(server, "/")
+
+	return func(t *HTTPTransport) {
+		t.buildCallURL = newBuildCallURL(server)
+		t.buildDownloadURL = newBuildFileURL(server)
+	}
+}
+
 // NewHTTPTransport creates HTTPTransport with default configuration.
 func NewHTTPTransport(opts ...HTTPTransportOption) *HTTPTransport {
 	t := &HTTPTransport{
@@ -200,7 +279,16 @@ func (t *HTTPTransport) Execute(ctx context.Context, r *Request) (*Response, err
 	)
 }
 
+// Download file by path.
+// Returns *DownloadError if server responds with non-2xx status.
 func (t *HTTPTransport) Download(ctx context.Context, token string, path string) (io.ReadCloser, error) {
+	return t.DownloadRange(ctx, token, path, 0)
+}
+
+// DownloadRange downloads file content starting from offset byte using Range header.
+// If server ignores Range header, first offset bytes of content are skipped.
+// Returns *DownloadError if server responds with non-2xx status.
+func (t *HTTPTransport) DownloadRange(ctx context.Context, token string, path string, offset int64) (io.ReadCloser, error) {
 	url := t.buildDownloadURL(token, path)
 
 	req, err := http.NewRequest(http.MethodGet, url, nil)
@@ -210,12 +298,77 @@ func (t *HTTPTransport) Download(ctx context.Context, token string, path string)
 
 	req = req.WithContext(ctx)
 
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
 	res, err := t.doer.Do(req)
 	if err != nil {
 		return nil, errors.Wrap(err, "do request")
 	}
 
-	return res.Body, nil
+	switch {
+	case res.StatusCode == http.StatusPartialContent && offset > 0:
+		return res.Body, nil
+	case res.StatusCode >= 200 && res.StatusCode < 300:
+		if offset > 0 {
+			return skipContent(res.Body, offset)
+		}
+
+		return res.Body, nil
+	default:
+		defer res.Body.Close()
+		return nil, newDownloadError(path, res)
+	}
+}
+
+// skipContent discards first n bytes of body.
+func skipContent(body io.ReadCloser, n int64) (io.ReadCloser, error) {
+	if _, err := io.CopyN(ioutil.Discard, body, n); err != nil {
+		body.Close()
+
+		if err == io.EOF {
+			return nil, errors.Errorf("content is shorter than offset %d", n)
+		}
+
+		return nil, errors.Wrap(err, "skip content")
+	}
+
+	return body, nil
+}
+
+// DownloadError returned by HTTPTransport.Download if server responds with non-2xx status.
+type DownloadError struct {
+	// Path of downloaded file.
+	Path string
+
+	// HTTP status code of response.
+	StatusCode int
+
+	// Description of error returned by server.
+	Description string
+}
+
+func newDownloadError(path string, res *http.Response) *DownloadError {
+	err := &DownloadError{
+		Path:        path,
+		StatusCode:  res.StatusCode,
+		Description: http.StatusText(res.StatusCode),
+	}
+
+	// Bot API returns JSON response on errors, other servers can return anything
+	body, _ := ioutil.ReadAll(io.LimitReader(res.Body, 4096))
+
+	apiResponse := &Response{}
+	if json.Unmarshal(body, apiResponse) == nil && apiResponse.Description != "" {
+		err.Description = apiResponse.Description
+	}
+
+	return err
+}
+
+func (err *DownloadError) Error() string {
+	return fmt.Sprintf("download '%s': status %d: %s", err.Path, err.StatusCode, err.Description)
 }
 
 func (t *HTTPTransport) executeSimple(
@@ -301,52 +454,80 @@ func (t *HTTPTransport) buildHTTPRequest(
 	return req, nil
 }
 
+// executeStreaming sends request with body encoded on the fly through the pipe.
+//
+// Encoder goroutine always terminates: encode error closes the pipe,
+// so HTTP request is aborted; pipe is closed after HTTP request is completed,
+// failed or its context is canceled, so encoder blocked on write gets error.
 func (t *HTTPTransport) executeStreaming(
 	ctx context.Context,
 	newEncoder func(io.Writer) HTTPEncoder,
 	r *Request,
 ) (*Response, error) {
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
-
 	pr, pw := io.Pipe()
 
 	encoder := newEncoder(pw)
 
-	resChan := make(chan *Response)
-	errChan := make(chan error)
+	// buffered, so upload goroutine never blocks on send
+	encodeErrChan := make(chan error, 1)
 
 	// upload
 	go func() {
-		defer pw.Close()
-		defer encoder.Close()
-
-		if err := r.Encode(encoder); err != nil {
-			errChan <- err
+		err := r.Encode(encoder)
+		if err == nil {
+			err = encoder.Close()
 		}
+
+		// error is sent before pipe closing,
+		// so it's available when HTTP request is aborted by it
+		encodeErrChan <- err
+
+		// nil error means EOF for reader
+		pw.CloseWithError(err)
 	}()
 
-	// send
+	// unblock upload if request is not completed reading of body
+	defer pr.Close()
+
+	// HTTP client waits body writing before returning on context cancel,
+	// so abort body reading to not wait upload of slow file.
+	done := make(chan struct{})
+	defer close(done)
+
 	go func() {
-		req, err := t.buildHTTPRequest(r, pr, encoder.ContentType())
-		if err != nil {
-			errChan <- errors.Wrap(err, "build http request")
-			return
+		select {
+		case <-ctx.Done():
+			pr.CloseWithError(ctx.Err())
+		case <-done:
 		}
+	}()
+
+	req, err := t.buildHTTPRequest(r, pr, encoder.ContentType())
+	if err != nil {
+		return nil, errors.Wrap(err, "build http request")
+	}
 
-		res, err := t.executeHTTPRequest(ctx, req)
-		if err != nil {
-			errChan <- errors.Wrap(err, "execute http request")
-			return
+	if sized, ok := encoder.(interface{ ContentLength(*Request) int64 }); ok {
+		if length := sized.ContentLength(r); length >= 0 {
+			req.ContentLength = length
 		}
+	}
 
-		resChan <- res
-	}()
+	res, err := t.executeHTTPRequest(ctx, req)
+	if err != nil {
+		// encode error is the cause of aborted request,
+		// don't wait upload if request is failed by other reason.
+		// closed pipe and context errors are caused by request failure itself.
+		select {
+		case encodeErr := <-encodeErrChan:
+			if cause := errors.Cause(encodeErr); cause != nil && cause != io.ErrClosedPipe && cause != ctx.Err() {
+				return nil, errors.Wrap(encodeErr, "encode")
+			}
+		default:
+		}
 
-	select {
-	case err := <-errChan:
-		return nil, err
-	case res := <-resChan:
-		return res, nil
+		return nil, errors.Wrap(err, "execute http request")
 	}
+
+	return res, nil
 }
@@ -9,9 +9,12 @@ import (
 	"mime"
 	"mime/multipart"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
@@ -147,6 +150,22 @@ func TestHTTPTransport_New(t *testing.T) {
 	)
 }
 
+func TestWithHTTPServerURL(t *testing.T) {
+	transport := NewHTTPTransport(
+		WithHTTPServerURL("http://localhost:8081/"),
+	)
+
+	assert.Equal(t,
+		"http://localhost:8081/bottest/getMe",
+		transport.buildCallURL("test", "getMe"),
+	)
+
+	assert.Equal(t,
+		"http://localhost:8081/file/bottest/photo.png",
+		transport.buildDownloadURL("test", "photo.png"),
+	)
+}
+
 func TestHTTPTransport_Download(t *testing.T) {
 	ctx := context.Background()
 
@@ -199,6 +218,105 @@ func TestHTTPTransport_Download(t *testing.T) {
 		assert.Nil(t, result)
 		assert.EqualError(t, errors.Cause(err), "do test error")
 	})
+
+	t.Run("StatusError", func(t *testing.T) {
+		body := &closeTracker{Reader: strings.NewReader(`{"ok":false,"error_code":404,"description":"Not Found"}`)}
+
+		trans := NewHTTPTransport(
+			WithHTTPDoer(&HTTPDoerMock{
+				DoFunc: func(r *http.Request) (*http.Response, error) {
+					return &http.Response{
+						StatusCode: http.StatusNotFound,
+						Body:       body,
+					}, nil
+				},
+			}),
+		)
+
+		result, err := trans.Download(ctx, "123:secret", "image.png")
+		assert.Nil(t, result)
+		assert.True(t, body.closed, "body should be closed")
+
+		downloadErr, ok := err.(*DownloadError)
+		require.True(t, ok, "error should be *DownloadError")
+		assert.Equal(t, &DownloadError{
+			Path:        "image.png",
+			StatusCode:  http.StatusNotFound,
+			Description: "Not Found",
+		}, downloadErr)
+		assert.EqualError(t, err, "download 'image.png': status 404: Not Found")
+	})
+
+	t.Run("StatusErrorNotJSON", func(t *testing.T) {
+		trans := NewHTTPTransport(
+			WithHTTPDoer(&HTTPDoerMock{
+				DoFunc: func(r *http.Request) (*http.Response, error) {
+					return &http.Response{
+						StatusCode: http.StatusBadGateway,
+						Body:       ioutil.NopCloser(strings.NewReader("<html>Bad Gateway</html>")),
+					}, nil
+				},
+			}),
+		)
+
+		_, err := trans.Download(ctx, "123:secret", "image.png")
+		assert.EqualError(t, err, "download 'image.png': status 502: Bad Gateway")
+	})
+
+	t.Run("Range", func(t *testing.T) {
+		for _, test := range []struct {
+			Name       string
+			StatusCode int
+			Body       string
+			Want       string
+			Err        string
+		}{
+			{Name: "PartialContent", StatusCode: http.StatusPartialContent, Body: "test...", Want: "test..."},
+			{Name: "RangeIgnored", StatusCode: http.StatusOK, Body: "test, test...", Want: "test..."},
+			{Name: "ShortContent", StatusCode: http.StatusOK, Body: "test", Err: "content is shorter than offset 6"},
+			{Name: "NotSatisfiable", StatusCode: http.StatusRequestedRangeNotSatisfiable, Err: "download 'image.png': status 416: Requested Range Not Satisfiable"},
+		} {
+			test := test
+
+			t.Run(test.Name, func(t *testing.T) {
+				trans := NewHTTPTransport(
+					WithHTTPDoer(&HTTPDoerMock{
+						DoFunc: func(r *http.Request) (*http.Response, error) {
+							assert.Equal(t, "bytes=6-", r.Header.Get("Range"))
+
+							return &http.Response{
+								StatusCode: test.StatusCode,
+								Body:       ioutil.NopCloser(strings.NewReader(test.Body)),
+							}, nil
+						},
+					}),
+				)
+
+				result, err := trans.DownloadRange(ctx, "123:secret", "image.png", 6)
+				if test.Err != "" {
+					assert.EqualError(t, err, test.Err)
+					return
+				}
+
+				require.NoError(t, err)
+				defer result.Close()
+
+				content, err := ioutil.ReadAll(result)
+				require.NoError(t, err)
+				assert.Equal(t, test.Want, string(content))
+			})
+		}
+	})
+}
+
+type closeTracker struct {
+	io.Reader
+	closed bool
+}
+
+func (tracker *closeTracker) Close() error {
+	tracker.closed = true
+	return nil
 }
 
 func TestHTTPTransport_Execute(t *testing.T) {
@@ -340,3 +458,223 @@ func TestHTTPTransport_Execute(t *testing.T) {
 		})
 	})
 }
+
+func TestHTTPTransport_Execute_Upload(t *testing.T) {
+	ctx := context.Background()
+
+	var lengths []int64
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		if r.ContentLength >= 0 {
+			assert.Equal(t, int64(len(body)), r.ContentLength, "declared length equal to actual")
+		}
+		lengths = append(lengths, r.ContentLength)
+
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		assert.NoError(t, r.ParseMultipartForm(1024))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok": true, "result": true}`))
+	}))
+	defer server.Close()
+
+	transport := NewHTTPTransport(
+		WithHTTPBuildCallURLFunc(func(token, method string) string {
+			return server.URL + "/bot" + token + "/" + method
+		}),
+	)
+
+	var progress []int64
+
+	document := NewInputFileBytes("test.txt", []byte("test, test, test")).
+		WithProgress(func(sent, total int64) {
+			assert.Equal(t, int64(16), total)
+			progress = append(progress, sent)
+		})
+
+	request := NewRequest("sendDocument").
+		WithToken("12345:secret").
+		AddString("chat_id", "@channely_updates").
+		AddFile("document", document).
+		AddFile("thumb", NewInputFileBytes("thumb.jpg", []byte("jpeg")))
+
+	_, err := transport.Execute(ctx, request)
+	require.NoError(t, err)
+
+	require.True(t, request.IsReplayable())
+	require.NoError(t, request.Rewind())
+
+	_, err = transport.Execute(ctx, request)
+	require.NoError(t, err)
+
+	assert.Equal(t, []int64{16, 16}, progress)
+	require.Len(t, lengths, 2)
+	assert.True(t, lengths[0] > 0)
+	assert.Equal(t, lengths[0], lengths[1])
+
+	// size is unknown, so content length too
+	request = NewRequest("sendDocument").
+		WithToken("12345:secret").
+		AddFile("document", NewInputFile("test.txt", strings.NewReader("test")))
+
+	assert.False(t, request.IsReplayable())
+	assert.Equal(t, ErrInputFileNotReopenable, request.Rewind())
+
+	_, err = transport.Execute(ctx, request)
+	require.NoError(t, err)
+
+	require.Len(t, lengths, 3)
+	assert.Equal(t, int64(-1), lengths[2])
+}
+
+// checkStreamingLeaks fails test if goroutines of HTTPTransport are still running after test.
+// Usage: defer checkStreamingLeaks(t)
+func checkStreamingLeaks(t *testing.T) {
+	t.Helper()
+
+	var stacks string
+
+	for i := 0; i < 100; i++ {
+		buf := make([]byte, 1<<20)
+		stacks = string(buf[:runtime.Stack(buf, true)])
+
+		leaked := false
+		for _, stack := range strings.Split(stacks, "\n\n") {
+			if strings.Contains(stack, "(*HTTPTransport).executeStreaming") {
+				leaked = true
+			}
+		}
+
+		if !leaked {
+			return
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	t.Errorf("streaming goroutines leaked:\n%s", stacks)
+}
+
+type failingReader struct {
+	err error
+}
+
+func (r failingReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
+
+func TestHTTPTransport_ExecuteStreaming_Leaks(t *testing.T) {
+	newRequest := func(body io.Reader) *Request {
+		return NewRequest("sendDocument").
+			WithToken("12345:secret").
+			AddString("chat_id", "1").
+			AddFile("document", NewInputFile("test.txt", body))
+	}
+
+	okHandler := func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(ioutil.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok": true, "result": true}`))
+	}
+
+	newTransport := func(url string) *HTTPTransport {
+		return NewHTTPTransport(
+			WithHTTPBuildCallURLFunc(func(token, method string) string {
+				return url + "/bot" + token + "/" + method
+			}),
+		)
+	}
+
+	t.Run("EncodeError", func(t *testing.T) {
+		defer checkStreamingLeaks(t)
+
+		server := httptest.NewServer(http.HandlerFunc(okHandler))
+		defer server.Close()
+
+		readErr := errors.New("read test error")
+
+		res, err := newTransport(server.URL).Execute(context.Background(), newRequest(failingReader{readErr}))
+		assert.Nil(t, res)
+		assert.Equal(t, readErr, errors.Cause(err))
+	})
+
+	t.Run("DoErrorWithoutReadingBody", func(t *testing.T) {
+		defer checkStreamingLeaks(t)
+
+		doer := &HTTPDoerMock{
+			DoFunc: func(r *http.Request) (*http.Response, error) {
+				return nil, errors.New("do test error")
+			},
+		}
+
+		res, err := NewHTTPTransport(WithHTTPDoer(doer)).
+			Execute(context.Background(), newRequest(strings.NewReader("test")))
+		assert.Nil(t, res)
+		assert.EqualError(t, errors.Cause(err), "do test error")
+	})
+
+	t.Run("ResponseWithoutReadingBody", func(t *testing.T) {
+		defer checkStreamingLeaks(t)
+
+		doer := &HTTPDoerMock{
+			DoFunc: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusRequestEntityTooLarge,
+					Body: ioutil.NopCloser(strings.NewReader(
+						`{"ok": false, "error_code": 413, "description": "Request Entity Too Large"}`,
+					)),
+				}, nil
+			},
+		}
+
+		res, err := NewHTTPTransport(WithHTTPDoer(doer)).
+			Execute(context.Background(), newRequest(bytes.NewReader(make([]byte, 1<<20))))
+		require.NoError(t, err)
+		assert.Equal(t, 413, res.ErrorCode)
+	})
+
+	t.Run("ContextCanceled", func(t *testing.T) {
+		defer checkStreamingLeaks(t)
+
+		block := make(chan struct{})
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-block
+		}))
+		defer server.Close()
+		defer close(block)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+		defer cancel()
+
+		// body reader never ends, so upload is in progress when context is canceled
+		pr, pw := io.Pipe()
+		defer pw.Close()
+
+		res, err := newTransport(server.URL).Execute(ctx, newRequest(pr))
+		assert.Nil(t, res)
+		assert.Error(t, err)
+	})
+
+	t.Run("BuildRequestError", func(t *testing.T) {
+		defer checkStreamingLeaks(t)
+
+		res, err := newTransport("://bad").Execute(context.Background(), newRequest(strings.NewReader("test")))
+		assert.Nil(t, res)
+		assert.Error(t, err)
+	})
+
+	t.Run("OK", func(t *testing.T) {
+		defer checkStreamingLeaks(t)
+
+		server := httptest.NewServer(http.HandlerFunc(okHandler))
+		defer server.Close()
+
+		res, err := newTransport(server.URL).Execute(context.Background(), newRequest(strings.NewReader("test")))
+		require.NoError(t, err)
+		assert.True(t, res.OK)
+	})
+}
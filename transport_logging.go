@@ -0,0 +1,336 @@
+package tg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LogRedacted replaces token and values of secret arguments in log entries.
+const LogRedacted = "<REDACTED>"
+
+// DefaultLogRedactedArgs contains names of arguments redacted by default.
+var DefaultLogRedactedArgs = []string{
+	"provider_token",
+}
+
+// Logger define interface of logger used by LoggingTransport.
+// Implement it to plug LoggingTransport into any logging library.
+type Logger interface {
+	Log(ctx context.Context, entry LogEntry)
+}
+
+// LoggerFunc is an adapter to use ordinary functions as Logger.
+type LoggerFunc func(ctx context.Context, entry LogEntry)
+
+// Log calls fn(ctx, entry).
+func (fn LoggerFunc) Log(ctx context.Context, entry LogEntry) {
+	fn(ctx, entry)
+}
+
+// NewStdLogger returns Logger writes entries to standard library logger.
+// If l is nil, log.Printf is used.
+func NewStdLogger(l *log.Logger) Logger {
+	printf := log.Printf
+	if l != nil {
+		printf = l.Printf
+	}
+
+	return LoggerFunc(func(ctx context.Context, entry LogEntry) {
+		printf("%s", entry)
+	})
+}
+
+// LogFile contains summary of file uploaded with request.
+type LogFile struct {
+	// Name of request argument.
+	Arg string
+
+	// Name of file.
+	Name string
+
+	// Size of file in bytes, -1 if size can't be detected without reading file.
+	Size int64
+}
+
+// LogEntry contains information about single Transport call.
+type LogEntry struct {
+	// Bot API method, empty for downloads.
+	Method string
+
+	// Arguments of request with secret values redacted.
+	Args map[string]string
+
+	// Files of request.
+	Files []LogFile
+
+	// Path of downloaded file, empty for API calls.
+	Path string
+
+	// Offset of downloaded file part, zero if whole file is downloaded.
+	Offset int64
+
+	// Duration of call.
+	Duration time.Duration
+
+	// HTTP status code of response.
+	StatusCode int
+
+	// True, if request is successful.
+	OK bool
+
+	// Error code and description returned by Bot API.
+	ErrorCode   int
+	Description string
+
+	// Raw result of response, only if dump is enabled.
+	Result json.RawMessage
+
+	// Transport error, token is redacted.
+	Err error
+}
+
+// String returns human readable representation of entry.
+func (entry LogEntry) String() string {
+	buf := &bytes.Buffer{}
+
+	if entry.Path != "" {
+		fmt.Fprintf(buf, "download %s", entry.Path)
+
+		if entry.Offset > 0 {
+			fmt.Fprintf(buf, " offset=%d", entry.Offset)
+		}
+	} else {
+		buf.WriteString(entry.Method)
+
+		keys := make([]string, 0, len(entry.Args))
+		for k := range entry.Args {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		items := make([]string, 0, len(keys)+len(entry.Files))
+
+		for _, k := range keys {
+			items = append(items, fmt.Sprintf("%s=%q", k, entry.Args[k]))
+		}
+
+		for _, file := range entry.Files {
+			if file.Size >= 0 {
+				items = append(items, fmt.Sprintf("%s=<%s, %d bytes>", file.Arg, file.Name, file.Size))
+			} else {
+				items = append(items, fmt.Sprintf("%s=<%s>", file.Arg, file.Name))
+			}
+		}
+
+		fmt.Fprintf(buf, "(%s)", strings.Join(items, ", "))
+	}
+
+	fmt.Fprintf(buf, " %s", entry.Duration)
+
+	switch {
+	case entry.Err != nil:
+		fmt.Fprintf(buf, " error: %v", entry.Err)
+	case entry.StatusCode != 0:
+		fmt.Fprintf(buf, " status=%d", entry.StatusCode)
+	}
+
+	if entry.ErrorCode != 0 || entry.Description != "" {
+		fmt.Fprintf(buf, " error_code=%d description=%q", entry.ErrorCode, entry.Description)
+	}
+
+	if entry.Result != nil {
+		fmt.Fprintf(buf, " result=%s", entry.Result)
+	}
+
+	return buf.String()
+}
+
+// redactedError wraps error with token removed from message.
+type redactedError struct {
+	err error
+	msg string
+}
+
+func (err *redactedError) Error() string {
+	return err.msg
+}
+
+// Cause returns original error, so errors.Cause works as expected.
+func (err *redactedError) Cause() error {
+	return err.err
+}
+
+func redactError(err error, token string) error {
+	if err == nil || token == "" || !strings.Contains(err.Error(), token) {
+		return err
+	}
+
+	return &redactedError{
+		err: err,
+		msg: strings.Replace(err.Error(), token, LogRedacted, -1),
+	}
+}
+
+// fileSize returns size of file, if it's known or can be detected without reading.
+func fileSize(file InputFile) int64 {
+	if file.Size > 0 {
+		return file.Size
+	}
+
+	switch body := file.Body.(type) {
+	case countingReader:
+		file.Body = body.Reader
+		return fileSize(file)
+	case interface{ Len() int }:
+		return int64(body.Len())
+	case *os.File:
+		info, err := body.Stat()
+		if err != nil {
+			return -1
+		}
+
+		offset, err := body.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+
+		return info.Size() - offset
+	default:
+		return -1
+	}
+}
+
+// LoggingTransport logs every call of wrapped Transport.
+type LoggingTransport struct {
+	next   Transport
+	logger Logger
+
+	redacted map[string]bool
+	dump     bool
+}
+
+// LoggingOption use this for configure LoggingTransport.
+type LoggingOption func(t *LoggingTransport)
+
+// WithLoggingRedactedArgs sets additional arguments which values should be redacted.
+func WithLoggingRedactedArgs(args ...string) LoggingOption {
+	return func(t *LoggingTransport) {
+		for _, arg := range args {
+			t.redacted[arg] = true
+		}
+	}
+}
+
+// WithLoggingDump enables logging of raw response result.
+// Be careful, result can contain sensitive data.
+func WithLoggingDump(enable bool) LoggingOption {
+	return func(t *LoggingTransport) {
+		t.dump = enable
+	}
+}
+
+// NewLoggingTransport creates LoggingTransport writes entries to logger.
+func NewLoggingTransport(next Transport, logger Logger, opts ...LoggingOption) *LoggingTransport {
+	t := &LoggingTransport{
+		next:     next,
+		logger:   logger,
+		redacted: make(map[string]bool, len(DefaultLogRedactedArgs)),
+	}
+
+	for _, arg := range DefaultLogRedactedArgs {
+		t.redacted[arg] = true
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+// Execute request using wrapped transport and log it.
+func (t *LoggingTransport) Execute(ctx context.Context, r *Request) (*Response, error) {
+	entry := LogEntry{
+		Method: r.method,
+		Args:   make(map[string]string, len(r.args)),
+	}
+
+	for k, v := range r.args {
+		if t.redacted[k] {
+			v = LogRedacted
+		}
+
+		entry.Args[k] = v
+	}
+
+	for k, file := range r.files {
+		entry.Files = append(entry.Files, LogFile{
+			Arg:  k,
+			Name: file.Name,
+			Size: fileSize(file),
+		})
+	}
+
+	sort.Slice(entry.Files, func(i, j int) bool {
+		return entry.Files[i].Arg < entry.Files[j].Arg
+	})
+
+	start := time.Now()
+
+	res, err := t.next.Execute(ctx, r)
+
+	entry.Duration = time.Since(start)
+
+	if err != nil {
+		entry.Err = redactError(err, r.token)
+	} else {
+		entry.StatusCode = res.StatusCode
+		entry.OK = res.OK
+		entry.ErrorCode = res.ErrorCode
+		entry.Description = res.Description
+
+		if t.dump {
+			entry.Result = res.Result
+		}
+	}
+
+	t.logger.Log(ctx, entry)
+
+	return res, err
+}
+
+// Download file using wrapped transport and log it.
+func (t *LoggingTransport) Download(ctx context.Context, token string, path string) (io.ReadCloser, error) {
+	return t.download(ctx, token, path, 0)
+}
+
+// DownloadRange downloads file from offset using wrapped transport and log it.
+func (t *LoggingTransport) DownloadRange(ctx context.Context, token string, path string, offset int64) (io.ReadCloser, error) {
+	return t.download(ctx, token, path, offset)
+}
+
+func (t *LoggingTransport) download(ctx context.Context, token string, path string, offset int64) (io.ReadCloser, error) {
+	start := time.Now()
+
+	body, err := downloadFromTransport(ctx, t.next, token, path, offset)
+
+	entry := LogEntry{
+		Path:     path,
+		Offset:   offset,
+		Duration: time.Since(start),
+		OK:       err == nil,
+		Err:      redactError(err, token),
+	}
+
+	t.logger.Log(ctx, entry)
+
+	return body, err
+}
@@ -0,0 +1,250 @@
+package tg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type logRecorder struct {
+	entries []LogEntry
+}
+
+func (recorder *logRecorder) Log(ctx context.Context, entry LogEntry) {
+	recorder.entries = append(recorder.entries, entry)
+}
+
+func TestLoggingTransport_Execute(t *testing.T) {
+	ctx := context.Background()
+
+	transportErr := errors.New("Post https://api.telegram.org/bot1234:secret/getMe: timeout")
+
+	transport := &TransportMock{
+		ExecuteFunc: func(ctx context.Context, r *Request) (*Response, error) {
+			switch r.Method() {
+			case "getMe":
+				return nil, transportErr
+			case "sendInvoice":
+				// wrapped transport receives original values
+				assert.Equal(t, "provider-secret", extractArgs(r)["provider_token"])
+
+				return &Response{
+					OK:          false,
+					StatusCode:  400,
+					ErrorCode:   400,
+					Description: "Bad Request: chat not found",
+				}, nil
+			default:
+				return &Response{
+					OK:         true,
+					StatusCode: 200,
+					Result:     json.RawMessage(`true`),
+				}, nil
+			}
+		},
+	}
+
+	recorder := &logRecorder{}
+
+	client := NewClient("1234:secret",
+		WithLogger(recorder, WithLoggingRedactedArgs("payload"), WithLoggingDump(true)),
+		WithTransport(transport),
+	)
+
+	_, err := client.GetMe(ctx)
+	assert.Equal(t, transportErr, err, "error is returned as is")
+
+	err = client.Invoke(ctx,
+		NewRequest("sendInvoice").
+			AddChatID(ChatID(1)).
+			AddString("provider_token", "provider-secret").
+			AddString("payload", "order-1"),
+		nil,
+	)
+	assert.EqualError(t, err, "Bad Request: chat not found")
+
+	err = client.Invoke(ctx,
+		NewRequest("sendDocument").
+			AddChatID(ChatID(1)).
+			AddFile("document", NewInputFileBytes("test.txt", []byte("hello"))).
+			AddFile("thumb", NewInputFile("thumb.jpg", ioutil.NopCloser(strings.NewReader("jpeg")))),
+		nil,
+	)
+	assert.NoError(t, err)
+
+	require.Len(t, recorder.entries, 3)
+
+	getMe := recorder.entries[0]
+	assert.Equal(t, "getMe", getMe.Method)
+	assert.EqualError(t, getMe.Err, "Post https://api.telegram.org/bot<REDACTED>/getMe: timeout")
+	assert.Equal(t, transportErr, errors.Cause(getMe.Err))
+
+	sendInvoice := recorder.entries[1]
+	assert.Equal(t, map[string]string{
+		"chat_id":        "1",
+		"provider_token": LogRedacted,
+		"payload":        LogRedacted,
+	}, sendInvoice.Args)
+	assert.False(t, sendInvoice.OK)
+	assert.Equal(t, 400, sendInvoice.StatusCode)
+	assert.Equal(t, 400, sendInvoice.ErrorCode)
+	assert.Equal(t, "Bad Request: chat not found", sendInvoice.Description)
+
+	sendDocument := recorder.entries[2]
+	assert.Equal(t, []LogFile{
+		{Arg: "document", Name: "test.txt", Size: 5},
+		{Arg: "thumb", Name: "thumb.jpg", Size: -1},
+	}, sendDocument.Files)
+	assert.True(t, sendDocument.OK)
+	assert.Equal(t, json.RawMessage(`true`), sendDocument.Result)
+}
+
+func TestLoggingTransport_Download(t *testing.T) {
+	ctx := context.Background()
+
+	transport := &TransportMock{
+		DownloadFunc: func(ctx context.Context, token string, path string) (io.ReadCloser, error) {
+			return ioutil.NopCloser(strings.NewReader("content")), nil
+		},
+	}
+
+	recorder := &logRecorder{}
+
+	client := NewClient("1234:secret", WithTransport(NewLoggingTransport(transport, recorder)))
+
+	body, err := client.DownloadFile(ctx, "photos/1.jpg")
+	require.NoError(t, err)
+	body.Close()
+
+	require.Len(t, recorder.entries, 1)
+	assert.Equal(t, "photos/1.jpg", recorder.entries[0].Path)
+	assert.True(t, recorder.entries[0].OK)
+}
+
+// rangeTransportMock is TransportMock with range download support.
+type rangeTransportMock struct {
+	TransportMock
+
+	// Content of each downloaded file.
+	Content string
+
+	// Offsets of DownloadRange calls.
+	Offsets []int64
+}
+
+func (mock *rangeTransportMock) DownloadRange(ctx context.Context, token string, path string, offset int64) (io.ReadCloser, error) {
+	mock.Offsets = append(mock.Offsets, offset)
+	return ioutil.NopCloser(strings.NewReader(mock.Content[offset:])), nil
+}
+
+func TestLoggingTransport_DownloadRange(t *testing.T) {
+	ctx := context.Background()
+
+	transport := &rangeTransportMock{Content: "content"}
+	recorder := &logRecorder{}
+
+	body, err := NewLoggingTransport(transport, recorder).DownloadRange(ctx, "1234:secret", "photos/1.jpg", 3)
+	require.NoError(t, err)
+	defer body.Close()
+
+	content, err := ioutil.ReadAll(body)
+	require.NoError(t, err)
+	assert.Equal(t, "tent", string(content))
+	assert.Equal(t, []int64{3}, transport.Offsets, "range is downloaded by wrapped transport")
+
+	require.Len(t, recorder.entries, 1)
+	assert.Equal(t, "photos/1.jpg", recorder.entries[0].Path)
+	assert.Equal(t, int64(3), recorder.entries[0].Offset)
+	assert.True(t, recorder.entries[0].OK)
+}
+
+func TestFileSize(t *testing.T) {
+	assert.Equal(t, int64(5), fileSize(NewInputFile("test.txt", strings.NewReader("hello"))))
+	assert.Equal(t, int64(-1), fileSize(NewInputFile("test.txt", ioutil.NopCloser(strings.NewReader("hello")))))
+
+	// body wrapped by observer
+	counted := new(int64)
+	file := NewInputFile("test.txt", countingReader{Reader: strings.NewReader("hello"), n: counted})
+	assert.Equal(t, int64(5), fileSize(file))
+}
+
+func TestLogEntry_String(t *testing.T) {
+	for _, test := range []struct {
+		Entry    LogEntry
+		Excepted string
+	}{
+		{
+			Entry: LogEntry{
+				Method: "sendDocument",
+				Args: map[string]string{
+					"chat_id": "1",
+					"caption": "hello world",
+				},
+				Files: []LogFile{
+					{Arg: "document", Name: "test.txt", Size: 5},
+					{Arg: "thumb", Name: "thumb.jpg", Size: -1},
+				},
+				Duration:   time.Millisecond * 15,
+				StatusCode: 200,
+				OK:         true,
+				Result:     json.RawMessage(`true`),
+			},
+			Excepted: `sendDocument(caption="hello world", chat_id="1", document=<test.txt, 5 bytes>, thumb=<thumb.jpg>) 15ms status=200 result=true`,
+		},
+		{
+			Entry: LogEntry{
+				Method:      "getChat",
+				Args:        map[string]string{"chat_id": "1"},
+				Duration:    time.Millisecond,
+				StatusCode:  400,
+				ErrorCode:   400,
+				Description: "Bad Request: chat not found",
+			},
+			Excepted: `getChat(chat_id="1") 1ms status=400 error_code=400 description="Bad Request: chat not found"`,
+		},
+		{
+			Entry: LogEntry{
+				Path:     "photos/1.jpg",
+				Duration: time.Second,
+				Err:      errors.New("timeout"),
+			},
+			Excepted: `download photos/1.jpg 1s error: timeout`,
+		},
+		{
+			Entry: LogEntry{
+				Path:     "photos/1.jpg",
+				Offset:   1024,
+				Duration: time.Second,
+				OK:       true,
+			},
+			Excepted: `download photos/1.jpg offset=1024 1s`,
+		},
+	} {
+		assert.Equal(t, test.Excepted, test.Entry.String())
+	}
+}
+
+func TestNewStdLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	logger := NewStdLogger(log.New(buf, "tg: ", 0))
+
+	logger.Log(context.Background(), LogEntry{
+		Method:     "getMe",
+		Duration:   time.Millisecond,
+		StatusCode: 200,
+		OK:         true,
+	})
+
+	assert.Equal(t, "tg: getMe() 1ms status=200\n", buf.String())
+}
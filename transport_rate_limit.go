@@ -0,0 +1,134 @@
+package tg
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// RateLimiter limits rate of calls.
+type RateLimiter interface {
+	// Wait blocks until call is allowed or ctx is done.
+	Wait(ctx context.Context) error
+}
+
+// TokenBucketLimiter is RateLimiter which allows calls with fixed rate and bursts up to bucket size.
+// It's safe for concurrent use, so one limiter can be shared by many clients.
+type TokenBucketLimiter struct {
+	interval time.Duration
+	burst    float64
+
+	lock   sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucketLimiter creates limiter which allows n calls per period
+// and bursts up to burst calls.
+//
+// Example (Telegram allows about 30 messages per second):
+//   limiter := NewTokenBucketLimiter(30, time.Second, 30)
+func NewTokenBucketLimiter(n int, per time.Duration, burst int) *TokenBucketLimiter {
+	if n < 1 {
+		n = 1
+	}
+
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &TokenBucketLimiter{
+		interval: per / time.Duration(n),
+		burst:    float64(burst),
+		tokens:   float64(burst),
+		last:     time.Now(),
+	}
+}
+
+// reserve takes token and returns time to wait until it is available.
+func (limiter *TokenBucketLimiter) reserve() time.Duration {
+	limiter.lock.Lock()
+	defer limiter.lock.Unlock()
+
+	now := time.Now()
+
+	if limiter.interval > 0 {
+		limiter.tokens += float64(now.Sub(limiter.last)) / float64(limiter.interval)
+	}
+
+	if limiter.tokens > limiter.burst {
+		limiter.tokens = limiter.burst
+	}
+
+	limiter.last = now
+	limiter.tokens--
+
+	if limiter.tokens >= 0 {
+		return 0
+	}
+
+	// negative tokens are reserved by waiting calls
+	return time.Duration(-limiter.tokens * float64(limiter.interval))
+}
+
+// cancel returns token taken by reserve.
+func (limiter *TokenBucketLimiter) cancel() {
+	limiter.lock.Lock()
+	defer limiter.lock.Unlock()
+
+	limiter.tokens++
+}
+
+// Wait blocks until call is allowed or ctx is done.
+func (limiter *TokenBucketLimiter) Wait(ctx context.Context) error {
+	delay := limiter.reserve()
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		limiter.cancel()
+		return ctx.Err()
+	}
+}
+
+// RateLimitTransport waits for RateLimiter before each Execute call of wrapped Transport.
+// Downloads are not limited.
+type RateLimitTransport struct {
+	next    Transport
+	limiter RateLimiter
+}
+
+// NewRateLimitTransport creates RateLimitTransport.
+func NewRateLimitTransport(next Transport, limiter RateLimiter) *RateLimitTransport {
+	return &RateLimitTransport{
+		next:    next,
+		limiter: limiter,
+	}
+}
+
+// Execute waits for limiter and executes request using wrapped transport.
+func (t *RateLimitTransport) Execute(ctx context.Context, r *Request) (*Response, error) {
+	if err := t.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	return t.next.Execute(ctx, r)
+}
+
+// Download file using wrapped transport.
+func (t *RateLimitTransport) Download(ctx context.Context, token string, path string) (io.ReadCloser, error) {
+	return t.next.Download(ctx, token, path)
+}
+
+// DownloadRange downloads file from offset using wrapped transport.
+func (t *RateLimitTransport) DownloadRange(ctx context.Context, token string, path string, offset int64) (io.ReadCloser, error) {
+	return downloadFromTransport(ctx, t.next, token, path, offset)
+}
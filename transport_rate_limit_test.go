@@ -0,0 +1,88 @@
+package tg
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucketLimiter(t *testing.T) {
+	t.Run("Burst", func(t *testing.T) {
+		limiter := NewTokenBucketLimiter(10, time.Second, 3)
+
+		start := time.Now()
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, limiter.Wait(context.Background()))
+		}
+
+		assert.True(t, time.Since(start) < time.Millisecond*50, "burst calls should not wait")
+
+		require.NoError(t, limiter.Wait(context.Background()))
+
+		assert.True(t, time.Since(start) >= time.Millisecond*90, "call after burst should wait interval")
+	})
+
+	t.Run("ContextDone", func(t *testing.T) {
+		limiter := NewTokenBucketLimiter(1, time.Hour, 1)
+
+		require.NoError(t, limiter.Wait(context.Background()))
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+		defer cancel()
+
+		assert.Equal(t, context.DeadlineExceeded, limiter.Wait(ctx))
+
+		// token of canceled call is returned
+		assert.InDelta(t, 0, limiter.tokens, 0.01)
+	})
+}
+
+type countingLimiter struct {
+	calls int64
+	err   error
+}
+
+func (limiter *countingLimiter) Wait(ctx context.Context) error {
+	atomic.AddInt64(&limiter.calls, 1)
+	return limiter.err
+}
+
+func TestRateLimitTransport(t *testing.T) {
+	ctx := context.Background()
+
+	limiter := &countingLimiter{}
+
+	transport := NewRateLimitTransport(&TransportMock{
+		ExecuteFunc: func(ctx context.Context, r *Request) (*Response, error) {
+			return &Response{OK: true}, nil
+		},
+		DownloadFunc: func(ctx context.Context, token string, path string) (io.ReadCloser, error) {
+			return ioutil.NopCloser(strings.NewReader("test, test")), nil
+		},
+	}, limiter)
+
+	_, err := transport.Execute(ctx, NewRequest("getMe"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), limiter.calls)
+
+	body, err := transport.DownloadRange(ctx, "1234:secret", "file.txt", 6)
+	require.NoError(t, err)
+
+	content, err := ioutil.ReadAll(body)
+	require.NoError(t, err)
+	assert.Equal(t, "test", string(content))
+	assert.Equal(t, int64(1), limiter.calls, "downloads are not limited")
+
+	limiter.err = context.Canceled
+
+	_, err = transport.Execute(ctx, NewRequest("getMe"))
+	assert.Equal(t, context.Canceled, err)
+}
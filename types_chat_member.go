@@ -0,0 +1,467 @@
+package tg
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ChatMemberStatus represents status of member in the chat.
+// Statuses unknown to this package are kept as is, see IsKnown.
+type ChatMemberStatus string
+
+const (
+	ChatMemberCreator       ChatMemberStatus = "creator"
+	ChatMemberAdministrator ChatMemberStatus = "administrator"
+	ChatMemberMember        ChatMemberStatus = "member"
+	ChatMemberRestricted    ChatMemberStatus = "restricted"
+	ChatMemberLeft          ChatMemberStatus = "left"
+	ChatMemberKicked        ChatMemberStatus = "kicked"
+)
+
+// ChatMemberStatuses list of all known values of ChatMemberStatus.
+var ChatMemberStatuses = []ChatMemberStatus{
+	ChatMemberCreator,
+	ChatMemberAdministrator,
+	ChatMemberMember,
+	ChatMemberRestricted,
+	ChatMemberLeft,
+	ChatMemberKicked,
+}
+
+// String returns name of chat member status.
+func (status ChatMemberStatus) String() string {
+	return string(status)
+}
+
+// IsKnown returns true if status is one of ChatMemberStatuses.
+// Unknown statuses can be added by new versions of Bot API.
+func (status ChatMemberStatus) IsKnown() bool {
+	for _, v := range ChatMemberStatuses {
+		if v == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+var (
+	errChatMemberStatusUnknown = errors.New("ChatMemberStatus unknown value")
+	errChatMemberStatusEmpty   = errors.New("ChatMemberStatus empty value")
+)
+
+// MarshalText returns name of status, unknown statuses are marshaled as is.
+func (status ChatMemberStatus) MarshalText() ([]byte, error) {
+	if status == "" {
+		return nil, errChatMemberStatusEmpty
+	}
+
+	return []byte(status), nil
+}
+
+// UnmarshalText parses status, unknown statuses are kept as is (see IsKnown).
+func (status *ChatMemberStatus) UnmarshalText(data []byte) error {
+	if len(data) == 0 {
+		return errChatMemberStatusEmpty
+	}
+
+	*status = ChatMemberStatus(data)
+
+	return nil
+}
+
+// ParseChatMemberStatus from string, returns error for unknown status.
+func ParseChatMemberStatus(v string) (ChatMemberStatus, error) {
+	status := ChatMemberStatus(v)
+
+	if !status.IsKnown() {
+		return status, errChatMemberStatusUnknown
+	}
+
+	return status, nil
+}
+
+// ChatPermission represents a single action that chat member can be allowed to do.
+type ChatPermission int8
+
+const (
+	// Administrators only permissions.
+	PermissionChangeInfo ChatPermission = iota + 1
+	PermissionPostMessages
+	PermissionEditMessages
+	PermissionDeleteMessages
+	PermissionInviteUsers
+	PermissionRestrictMembers
+	PermissionPinMessages
+	PermissionPromoteMembers
+
+	// Permissions which can be taken away by restrictions.
+	PermissionSendMessages
+	PermissionSendMediaMessages
+	PermissionSendOtherMessages
+	PermissionAddWebPagePreviews
+)
+
+// ChatPermissions list of all possible values of ChatPermission.
+var ChatPermissions = []ChatPermission{
+	PermissionChangeInfo,
+	PermissionPostMessages,
+	PermissionEditMessages,
+	PermissionDeleteMessages,
+	PermissionInviteUsers,
+	PermissionRestrictMembers,
+	PermissionPinMessages,
+	PermissionPromoteMembers,
+	PermissionSendMessages,
+	PermissionSendMediaMessages,
+	PermissionSendOtherMessages,
+	PermissionAddWebPagePreviews,
+}
+
+// String returns name of permission (same as ChatMember field in Bot API).
+func (perm ChatPermission) String() string {
+	switch perm {
+	case PermissionChangeInfo:
+		return "can_change_info"
+	case PermissionPostMessages:
+		return "can_post_messages"
+	case PermissionEditMessages:
+		return "can_edit_messages"
+	case PermissionDeleteMessages:
+		return "can_delete_messages"
+	case PermissionInviteUsers:
+		return "can_invite_users"
+	case PermissionRestrictMembers:
+		return "can_restrict_members"
+	case PermissionPinMessages:
+		return "can_pin_messages"
+	case PermissionPromoteMembers:
+		return "can_promote_members"
+	case PermissionSendMessages:
+		return "can_send_messages"
+	case PermissionSendMediaMessages:
+		return "can_send_media_messages"
+	case PermissionSendOtherMessages:
+		return "can_send_other_messages"
+	case PermissionAddWebPagePreviews:
+		return "can_add_web_page_previews"
+	default:
+		return ""
+	}
+}
+
+// IsAdministrative returns true if permission can be granted only to administrators.
+func (perm ChatPermission) IsAdministrative() bool {
+	return perm >= PermissionChangeInfo && perm <= PermissionPromoteMembers
+}
+
+// ChatMember contains information about one member of a chat.
+type ChatMember struct {
+	// Information about the user
+	User User `json:"user"`
+
+	// The member's status in the chat.
+	Status ChatMemberStatus `json:"status"`
+
+	// Optional. Restricted and kicked only.
+	// Date when restrictions will be lifted for this user, unix time.
+	UntilDate int64 `json:"until_date,omitempty"`
+
+	// Optional. Administrators only.
+	// True, if the bot is allowed to edit administrator privileges of that user.
+	CanBeEdited bool `json:"can_be_edited,omitempty"`
+
+	// Optional. Administrators only.
+	// True, if the administrator can change the chat title, photo and other settings.
+	CanChangeInfo bool `json:"can_change_info,omitempty"`
+
+	// Optional. Administrators only.
+	// True, if the administrator can post in the channel, channels only.
+	CanPostMessages bool `json:"can_post_messages,omitempty"`
+
+	// Optional. Administrators only.
+	// True, if the administrator can edit messages of other users
+	// and can pin messages, channels only.
+	CanEditMessages bool `json:"can_edit_messages,omitempty"`
+
+	// Optional. Administrators only.
+	// True, if the administrator can delete messages of other users
+	CanDeleteMessages bool `json:"can_delete_messages,omitempty"`
+
+	// Optional. Administrators only.
+	// True, if the administrator can invite new users to the chat.
+	CanInviteUsers bool `json:"can_invite_users,omitempty"`
+
+	// Optional. Administrators only.
+	// True, if the administrator can restrict, ban or unban chat members.
+	CanRestrictMembers bool `json:"can_restrict_members,omitempty"`
+
+	// Optional. Administrators only.
+	// True, if the administrator can pin messages, groups and supergroups only
+	CanPinMessages bool `json:"can_pin_messages,omitempty"`
+
+	// Optional. Administrators only.
+	// True, if the administrator can add new administrators with a subset
+	// of his own privileges or demote administrators that he has promoted,
+	// directly or indirectly (promoted by administrators that were appointed by the user).
+	CanPromoteMembers bool `json:"can_promote_members,omitempty"`
+
+	// Optional. Restricted only. True, if the user is a member of the chat at the moment of the request.
+	// Use IsChatMember method to check membership of member with any status.
+	IsMember bool `json:"is_member,omitempty"`
+
+	// Optional. Restricted only. True, if the user can send text messages, contacts, locations and venues
+	CanSendMessages bool `json:"can_send_messages,omitempty"`
+
+	// Optional. Restricted only.
+	// True, if the user can send audios, documents, photos, videos, video notes and voice notes,
+	// implies can_send_messages
+	CanSendMediaMessages bool `json:"can_send_media_messages,omitempty"`
+
+	// Optional. Restricted only. True, if the user can send animations, games, stickers and use inline bots, i
+	// implies can_send_media_messages
+	CanSendOtherMessages bool `json:"can_send_other_messages,omitempty"`
+
+	// Optional. Restricted only. True, if the user may add web page previews to his messages,
+	// implies can_send_media_messages
+	CanAddWebPagePreviews bool `json:"can_add_web_page_previews,omitempty"`
+}
+
+// ChatMemberSlice define a array of chat members
+type ChatMemberSlice []ChatMember
+
+// IsAdmin returns true if member is creator or administrator of the chat.
+func (member ChatMember) IsAdmin() bool {
+	return member.Status == ChatMemberCreator ||
+		member.Status == ChatMemberAdministrator
+}
+
+// IsChatMember returns true if user is a member of the chat at the moment.
+// Restricted users are members only if they have not left the chat.
+func (member ChatMember) IsChatMember() bool {
+	switch member.Status {
+	case ChatMemberCreator, ChatMemberAdministrator, ChatMemberMember:
+		return true
+	case ChatMemberRestricted:
+		return member.IsMember
+	default:
+		return false
+	}
+}
+
+// IsRestricted returns true if user has restrictions in the chat.
+func (member ChatMember) IsRestricted() bool {
+	return member.Status == ChatMemberRestricted
+}
+
+// IsBanned returns true if user was kicked from the chat and can't return.
+func (member ChatMember) IsBanned() bool {
+	return member.Status == ChatMemberKicked
+}
+
+// Until returns date when restrictions will be lifted for this user.
+// Returns zero time, if restrictions are forever or not applied.
+func (member ChatMember) Until() time.Time {
+	if member.UntilDate == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(member.UntilDate, 0)
+}
+
+// CanDo returns true if member is allowed to do action described by permission.
+func (member ChatMember) CanDo(perm ChatPermission) bool {
+	switch member.Status {
+	case ChatMemberCreator:
+		return true
+	case ChatMemberAdministrator:
+		if !perm.IsAdministrative() {
+			return true
+		}
+	case ChatMemberMember:
+		return !perm.IsAdministrative()
+	case ChatMemberRestricted:
+		if perm.IsAdministrative() {
+			return false
+		}
+	default:
+		return false
+	}
+
+	switch perm {
+	case PermissionChangeInfo:
+		return member.CanChangeInfo
+	case PermissionPostMessages:
+		return member.CanPostMessages
+	case PermissionEditMessages:
+		return member.CanEditMessages
+	case PermissionDeleteMessages:
+		return member.CanDeleteMessages
+	case PermissionInviteUsers:
+		return member.CanInviteUsers
+	case PermissionRestrictMembers:
+		return member.CanRestrictMembers
+	case PermissionPinMessages:
+		return member.CanPinMessages
+	case PermissionPromoteMembers:
+		return member.CanPromoteMembers
+	case PermissionSendMessages:
+		return member.CanSendMessages
+	case PermissionSendMediaMessages:
+		return member.CanSendMediaMessages
+	case PermissionSendOtherMessages:
+		return member.CanSendOtherMessages
+	case PermissionAddWebPagePreviews:
+		return member.CanAddWebPagePreviews
+	default:
+		return false
+	}
+}
+
+// RestrictOptions returns current member restrictions as options of RestrictChatMember.
+// Members without restrictions get all permissions set.
+func (member ChatMember) RestrictOptions() RestrictOptions {
+	return RestrictOptions{
+		Until:                  member.Until(),
+		CanSendMessages:        member.CanDo(PermissionSendMessages),
+		CanSendMediaMessages:   member.CanDo(PermissionSendMediaMessages),
+		CanSendOtherMessages:   member.CanDo(PermissionSendOtherMessages),
+		CanSendWebPagePreviews: member.CanDo(PermissionAddWebPagePreviews),
+	}
+}
+
+// ApplyTo returns state of member after applying restrictions.
+// If all permissions are granted, restrictions are lifted and user becomes a regular member.
+// Administrators and creator can't be restricted, so they are returned as is.
+func (opts RestrictOptions) ApplyTo(member ChatMember) ChatMember {
+	if member.IsAdmin() {
+		return member
+	}
+
+	result := ChatMember{User: member.User}
+
+	if opts.CanSendMessages && opts.CanSendMediaMessages &&
+		opts.CanSendOtherMessages && opts.CanSendWebPagePreviews {
+		if member.IsChatMember() {
+			result.Status = ChatMemberMember
+		} else {
+			result.Status = ChatMemberLeft
+		}
+
+		return result
+	}
+
+	result.Status = ChatMemberRestricted
+	result.IsMember = member.IsChatMember()
+	result.CanSendMessages = opts.CanSendMessages
+	result.CanSendMediaMessages = opts.CanSendMediaMessages
+	result.CanSendOtherMessages = opts.CanSendOtherMessages
+	result.CanAddWebPagePreviews = opts.CanSendWebPagePreviews
+
+	if !opts.Until.IsZero() {
+		result.UntilDate = opts.Until.Unix()
+	}
+
+	return result
+}
+
+// ChatPermissionChange describes change of single member permission.
+type ChatPermissionChange struct {
+	Permission ChatPermission
+	Old        bool
+	New        bool
+}
+
+func (change ChatPermissionChange) String() string {
+	return fmt.Sprintf("%s: %t -> %t", change.Permission, change.Old, change.New)
+}
+
+// ChatMemberDiff describes what changed between two states of the same chat member.
+// Useful for audit logs.
+type ChatMemberDiff struct {
+	// Old and new status of member, equal if status was not changed.
+	OldStatus ChatMemberStatus
+	NewStatus ChatMemberStatus
+
+	// Old and new date of restrictions lifting.
+	OldUntil time.Time
+	NewUntil time.Time
+
+	// Changed permissions.
+	Permissions []ChatPermissionChange
+}
+
+// DiffChatMember computes what changed between old and new state of member.
+func DiffChatMember(old, new ChatMember) ChatMemberDiff {
+	diff := ChatMemberDiff{
+		OldStatus: old.Status,
+		NewStatus: new.Status,
+		OldUntil:  old.Until(),
+		NewUntil:  new.Until(),
+	}
+
+	for _, perm := range ChatPermissions {
+		o, n := old.CanDo(perm), new.CanDo(perm)
+
+		if o != n {
+			diff.Permissions = append(diff.Permissions, ChatPermissionChange{
+				Permission: perm,
+				Old:        o,
+				New:        n,
+			})
+		}
+	}
+
+	return diff
+}
+
+// StatusChanged returns true if member status was changed.
+func (diff ChatMemberDiff) StatusChanged() bool {
+	return diff.OldStatus != diff.NewStatus
+}
+
+// UntilChanged returns true if date of restrictions lifting was changed.
+func (diff ChatMemberDiff) UntilChanged() bool {
+	return !diff.OldUntil.Equal(diff.NewUntil)
+}
+
+// IsEmpty returns true if nothing changed.
+func (diff ChatMemberDiff) IsEmpty() bool {
+	return !diff.StatusChanged() &&
+		!diff.UntilChanged() &&
+		len(diff.Permissions) == 0
+}
+
+// String returns human readable description of changes,
+// e.g. "status: member -> restricted; can_send_messages: true -> false".
+func (diff ChatMemberDiff) String() string {
+	parts := make([]string, 0, len(diff.Permissions)+2)
+
+	if diff.StatusChanged() {
+		parts = append(parts, fmt.Sprintf("status: %s -> %s", diff.OldStatus, diff.NewStatus))
+	}
+
+	if diff.UntilChanged() {
+		parts = append(parts, fmt.Sprintf("until: %s -> %s",
+			formatUntil(diff.OldUntil),
+			formatUntil(diff.NewUntil),
+		))
+	}
+
+	for _, change := range diff.Permissions {
+		parts = append(parts, change.String())
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+func formatUntil(t time.Time) string {
+	if t.IsZero() {
+		return "none"
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
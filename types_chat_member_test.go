@@ -0,0 +1,230 @@
+package tg
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseChatMemberStatus(t *testing.T) {
+	for _, status := range ChatMemberStatuses {
+		v, err := ParseChatMemberStatus(status.String())
+		if assert.NoError(t, err) {
+			assert.Equal(t, status, v)
+			assert.True(t, v.IsKnown())
+		}
+	}
+
+	v, err := ParseChatMemberStatus("unknown")
+	assert.Error(t, err)
+	assert.False(t, v.IsKnown())
+}
+
+func TestChatMemberStatus_MarshalText(t *testing.T) {
+	for _, tt := range []struct {
+		Status  ChatMemberStatus
+		Text    string
+		WantErr bool
+	}{
+		{ChatMemberCreator, "creator", false},
+		{ChatMemberKicked, "kicked", false},
+		{ChatMemberStatus("visitor"), "visitor", false},
+		{ChatMemberStatus(""), "", true},
+	} {
+		text, err := tt.Status.MarshalText()
+
+		if tt.WantErr {
+			assert.Error(t, err)
+		} else if assert.NoError(t, err) {
+			assert.Equal(t, tt.Text, string(text))
+		}
+
+		var status ChatMemberStatus
+
+		err = status.UnmarshalText([]byte(tt.Text))
+
+		if tt.WantErr {
+			assert.Error(t, err)
+		} else if assert.NoError(t, err) {
+			assert.Equal(t, tt.Status, status)
+		}
+	}
+}
+
+func TestChatMember_UnknownStatus(t *testing.T) {
+	var member ChatMember
+
+	err := json.Unmarshal([]byte(`{"user": {"id": 1, "first_name": "Sasha"}, "status": "visitor"}`), &member)
+	require.NoError(t, err)
+
+	assert.Equal(t, ChatMemberStatus("visitor"), member.Status)
+	assert.False(t, member.IsChatMember())
+	assert.False(t, member.CanDo(PermissionSendMessages))
+
+	data, err := json.Marshal(member)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"status":"visitor"`)
+}
+
+func TestChatMember_UnmarshalJSON(t *testing.T) {
+	var member ChatMember
+
+	err := json.Unmarshal([]byte(`{
+		"user": {"id": 1, "first_name": "Sasha"},
+		"status": "restricted",
+		"until_date": 1563000000,
+		"is_member": true,
+		"can_send_messages": true
+	}`), &member)
+
+	require.NoError(t, err)
+
+	assert.Equal(t, ChatMemberRestricted, member.Status)
+	assert.Equal(t, time.Unix(1563000000, 0), member.Until())
+	assert.True(t, member.IsChatMember())
+	assert.True(t, member.CanDo(PermissionSendMessages))
+	assert.False(t, member.CanDo(PermissionSendMediaMessages))
+}
+
+func TestChatMember_Is(t *testing.T) {
+	for _, tt := range []struct {
+		Member     ChatMember
+		Admin      bool
+		IsMember   bool
+		Restricted bool
+		Banned     bool
+	}{
+		{ChatMember{Status: ChatMemberCreator}, true, true, false, false},
+		{ChatMember{Status: ChatMemberAdministrator}, true, true, false, false},
+		{ChatMember{Status: ChatMemberMember}, false, true, false, false},
+		{ChatMember{Status: ChatMemberRestricted, IsMember: true}, false, true, true, false},
+		{ChatMember{Status: ChatMemberRestricted}, false, false, true, false},
+		{ChatMember{Status: ChatMemberLeft}, false, false, false, false},
+		{ChatMember{Status: ChatMemberKicked}, false, false, false, true},
+	} {
+		assert.Equal(t, tt.Admin, tt.Member.IsAdmin(), "IsAdmin of %s", tt.Member.Status)
+		assert.Equal(t, tt.IsMember, tt.Member.IsChatMember(), "IsChatMember of %s", tt.Member.Status)
+		assert.Equal(t, tt.Restricted, tt.Member.IsRestricted(), "IsRestricted of %s", tt.Member.Status)
+		assert.Equal(t, tt.Banned, tt.Member.IsBanned(), "IsBanned of %s", tt.Member.Status)
+	}
+}
+
+func TestChatMember_CanDo(t *testing.T) {
+	creator := ChatMember{Status: ChatMemberCreator}
+	admin := ChatMember{Status: ChatMemberAdministrator, CanPinMessages: true}
+	member := ChatMember{Status: ChatMemberMember}
+	kicked := ChatMember{Status: ChatMemberKicked}
+
+	for _, perm := range ChatPermissions {
+		assert.True(t, creator.CanDo(perm), "creator %s", perm)
+		assert.False(t, kicked.CanDo(perm), "kicked %s", perm)
+		assert.Equal(t, !perm.IsAdministrative(), member.CanDo(perm), "member %s", perm)
+	}
+
+	assert.True(t, admin.CanDo(PermissionPinMessages))
+	assert.False(t, admin.CanDo(PermissionPromoteMembers))
+	assert.True(t, admin.CanDo(PermissionSendMediaMessages))
+}
+
+func TestChatMember_RestrictOptions(t *testing.T) {
+	until := time.Unix(1563000000, 0)
+
+	member := ChatMember{
+		Status:          ChatMemberRestricted,
+		UntilDate:       until.Unix(),
+		IsMember:        true,
+		CanSendMessages: true,
+	}
+
+	opts := member.RestrictOptions()
+
+	assert.Equal(t, RestrictOptions{
+		Until:           until,
+		CanSendMessages: true,
+	}, opts)
+
+	assert.Equal(t, member, opts.ApplyTo(ChatMember{Status: ChatMemberMember}))
+
+	assert.Equal(t,
+		RestrictOptions{
+			CanSendMessages:        true,
+			CanSendMediaMessages:   true,
+			CanSendOtherMessages:   true,
+			CanSendWebPagePreviews: true,
+		},
+		ChatMember{Status: ChatMemberMember}.RestrictOptions(),
+	)
+}
+
+func TestRestrictOptions_ApplyTo(t *testing.T) {
+	all := RestrictOptions{
+		CanSendMessages:        true,
+		CanSendMediaMessages:   true,
+		CanSendOtherMessages:   true,
+		CanSendWebPagePreviews: true,
+	}
+
+	t.Run("Admin", func(t *testing.T) {
+		admin := ChatMember{Status: ChatMemberAdministrator}
+		assert.Equal(t, admin, RestrictOptions{}.ApplyTo(admin))
+	})
+
+	t.Run("Lift", func(t *testing.T) {
+		assert.Equal(t,
+			ChatMemberMember,
+			all.ApplyTo(ChatMember{Status: ChatMemberRestricted, IsMember: true}).Status,
+		)
+
+		assert.Equal(t,
+			ChatMemberLeft,
+			all.ApplyTo(ChatMember{Status: ChatMemberRestricted}).Status,
+		)
+	})
+}
+
+func TestDiffChatMember(t *testing.T) {
+	user := User{ID: 1, FirstName: "Sasha"}
+
+	t.Run("Empty", func(t *testing.T) {
+		member := ChatMember{User: user, Status: ChatMemberMember}
+
+		diff := DiffChatMember(member, member)
+
+		assert.True(t, diff.IsEmpty())
+		assert.Equal(t, "", diff.String())
+	})
+
+	t.Run("Restrict", func(t *testing.T) {
+		until := time.Date(2019, 7, 13, 0, 0, 0, 0, time.UTC)
+
+		old := ChatMember{User: user, Status: ChatMemberMember}
+		new := RestrictOptions{
+			Until:           until,
+			CanSendMessages: true,
+		}.ApplyTo(old)
+
+		diff := DiffChatMember(old, new)
+
+		assert.False(t, diff.IsEmpty())
+		assert.True(t, diff.StatusChanged())
+		assert.True(t, diff.UntilChanged())
+
+		assert.Equal(t, []ChatPermissionChange{
+			{PermissionSendMediaMessages, true, false},
+			{PermissionSendOtherMessages, true, false},
+			{PermissionAddWebPagePreviews, true, false},
+		}, diff.Permissions)
+
+		assert.Equal(t,
+			"status: member -> restricted; "+
+				"until: none -> 2019-07-13T00:00:00Z; "+
+				"can_send_media_messages: true -> false; "+
+				"can_send_other_messages: true -> false; "+
+				"can_add_web_page_previews: true -> false",
+			diff.String(),
+		)
+	})
+}
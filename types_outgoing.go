@@ -337,3 +337,93 @@ func (msg *AudioMessage) BuildSendRequest() (*Request, error) {
 
 	return addOptReplyMarkupToRequest(r, "reply_markup", msg.ReplyMarkup)
 }
+
+// DocumentMessage represents outgoing general file message.
+// Bots can currently send files of any type of up to 50 MB in size, this limit may be changed in the future.
+//
+// Related API method: https://core.telegram.org/bots/api#senddocument
+type DocumentMessage struct {
+	// Recipient of document message.
+	Peer Peer
+
+	// Document media to send (InputFile, FileID, RemoteFile).
+	Document Media
+
+	// Caption of document (0-1024).
+	Caption string
+
+	// Parse mode of caption.
+	ParseMode ParseMode
+
+	// Thumbnail of the file sent.
+	// Can be ignored if thumbnail generation for the file is supported server-side.
+	// The thumbnail should be in JPEG format and less than 200 kB in size.
+	Thumb *InputFile
+
+	// Pass true for send message silent.
+	DisableNotification bool
+
+	// Reply to message identity.
+	ReplyTo MessageIdentity
+
+	// Reply markup of the message.
+	ReplyMarkup ReplyMarkup
+}
+
+// NewDocumentMessage creates outgoing document message.
+func NewDocumentMessage(to Peer, document Media) *DocumentMessage {
+	return &DocumentMessage{
+		Peer:     to,
+		Document: document,
+	}
+}
+
+// WithCaption sets message caption.
+func (msg *DocumentMessage) WithCaption(text string) *DocumentMessage {
+	msg.Caption = text
+	return msg
+}
+
+// WithThumb sets document thumbnail.
+func (msg *DocumentMessage) WithThumb(thumb InputFile) *DocumentMessage {
+	msg.Thumb = &thumb
+	return msg
+}
+
+// WithParseMode sets caption parse mode.
+func (msg *DocumentMessage) WithParseMode(pm ParseMode) *DocumentMessage {
+	msg.ParseMode = pm
+	return msg
+}
+
+// WithNotification enable or disable notification (default: enabled).
+func (msg *DocumentMessage) WithNotification(yes bool) *DocumentMessage {
+	msg.DisableNotification = !yes
+	return msg
+}
+
+// WithReplyTo sets ids of original message, if message is reply.
+func (msg *DocumentMessage) WithReplyTo(msgID MessageIdentity) *DocumentMessage {
+	msg.ReplyTo = msgID
+	return msg
+}
+
+// WithReplyMarkup sets message reply markup.
+func (msg *DocumentMessage) WithReplyMarkup(rm ReplyMarkup) *DocumentMessage {
+	msg.ReplyMarkup = rm
+	return msg
+}
+
+func (msg *DocumentMessage) BuildSendRequest() (*Request, error) {
+	r := NewRequest("sendDocument").
+		AddChatID(msg.Peer).
+		AddOptString("caption", msg.Caption).
+		AddOptString("parse_mode", msg.ParseMode.String()).
+		AddOptBool("disable_notification", msg.DisableNotification).
+		AddOptAttachment("thumb", msg.Thumb)
+
+	addMediaToRequest(r, "document", msg.Document)
+	addOptMessageIdentityToRequest(r, "reply_to_message_id", msg.ReplyTo)
+
+	return addOptReplyMarkupToRequest(r, "reply_markup", msg.ReplyMarkup)
+}
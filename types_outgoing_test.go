@@ -218,3 +218,65 @@ func TestAudioMessage(t *testing.T) {
 		}
 	})
 }
+
+func TestDocumentMessage(t *testing.T) {
+	inputFile := NewInputFileBytes("document.txt", []byte("no data"))
+	thumbFile := NewInputFileBytes("thumb.png", []byte("no thumb data"))
+
+	t.Run("NewAndWith", func(t *testing.T) {
+		assert.Equal(t,
+			&DocumentMessage{
+				Peer:                UserID(1),
+				Document:            inputFile,
+				Thumb:               &thumbFile,
+				Caption:             "test",
+				ParseMode:           HTML,
+				DisableNotification: true,
+				ReplyTo:             MessageID(1),
+				ReplyMarkup:         NewForceReply(),
+			},
+			NewDocumentMessage(UserID(1), inputFile).
+				WithCaption("test").
+				WithThumb(thumbFile).
+				WithParseMode(HTML).
+				WithNotification(false).
+				WithReplyTo(MessageID(1)).
+				WithReplyMarkup(NewForceReply()),
+		)
+	})
+
+	t.Run("BuildSendRequest", func(t *testing.T) {
+		msg := NewDocumentMessage(UserID(1), inputFile).
+			WithCaption("test").
+			WithThumb(thumbFile).
+			WithParseMode(HTML).
+			WithNotification(false).
+			WithReplyTo(MessageID(1)).
+			WithReplyMarkup(NewForceReply())
+
+		r, err := msg.BuildSendRequest()
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "sendDocument", r.Method())
+
+			args := extractArgs(r)
+
+			assert.Equal(t, map[string]string{
+				"chat_id":              "1",
+				"caption":              "test",
+				"parse_mode":           "HTML",
+				"disable_notification": "true",
+				"reply_markup":         `{"force_reply":true,"selective":false}`,
+				"reply_to_message_id":  "1",
+				"thumb":                "attach://__0__",
+			}, args)
+
+			files := extractFiles(r)
+
+			assert.Equal(t, map[string]InputFile{
+				"document": inputFile,
+				"__0__":    thumbFile,
+			}, files)
+		}
+	})
+}
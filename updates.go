@@ -188,3 +188,55 @@ func ParseUpdateType(v string) (UpdateType, error) {
 		return UpdateType(0), errUpdateTypeUnknown
 	}
 }
+
+// Chat returns chat where update happened.
+// Returns nil for updates not related to any chat (e.g. inline queries).
+func (u Update) Chat() *Chat {
+	if msg := u.message(); msg != nil {
+		return &msg.Chat
+	}
+
+	if u.CallbackQuery != nil && u.CallbackQuery.Message != nil {
+		return &u.CallbackQuery.Message.Chat
+	}
+
+	return nil
+}
+
+// From returns sender of update.
+// Returns nil for updates without sender (e.g. channel posts and polls).
+func (u Update) From() *User {
+	if msg := u.message(); msg != nil {
+		return msg.From
+	}
+
+	switch {
+	case u.InlineQuery != nil:
+		return &u.InlineQuery.From
+	case u.ChosenInlineResult != nil:
+		return &u.ChosenInlineResult.From
+	case u.CallbackQuery != nil:
+		return &u.CallbackQuery.From
+	case u.ShippingQuery != nil:
+		return &u.ShippingQuery.From
+	case u.PreCheckoutQuery != nil:
+		return &u.PreCheckoutQuery.From
+	default:
+		return nil
+	}
+}
+
+func (u Update) message() *Message {
+	switch {
+	case u.Message != nil:
+		return u.Message
+	case u.EditedMessage != nil:
+		return u.EditedMessage
+	case u.ChannelPost != nil:
+		return u.ChannelPost
+	case u.EditedChannelPost != nil:
+		return u.EditedChannelPost
+	default:
+		return nil
+	}
+}
@@ -124,3 +124,29 @@ func TestUpdateType_UnmarshalText(t *testing.T) {
 		}
 	}
 }
+
+func TestUpdate_ChatAndFrom(t *testing.T) {
+	user := User{ID: 1}
+	chat := Chat{ID: 2}
+
+	for _, tt := range []struct {
+		Input Update
+		Chat  *Chat
+		From  *User
+	}{
+		{Update{Message: &Message{Chat: chat, From: &user}}, &chat, &user},
+		{Update{EditedMessage: &Message{Chat: chat, From: &user}}, &chat, &user},
+		{Update{ChannelPost: &Message{Chat: chat}}, &chat, nil},
+		{Update{EditedChannelPost: &Message{Chat: chat}}, &chat, nil},
+		{Update{InlineQuery: &InlineQuery{From: user}}, nil, &user},
+		{Update{ChosenInlineResult: &ChosenInlineResult{From: user}}, nil, &user},
+		{Update{CallbackQuery: &CallbackQuery{From: user, Message: &Message{Chat: chat}}}, &chat, &user},
+		{Update{CallbackQuery: &CallbackQuery{From: user}}, nil, &user},
+		{Update{ShippingQuery: &ShippingQuery{From: user}}, nil, &user},
+		{Update{PreCheckoutQuery: &PreCheckoutQuery{From: user}}, nil, &user},
+		{Update{Poll: &Poll{}}, nil, nil},
+	} {
+		assert.Equal(t, tt.Chat, tt.Input.Chat(), "chat of %s", tt.Input.Type())
+		assert.Equal(t, tt.From, tt.Input.From(), "from of %s", tt.Input.Type())
+	}
+}
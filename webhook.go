@@ -0,0 +1,71 @@
+package tg
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// Webhook it's http.Handler which receives updates sent by Telegram and passes them to the Handler.
+//
+// If handler returns ErrExecutorBusy, Webhook responds with 503 Service Unavailable,
+// so Telegram will repeat delivery later.
+// Other handler errors are passed to error handler and not reported to Telegram,
+// otherwise Telegram will deliver failed update again and again.
+type Webhook struct {
+	handler Handler
+	onError func(err error)
+}
+
+// WebhookOption use this for configure webhook.
+type WebhookOption func(webhook *Webhook)
+
+// WithWebhookErrorHandler sets callback for decoding and handler errors.
+// By default, errors are ignored.
+func WithWebhookErrorHandler(onError func(err error)) WebhookOption {
+	return func(webhook *Webhook) {
+		webhook.onError = onError
+	}
+}
+
+// NewWebhook creates webhook HTTP handler.
+func NewWebhook(handler Handler, opts ...WebhookOption) *Webhook {
+	webhook := &Webhook{
+		handler: handler,
+		onError: func(err error) {},
+	}
+
+	for _, opt := range opts {
+		opt(webhook)
+	}
+
+	return webhook
+}
+
+func (webhook *Webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	update := &Update{}
+
+	if err := json.NewDecoder(r.Body).Decode(update); err != nil {
+		webhook.onError(errors.Wrap(err, "decode update"))
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	if err := webhook.handler.HandleUpdate(r.Context(), update); err != nil {
+		if errors.Cause(err) == ErrExecutorBusy {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+
+		webhook.onError(errors.Wrapf(err, "handle update %d", update.ID))
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
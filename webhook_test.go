@@ -0,0 +1,96 @@
+package tg
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhook_ServeHTTP(t *testing.T) {
+	serve := func(webhook *Webhook, method string, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, "/webhook", strings.NewReader(body))
+
+		webhook.ServeHTTP(w, r)
+
+		return w
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		var received *Update
+
+		webhook := NewWebhook(HandlerFunc(func(ctx context.Context, update *Update) error {
+			received = update
+			return nil
+		}))
+
+		w := serve(webhook, http.MethodPost, `{"update_id": 1, "message": {"text": "hello"}}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		if assert.NotNil(t, received) {
+			assert.Equal(t, UpdateID(1), received.ID)
+			assert.Equal(t, "hello", received.Message.Text)
+		}
+	})
+
+	t.Run("MethodNotAllowed", func(t *testing.T) {
+		webhook := NewWebhook(HandlerFunc(func(ctx context.Context, update *Update) error {
+			return nil
+		}))
+
+		w := serve(webhook, http.MethodGet, "")
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+
+	t.Run("BadRequest", func(t *testing.T) {
+		var errs []string
+
+		webhook := NewWebhook(
+			HandlerFunc(func(ctx context.Context, update *Update) error {
+				return nil
+			}),
+			WithWebhookErrorHandler(func(err error) {
+				errs = append(errs, err.Error())
+			}),
+		)
+
+		w := serve(webhook, http.MethodPost, "{")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Len(t, errs, 1)
+	})
+
+	t.Run("HandlerError", func(t *testing.T) {
+		var errs []string
+
+		webhook := NewWebhook(
+			HandlerFunc(func(ctx context.Context, update *Update) error {
+				return errors.New("handler error")
+			}),
+			WithWebhookErrorHandler(func(err error) {
+				errs = append(errs, err.Error())
+			}),
+		)
+
+		w := serve(webhook, http.MethodPost, `{"update_id": 1}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{"handle update 1: handler error"}, errs)
+	})
+
+	t.Run("Busy", func(t *testing.T) {
+		webhook := NewWebhook(HandlerFunc(func(ctx context.Context, update *Update) error {
+			return ErrExecutorBusy
+		}))
+
+		w := serve(webhook, http.MethodPost, `{"update_id": 1}`)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}